@@ -0,0 +1,141 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DistinctAccuracy accuracy of the number of distinct values counted by CountDistinct
+type DistinctAccuracy int
+
+const (
+	Exact          DistinctAccuracy = iota // uniqExact, exact but memory grows with the number of distinct values
+	Approx                                 // uniq, adaptive sampling with an error around 1%
+	ApproxCombined                         // uniqCombined, HyperLogLog with less memory and error than uniq on large sets
+)
+
+// AggregateFunc an aggregate function call, parameters of parametric functions are written before the
+// arguments, e.g. quantile(0.95)(`duration`), composed with Select and Having
+//
+//	db.Model(&Event{}).Select("country, ?, ?", clickhouse.Uniq("user_id").As("users"), clickhouse.Quantile(0.95, "duration").As("p95")).
+//		Group("country").Having("? > ?", clickhouse.Uniq("user_id"), 100).Find(&stats)
+type AggregateFunc struct {
+	Func   string
+	Params []float64
+	Args   []interface{}
+	Alias  string
+}
+
+// As sets the alias of the aggregate function result
+func (f AggregateFunc) As(alias string) AggregateFunc {
+	f.Alias = alias
+	return f
+}
+
+// Build implements clause.Expression interface
+func (f AggregateFunc) Build(builder clause.Builder) {
+	builder.WriteString(f.Func)
+	if len(f.Params) > 0 {
+		builder.WriteByte('(')
+		for idx, param := range f.Params {
+			if idx > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteString(strconv.FormatFloat(param, 'f', -1, 64))
+		}
+		builder.WriteByte(')')
+	}
+
+	builder.WriteByte('(')
+	for idx, arg := range f.Args {
+		if idx > 0 {
+			builder.WriteByte(',')
+		}
+
+		switch v := arg.(type) {
+		case clause.Column, clause.Table:
+			builder.WriteQuoted(v)
+		default:
+			builder.AddVar(builder, v)
+		}
+	}
+	builder.WriteByte(')')
+
+	if f.Alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteQuoted(f.Alias)
+	}
+}
+
+func aggregateColumns(columns []string) []interface{} {
+	args := make([]interface{}, len(columns))
+	for idx, column := range columns {
+		args[idx] = clause.Column{Name: column}
+	}
+	return args
+}
+
+// Uniq uniq(columns...) approximate number of distinct values
+func Uniq(columns ...string) AggregateFunc {
+	return AggregateFunc{Func: "uniq", Args: aggregateColumns(columns)}
+}
+
+// UniqExact uniqExact(columns...) exact number of distinct values, uses more memory than Uniq
+func UniqExact(columns ...string) AggregateFunc {
+	return AggregateFunc{Func: "uniqExact", Args: aggregateColumns(columns)}
+}
+
+// UniqCombined uniqCombined(columns...) approximate number of distinct values using less memory than Uniq
+func UniqCombined(columns ...string) AggregateFunc {
+	return AggregateFunc{Func: "uniqCombined", Args: aggregateColumns(columns)}
+}
+
+// CountDistinct counts the distinct values of column with uniqExact, uniq or uniqCombined by accuracy, which
+// are much faster than count(DISTINCT column) of Distinct and Count on large tables
+//
+//	count, err := clickhouse.CountDistinct(db.Model(&Event{}).Where("date = ?", date), "user_id", clickhouse.Approx)
+func CountDistinct(db *gorm.DB, column string, accuracy DistinctAccuracy) (int64, error) {
+	var fn AggregateFunc
+	switch accuracy {
+	case Exact:
+		fn = UniqExact(column)
+	case Approx:
+		fn = Uniq(column)
+	case ApproxCombined:
+		fn = UniqCombined(column)
+	default:
+		return 0, fmt.Errorf("%w: unknown distinct accuracy %d", gorm.ErrInvalidData, accuracy)
+	}
+
+	tx := db.Session(&gorm.Session{}).Select("?", fn)
+	if _, ok := tx.Statement.Clauses["GROUP BY"]; !ok {
+		delete(tx.Statement.Clauses, "ORDER BY")
+	}
+
+	var count uint64
+	err := tx.Scan(&count).Error
+	return int64(count), err
+}
+
+// Quantile quantile(level)(column) approximate quantile of the column, level between 0 and 1
+func Quantile(level float64, column string) AggregateFunc {
+	return AggregateFunc{Func: "quantile", Params: []float64{level}, Args: aggregateColumns([]string{column})}
+}
+
+// ArgMax argMax(arg, val) value of arg of the row with the maximum val
+func ArgMax(arg, val string) AggregateFunc {
+	return AggregateFunc{Func: "argMax", Args: aggregateColumns([]string{arg, val})}
+}
+
+// ArgMin argMin(arg, val) value of arg of the row with the minimum val
+func ArgMin(arg, val string) AggregateFunc {
+	return AggregateFunc{Func: "argMin", Args: aggregateColumns([]string{arg, val})}
+}
+
+// TopK topK(k)(column) array of the approximately k most frequent values of the column
+func TopK(k int, column string) AggregateFunc {
+	return AggregateFunc{Func: "topK", Params: []float64{float64(k)}, Args: aggregateColumns([]string{column})}
+}
@@ -0,0 +1,174 @@
+package clickhouse
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// ScanArray returns a sql.Scanner scanning an Array column into dest, a pointer to a slice, converting
+// the elements to the element type of dest, e.g. Array(Nullable(UInt32)) into *[]int or Array(Array(String))
+// into *[][]string, NULL elements are scanned as zero values unless the elements are pointers
+//
+//	rows.Scan(&id, clickhouse.ScanArray(&tags))
+func ScanArray(dest interface{}) sql.Scanner {
+	return arrayScanner{dest: dest}
+}
+
+type arrayScanner struct {
+	dest interface{}
+}
+
+// Scan implements sql.Scanner interface
+func (s arrayScanner) Scan(src interface{}) error {
+	dest := reflect.ValueOf(s.dest)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return fmt.Errorf("%w: ScanArray dest should be a pointer, got %T", gorm.ErrInvalidData, s.dest)
+	}
+	return assignValue(dest.Elem(), reflect.ValueOf(src))
+}
+
+// isArrayDest reports whether dest points to a slice which is not scanned by database/sql or a sql.Scanner
+func isArrayDest(dest interface{}) bool {
+	if _, ok := dest.(sql.Scanner); ok {
+		return false
+	}
+
+	t := reflect.TypeOf(dest)
+	for t != nil && t.Kind() == reflect.Ptr {
+		if t.Implements(scannerType) {
+			return false
+		}
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// assignValue assigns src to dst converting slices element by element, numbers to other number types
+// and dereferencing pointers, nil values are assigned as zero values
+func assignValue(dst, src reflect.Value) error {
+	for src.IsValid() && (src.Kind() == reflect.Interface || src.Kind() == reflect.Ptr) {
+		if src.IsNil() {
+			src = reflect.Value{}
+			break
+		}
+		if src.Type().AssignableTo(dst.Type()) {
+			break
+		}
+		src = src.Elem()
+	}
+
+	if !src.IsValid() {
+		dst.SetZero()
+		return nil
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		value := reflect.New(dst.Type().Elem())
+		if err := assignValue(value.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(value)
+		return nil
+	}
+
+	if dst.CanAddr() && dst.Addr().Type().Implements(scannerType) {
+		return dst.Addr().Interface().(sql.Scanner).Scan(src.Interface())
+	}
+
+	switch dst.Kind() {
+	case reflect.Slice:
+		if src.Kind() == reflect.Slice || src.Kind() == reflect.Array {
+			slice := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+			for i := 0; i < src.Len(); i++ {
+				if err := assignValue(slice.Index(i), src.Index(i)); err != nil {
+					return err
+				}
+			}
+			dst.Set(slice)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if isNumberKind(src.Kind()) {
+			dst.Set(src.Convert(dst.Type()))
+			return nil
+		}
+	case reflect.String, reflect.Bool:
+		if src.Kind() == dst.Kind() {
+			dst.Set(src.Convert(dst.Type()))
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: can not scan %s into %s", gorm.ErrInvalidData, src.Type(), dst.Type())
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// arrayRows scans Array columns into slice destinations with ScanArray
+type arrayRows struct {
+	*sql.Rows
+}
+
+func (rows arrayRows) Scan(dest ...interface{}) error {
+	var wrapped []interface{}
+	for idx, d := range dest {
+		if isArrayDest(d) {
+			if wrapped == nil {
+				wrapped = append([]interface{}(nil), dest...)
+			}
+			wrapped[idx] = arrayScanner{dest: d}
+		}
+	}
+
+	if wrapped == nil {
+		return rows.Rows.Scan(dest...)
+	}
+	return rows.Rows.Scan(wrapped...)
+}
+
+// query replaces gorm:query scanning the rows with arrayRows
+func query(db *gorm.DB) {
+	if db.Error != nil || queryPreloadInChunks(db) {
+		return
+	}
+	queryRows(db)
+}
+
+func queryRows(db *gorm.DB) {
+	callbacks.BuildQuerySQL(db)
+	if db.DryRun || db.Error != nil {
+		return
+	}
+
+	rows, err := db.Statement.ConnPool.QueryContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	defer func() {
+		db.AddError(rows.Close())
+	}()
+	gorm.Scan(newFlattenedRows(db.Statement, arrayRows{Rows: rows}), db, 0)
+
+	if db.Statement.Result != nil {
+		db.Statement.Result.RowsAffected = db.RowsAffected
+	}
+}
@@ -0,0 +1,134 @@
+package clickhouse
+
+import (
+	"context"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+// AsyncStatus state of a query started with ExecAsync
+type AsyncStatus string
+
+const (
+	AsyncRunning  AsyncStatus = "running"
+	AsyncFinished AsyncStatus = "finished"
+	AsyncFailed   AsyncStatus = "failed"
+	AsyncUnknown  AsyncStatus = "unknown" // neither running nor found in system.query_log, which is flushed periodically
+)
+
+// AsyncQuery handle of a query started with ExecAsync, queries started by other processes are polled
+// with a handle of their ID, e.g. clickhouse.PollResult(db, &clickhouse.AsyncQuery{ID: id})
+type AsyncQuery struct {
+	ID string
+
+	done chan struct{}
+	err  error
+}
+
+// AsyncResult progress or outcome of a query started with ExecAsync
+type AsyncResult struct {
+	Status      AsyncStatus
+	ReadRows    uint64
+	WrittenRows uint64
+	Elapsed     time.Duration
+	Err         error // error of the failed query
+}
+
+// ExecAsync starts executing sql in the background and returns right away, the query keeps running when
+// the context of db is cancelled, e.g. heavy aggregations triggered by web handlers
+//
+//	query := clickhouse.ExecAsync(db, "INSERT INTO daily_stats SELECT day, count() FROM events GROUP BY day")
+//	result, err := clickhouse.PollResult(db, query)
+func ExecAsync(db *gorm.DB, sql string, values ...interface{}) *AsyncQuery {
+	query := &AsyncQuery{ID: newQueryID(), done: make(chan struct{})}
+	tx := WithQueryID(db.WithContext(context.WithoutCancel(db.Statement.Context)), query.ID)
+
+	go func() {
+		defer close(query.done)
+		query.err = tx.Exec(sql, values...).Error
+	}()
+	return query
+}
+
+// PollResult returns the state of query from system.processes and system.query_log, falling back to the
+// outcome observed by ExecAsync while the query log is not flushed yet
+func PollResult(db *gorm.DB, query *AsyncQuery) (result AsyncResult, err error) {
+	var process struct {
+		ReadRows    uint64
+		WrittenRows uint64
+		Elapsed     float64
+	}
+	tx := db.Raw("SELECT read_rows, written_rows, elapsed FROM system.processes WHERE query_id = ?", query.ID).Scan(&process)
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+	if tx.RowsAffected > 0 {
+		return AsyncResult{
+			Status:      AsyncRunning,
+			ReadRows:    process.ReadRows,
+			WrittenRows: process.WrittenRows,
+			Elapsed:     time.Duration(process.Elapsed * float64(time.Second)),
+		}, nil
+	}
+
+	var logged struct {
+		Type            string
+		ReadRows        uint64
+		WrittenRows     uint64
+		QueryDurationMs uint64
+		Exception       string
+		ExceptionCode   int32
+	}
+	tx = db.Raw(
+		"SELECT type, read_rows, written_rows, query_duration_ms, exception, exception_code FROM system.query_log "+
+			"WHERE query_id = ? AND type != 'QueryStart' ORDER BY event_time_microseconds DESC LIMIT 1",
+		query.ID,
+	).Scan(&logged)
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+	if tx.RowsAffected > 0 {
+		result = AsyncResult{
+			Status:      AsyncFinished,
+			ReadRows:    logged.ReadRows,
+			WrittenRows: logged.WrittenRows,
+			Elapsed:     time.Duration(logged.QueryDurationMs) * time.Millisecond,
+		}
+		if logged.Type != "QueryFinish" {
+			result.Status = AsyncFailed
+			result.Err = &clickhouse.Exception{Code: logged.ExceptionCode, Message: logged.Exception}
+		}
+		return result, nil
+	}
+
+	if query.done == nil {
+		return AsyncResult{Status: AsyncUnknown}, nil
+	}
+
+	select {
+	case <-query.done:
+		if query.err != nil {
+			return AsyncResult{Status: AsyncFailed, Err: query.err}, nil
+		}
+		return AsyncResult{Status: AsyncFinished}, nil
+	default:
+		return AsyncResult{Status: AsyncRunning}, nil
+	}
+}
+
+// Wait blocks until the query started with ExecAsync finishes and returns its error, handles of queries
+// started by other processes return nil right away
+func (query *AsyncQuery) Wait(ctx context.Context) error {
+	if query.done == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-query.done:
+		return query.err
+	}
+}
@@ -0,0 +1,51 @@
+package clickhouse_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestExecAsync(t *testing.T) {
+	type AsyncStat struct {
+		Number uint64
+	}
+
+	DB.Migrator().DropTable(&AsyncStat{})
+	if err := DB.AutoMigrate(&AsyncStat{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	query := clickhouse.ExecAsync(DB, "INSERT INTO async_stats SELECT number FROM numbers(?)", 1000)
+	if query.ID == "" {
+		t.Fatalf("expects a query id")
+	}
+
+	if err := query.Wait(context.Background()); err != nil {
+		t.Fatalf("failed to execute async query, got error %v", err)
+	}
+
+	result, err := clickhouse.PollResult(DB, query)
+	if err != nil {
+		t.Fatalf("failed to poll result, got error %v", err)
+	}
+	if result.Status != clickhouse.AsyncFinished || result.Err != nil {
+		t.Errorf("expects finished query, got %+v", result)
+	}
+
+	var count int64
+	if err := DB.Model(&AsyncStat{}).Count(&count).Error; err != nil || count != 1000 {
+		t.Errorf("expects 1000 rows, got %v, error %v", count, err)
+	}
+
+	failed := clickhouse.ExecAsync(DB, "INSERT INTO async_stats SELECT number FROM not_exists_table")
+	failed.Wait(context.Background())
+	if result, err := clickhouse.PollResult(DB, failed); err != nil || result.Status != clickhouse.AsyncFailed || result.Err == nil {
+		t.Errorf("expects failed query, got %+v, error %v", result, err)
+	}
+
+	if result, err := clickhouse.PollResult(DB, &clickhouse.AsyncQuery{ID: "not-exists"}); err != nil || result.Status != clickhouse.AsyncUnknown {
+		t.Errorf("expects unknown query, got %+v, error %v", result, err)
+	}
+}
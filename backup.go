@@ -0,0 +1,141 @@
+package clickhouse
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBackupFailed returned by WaitBackup when a backup or restore failed
+var ErrBackupFailed = errors.New("backup failed")
+
+// Backup status of a backup or restore in system.backups
+type Backup struct {
+	ID               string
+	Name             string
+	Status           string
+	Error            string
+	StartTime        time.Time
+	EndTime          time.Time
+	NumFiles         uint64
+	TotalSize        uint64
+	UncompressedSize uint64
+}
+
+// Done reports whether the backup or restore is finished
+func (backup Backup) Done() bool {
+	return backup.Status != "CREATING_BACKUP" && backup.Status != "RESTORING"
+}
+
+// Failed reports whether the backup or restore failed
+func (backup Backup) Failed() bool {
+	return strings.HasSuffix(backup.Status, "_FAILED")
+}
+
+// BackupDisk returns the destination of a backup at path of a disk of the server, e.g. BackupDisk("backups", "events.zip")
+func BackupDisk(disk string, path string) string {
+	return tableFunction("Disk", disk, path)
+}
+
+// BackupS3 returns the destination of a backup in a s3 bucket
+func BackupS3(url string, credentials S3Credentials) string {
+	if credentials.AccessKeyID == "" {
+		return tableFunction("S3", url)
+	}
+	return tableFunction("S3", url, credentials.AccessKeyID, credentials.SecretAccessKey)
+}
+
+// backupTables runs BACKUP or RESTORE of tables, models or table names, from or to target
+func backupTables(db *gorm.DB, action string, target string, async bool, tables []interface{}) (backup Backup, err error) {
+	if len(tables) == 0 {
+		return backup, fmt.Errorf("%w: no tables to %s", gorm.ErrInvalidValue, strings.ToLower(action))
+	}
+
+	var (
+		sql  strings.Builder
+		vars = make([]interface{}, 0, len(tables))
+	)
+	sql.WriteString(action)
+	for idx, table := range tables {
+		name, err := tableNameOf(db, table)
+		if err != nil {
+			return backup, err
+		}
+		if idx > 0 {
+			sql.WriteByte(',')
+		}
+		sql.WriteString(" TABLE ?")
+		vars = append(vars, clause.Table{Name: name})
+	}
+
+	if action == "BACKUP" {
+		sql.WriteString(" TO ")
+	} else {
+		sql.WriteString(" FROM ")
+	}
+	sql.WriteString(target)
+	if async {
+		sql.WriteString(" ASYNC")
+	}
+
+	if err = db.Raw(sql.String(), vars...).Row().Scan(&backup.ID, &backup.Status); err != nil {
+		return backup, err
+	}
+	if backup.Failed() {
+		return backup, fmt.Errorf("%w: %s", ErrBackupFailed, backup.Status)
+	}
+	return backup, nil
+}
+
+// BackupTables backs up tables, models or table names, to dest, returns immediately with the backup id when async, e.g.
+//
+//	clickhouse.BackupTables(db, clickhouse.BackupDisk("backups", "events.zip"), true, &Event{})
+func BackupTables(db *gorm.DB, dest string, async bool, tables ...interface{}) (Backup, error) {
+	return backupTables(db, "BACKUP", dest, async, tables)
+}
+
+// RestoreTables restores tables, models or table names, from the backup at src, returns immediately with the restore id when async
+func RestoreTables(db *gorm.DB, src string, async bool, tables ...interface{}) (Backup, error) {
+	return backupTables(db, "RESTORE", src, async, tables)
+}
+
+// GetBackup returns the status of the backup or restore with id from system.backups, gorm.ErrRecordNotFound
+// if there is none
+func GetBackup(db *gorm.DB, id string) (backup Backup, err error) {
+	err = db.Raw(
+		"SELECT id, name, toString(status) AS status, error, start_time, end_time, num_files, total_size, uncompressed_size FROM system.backups WHERE id = ?", id,
+	).Row().Scan(&backup.ID, &backup.Name, &backup.Status, &backup.Error, &backup.StartTime, &backup.EndTime, &backup.NumFiles, &backup.TotalSize, &backup.UncompressedSize)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = gorm.ErrRecordNotFound
+	}
+	return
+}
+
+// WaitBackup polls system.backups every interval until the async backup or restore with id is finished,
+// returns ErrBackupFailed if it failed
+func WaitBackup(db *gorm.DB, id string, interval time.Duration) (Backup, error) {
+	for {
+		backup, err := GetBackup(db, id)
+		if err != nil {
+			return backup, err
+		}
+
+		if backup.Done() {
+			if backup.Failed() {
+				return backup, fmt.Errorf("%w: %s", ErrBackupFailed, backup.Error)
+			}
+			return backup, nil
+		}
+
+		select {
+		case <-db.Statement.Context.Done():
+			return backup, db.Statement.Context.Err()
+		case <-time.After(interval):
+		}
+	}
+}
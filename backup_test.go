@@ -0,0 +1,121 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+	"gorm.io/gorm"
+)
+
+func TestBackupTables(t *testing.T) {
+	dest := clickhouse.BackupDisk("backups", "users-"+time.Now().Format("20060102150405")+".zip")
+	if !strings.HasPrefix(dest, "Disk('backups', 'users-") {
+		t.Errorf("unexpected backup destination %v", dest)
+	}
+
+	if _, err := clickhouse.GetBackup(DB, "not-exists"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("unknown backup should return ErrRecordNotFound, got %v", err)
+	}
+
+	backup, err := clickhouse.BackupTables(DB, dest, true, &User{})
+	if err != nil {
+		// the backups disk is only available when configured in the server
+		if clickhouseerr.IsSyntaxError(err) {
+			t.Fatalf("backup statement should be valid, got error %v", err)
+		}
+		t.Skipf("backups disk isn't available, got error %v", err)
+	}
+
+	if backup, err = clickhouse.WaitBackup(DB, backup.ID, 100*time.Millisecond); err != nil || backup.Status != "BACKUP_CREATED" {
+		t.Fatalf("backup should be created, got %+v, error %v", backup, err)
+	}
+}
+
+func TestBackupTablesMock(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	backupColumns := []string{"id", "name", "status", "error", "start_time", "end_time", "num_files", "total_size", "uncompressed_size"}
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.Returns("^BACKUP ", []string{"id", "status"}, []interface{}{"b1", "CREATING_BACKUP"})
+	mock.Returns("FROM system.backups", backupColumns,
+		[]interface{}{"b1", "Disk('backups', 'users.zip')", "CREATING_BACKUP", "", startTime, time.Time{}, uint64(0), uint64(0), uint64(0)})
+
+	dest := clickhouse.BackupDisk("backups", "users.zip")
+	backup, err := clickhouse.BackupTables(mockDB, dest, true, &User{}, "orders")
+	if err != nil || backup.ID != "b1" || backup.Status != "CREATING_BACKUP" {
+		t.Fatalf("expects async backup b1, got %+v, error %v", backup, err)
+	}
+
+	type result struct {
+		backup clickhouse.Backup
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		backup, err := clickhouse.WaitBackup(mockDB, backup.ID, 10*time.Millisecond)
+		done <- result{backup, err}
+	}()
+
+	// the backup is created after it was polled once
+	for polled := false; !polled; time.Sleep(time.Millisecond) {
+		for _, sql := range mock.SQL() {
+			polled = polled || strings.Contains(sql, "FROM system.backups")
+		}
+	}
+	mock.Returns("FROM system.backups", backupColumns,
+		[]interface{}{"b1", "Disk('backups', 'users.zip')", "BACKUP_CREATED", "", startTime, startTime.Add(time.Second), uint64(10), uint64(1024), uint64(4096)})
+
+	select {
+	case r := <-done:
+		if r.err != nil || r.backup.Status != "BACKUP_CREATED" || r.backup.NumFiles != 10 || r.backup.TotalSize != 1024 {
+			t.Fatalf("expects created backup, got %+v, error %v", r.backup, r.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("backup wasn't waited, got %v", mock.SQL())
+	}
+
+	mock.Returns("^RESTORE ", []string{"id", "status"}, []interface{}{"r1", "RESTORED"})
+	if backup, err = clickhouse.RestoreTables(mockDB, dest, false, &User{}); err != nil || backup.ID != "r1" {
+		t.Fatalf("expects restore r1, got %+v, error %v", backup, err)
+	}
+
+	mock.Returns("^RESTORE ", []string{"id", "status"}, []interface{}{"r2", "RESTORE_FAILED"})
+	if _, err = clickhouse.RestoreTables(mockDB, dest, true, "orders"); !errors.Is(err, clickhouse.ErrBackupFailed) {
+		t.Errorf("expects ErrBackupFailed, got %v", err)
+	}
+
+	var polls int
+	statements := []string{}
+	for _, sql := range mock.SQL() {
+		if strings.Contains(sql, "FROM system.backups") {
+			polls++
+			continue
+		}
+		statements = append(statements, sql)
+	}
+	expected := []string{
+		"BACKUP TABLE `users`, TABLE `orders` TO Disk('backups', 'users.zip') ASYNC",
+		"RESTORE TABLE `users` FROM Disk('backups', 'users.zip')",
+		"RESTORE TABLE `orders` FROM Disk('backups', 'users.zip') ASYNC",
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("expects %v, got %v", expected, statements)
+	}
+	if polls < 2 || !strings.Contains(mock.SQL()[1], "FROM system.backups WHERE id = 'b1'") {
+		t.Errorf("expects system.backups polled until the backup is created, got %v", mock.SQL())
+	}
+
+	mock.Returns("FROM system.backups", backupColumns)
+	if _, err := clickhouse.GetBackup(mockDB, "unknown"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expects ErrRecordNotFound, got %v", err)
+	}
+}
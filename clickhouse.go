@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/hashicorp/go-version"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
@@ -21,23 +23,61 @@ type Config struct {
 	DriverName                   string
 	DSN                          string
 	Conn                         gorm.ConnPool
+	Options                      *clickhouse.Options // open the connection with clickhouse-go options instead of DSN
 	DisableDatetimePrecision     bool
 	DontSupportRenameColumn      bool
 	DontSupportColumnPrecision   bool
 	DontSupportEmptyDefaultValue bool
+	DontSupportLightweightDelete bool
+	DontSupportBoolType          bool
+	DontSupportJSONType          bool
 	SkipInitializeWithVersion    bool
-	DefaultGranularity           int    // 1 granule = 8192 rows
-	DefaultCompression           string // default compression algorithm. LZ4 is lossless
-	DefaultIndexType             string // index stores extremes of the expression
-	DefaultTableEngineOpts       string
+	DefaultGranularity           int                              // 1 granule = 8192 rows
+	DefaultCompression           string                           // default compression algorithm. LZ4 is lossless
+	DefaultIndexType             string                           // index stores extremes of the expression
+	DefaultTableEngineOpts       string                           // table options of tables without gorm:table_options, overrides DefaultEngine and DefaultOrderBy
+	DefaultEngine                string                           // engine of tables without table options, defaults to MergeTree()
+	DefaultOrderBy               string                           // sorting key of tables without table options, defaults to tuple()
+	DefaultCluster               string                           // ON CLUSTER of migrator DDL when table options have none, e.g. {cluster}
+	DistributedDDLOutputMode     string                           // distributed_ddl_output_mode of migrator DDL, e.g. throw, none or null_status_on_timeout
+	DistributedDDLTaskTimeout    time.Duration                    // distributed_ddl_task_timeout of migrator DDL, rounded to seconds, negative waits indefinitely
+	MaxInsertBlockRows           int                              // split inserts into native batches of at most N rows, 0 disables, not in transactions of the caller
+	MaxInsertBlockBytes          int                              // split inserts into blocks of roughly at most N bytes, 0 disables
+	LogQueryStats                bool                             // append query_id, read rows/bytes and peak memory to logged SQL
+	TracerProvider               trace.TracerProvider             // create an OpenTelemetry span for each statement
+	Metrics                      Metrics                          // receive pool stats, insert block and error metrics
+	Retry                        *RetryPolicy                     // retry idempotent statements on transient errors
+	HTTPURL                      string                           // HTTP interface url for streaming formatted data, e.g. http://127.0.0.1:8123
+	S3Credentials                *S3Credentials                   // credentials of the s3 table function used by FromS3
+	MigrationLock                *MigrationLock                   // acquire a table based lock during AutoMigrate
+	DataTypeMapper               func(field *schema.Field) string // override DataTypeOf, return "" to use the default type
+	UseBoolType                  bool                             // map bool to Bool instead of UInt8, requires clickhouse 21.12
+	UseLightweightDelete         bool                             // delete with DELETE FROM instead of ALTER TABLE DELETE mutations, requires clickhouse 23.3
+	InsertTimeZone               TimeZonePolicy                   // convert time.Time values of created and updated columns to the location, "" keeps them as is
+	ScanTimeZone                 TimeZonePolicy                   // convert scanned time.Time fields to the location, "" keeps the driver's, the timezone of the column
+	KillQueryOnCancel            bool                             // KILL QUERY on the server when the context of a running statement is cancelled, not while reading the rows of Rows() or Stream
+	ValidateEnums                bool                             // fail AutoMigrate when Enum columns differ from their EnumValuesInterface values
+	MaxOpenConns                 int                              // max open connections of the pool, 0 keeps the default
+	MaxIdleConns                 int                              // max idle connections of the pool, 0 keeps the default, negative keeps none
+	ConnMaxLifetime              time.Duration                    // max lifetime of pooled connections, 0 keeps the default
+	ConnMaxIdleTime              time.Duration                    // max idle time of pooled connections, 0 keeps the default
+	DialContext                  DialFunc                         // dial the server through a custom dialer, e.g. an SSH tunnel or SOCKS proxy, opens the DSN with clickhouse-go options
+	KeepAlive                    time.Duration                    // TCP keep-alive period of native connections, negative disables keep-alives
+	ConnOpenStrategy             clickhouse.ConnOpenStrategy      // order of the DSN addresses connections are opened to, 0 keeps the strategy of the DSN or Options
+	BlockBufferSize              uint8                            // blocks buffered while reading results, 0 keeps the default
+	RecycleConnCodes             []int32                          // discard connections after statements fail with the exception codes instead of reusing them
+	EnableExperimentalSettings   bool                             // send the allow_experimental_* settings required by the features used in DDL statements, e.g. statistics and Variant columns
+	PreloadChunkSize             int                              // split the IN list of Preload queries into queries of at most N values, 0 disables
+	PreloadGlobalIn              bool                             // write the IN list of Preload queries as GLOBAL IN, e.g. preloading from Distributed tables
 
 	InformationSchemaTablesTableTypeString bool // information_schema.tables.table_type is String
 }
 
 type Dialector struct {
 	*Config
-	options clickhouse.Options
-	Version string
+	options        clickhouse.Options
+	serverLocation *time.Location
+	Version        string // server version detected on Initialize unless SkipInitializeWithVersion
 }
 
 func Open(dsn string) gorm.Dialector {
@@ -56,10 +96,23 @@ func (dialector *Dialector) Initialize(db *gorm.DB) (err error) {
 	// register callbacks
 	ctx := context.Background()
 	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
-		DeleteClauses: []string{"DELETE", "WHERE"},
+		DeleteClauses: []string{"DELETE", inPartitionName, "WHERE"},
+		UpdateClauses: []string{"UPDATE", "SET", inPartitionName, "WHERE"},
 	})
 	db.Callback().Create().Replace("gorm:create", dialector.Create)
 	db.Callback().Update().Replace("gorm:update", dialector.Update)
+	db.Callback().Query().Replace("gorm:query", query)
+	dialector.registerPreparedStmtCallbacks(db)
+	dialector.registerQueryStatsCallbacks(db)
+	dialector.registerTracingCallbacks(db)
+	dialector.registerMetricsCallbacks(db)
+	registerReadOnlyCallbacks(db)
+	registerPrimaryKeyCallbacks(db)
+	registerDeduplicationCallbacks(db)
+	dialector.registerTimeZoneCallbacks(db)
+	dialector.registerKillOnCancelCallbacks(db)
+	dialector.registerExperimentalSettingsCallbacks(db)
+	dialector.registerPreloadCallbacks(db)
 
 	// assign option fields to default values
 	if dialector.DriverName == "" {
@@ -79,12 +132,28 @@ func (dialector *Dialector) Initialize(db *gorm.DB) (err error) {
 		dialector.DefaultIndexType = "minmax"
 	}
 
+	if dialector.DefaultEngine == "" {
+		dialector.DefaultEngine = "MergeTree()"
+	}
+
+	if dialector.DefaultOrderBy == "" {
+		dialector.DefaultOrderBy = "tuple()"
+	}
+
 	if dialector.DefaultTableEngineOpts == "" {
-		dialector.DefaultTableEngineOpts = "ENGINE=MergeTree() ORDER BY tuple()"
+		dialector.DefaultTableEngineOpts = "ENGINE=" + dialector.DefaultEngine + " ORDER BY " + dialector.DefaultOrderBy
 	}
 
 	if dialector.Conn != nil {
 		db.ConnPool = dialector.Conn
+	} else if dialector.Options != nil {
+		db.ConnPool = dialector.openDB(*dialector.Options)
+	} else if dialector.hasConnOptions() {
+		opts, err := clickhouse.ParseDSN(dialector.DSN)
+		if err != nil {
+			return err
+		}
+		db.ConnPool = dialector.openDB(*opts)
 	} else {
 		db.ConnPool, err = sql.Open(dialector.DriverName, dialector.DSN)
 		if err != nil {
@@ -92,11 +161,25 @@ func (dialector *Dialector) Initialize(db *gorm.DB) (err error) {
 		}
 	}
 
-	if dialector.DSN != "" {
+	if sqlDB, ok := db.ConnPool.(*sql.DB); ok {
+		dialector.configurePool(sqlDB)
+	}
+
+	if dialector.Retry != nil {
+		retry := dialector.Retry.withDefaults()
+		db.ConnPool = &connPool{ConnPool: db.ConnPool, retry: &retry}
+	}
+
+	if dialector.Options != nil {
+		dialector.options = *dialector.Options
+	} else if dialector.DSN != "" {
 		if opts, err := clickhouse.ParseDSN(dialector.DSN); err == nil {
 			dialector.options = *opts
 		}
 	}
+	if dialector.hasConnOptions() {
+		dialector.options = dialector.connOptions(dialector.options)
+	}
 
 	if !dialector.SkipInitializeWithVersion {
 		err = db.ConnPool.QueryRowContext(ctx, "SELECT version()").Scan(&dialector.Version)
@@ -115,11 +198,36 @@ func (dialector *Dialector) Initialize(db *gorm.DB) (err error) {
 				dialector.DontSupportColumnPrecision = true
 			}
 
+			versionNoBoolType, _ := version.NewConstraint("< 21.12")
+			if versionNoBoolType.Check(dbversion) {
+				dialector.DontSupportBoolType = true
+			}
+
+			versionNoLightweightDelete, _ := version.NewConstraint("< 23.3")
+			if versionNoLightweightDelete.Check(dbversion) {
+				dialector.DontSupportLightweightDelete = true
+			}
+
+			versionNoJSONType, _ := version.NewConstraint("< 25.3")
+			if versionNoJSONType.Check(dbversion) {
+				dialector.DontSupportJSONType = true
+			}
+
 			versionTableType, _ := version.NewConstraint(">= 23.9")
 			if versionTableType.Check(dbversion) {
 				dialector.Config.InformationSchemaTablesTableTypeString = true
 			}
 		}
+
+		if dialector.InsertTimeZone != "" || dialector.ScanTimeZone != "" {
+			var timezone string
+			if err = db.ConnPool.QueryRowContext(ctx, "SELECT timezone()").Scan(&timezone); err != nil {
+				return err
+			}
+			if dialector.serverLocation, err = time.LoadLocation(timezone); err != nil {
+				return err
+			}
+		}
 	}
 
 	for k, v := range dialector.ClauseBuilders() {
@@ -129,6 +237,7 @@ func (dialector *Dialector) Initialize(db *gorm.DB) (err error) {
 }
 
 func modifyStatementWhereConds(stmt *gorm.Statement) {
+	expandCompositePrimaryKey(stmt)
 	if c, ok := stmt.Clauses["WHERE"]; ok {
 		if where, ok := c.Expression.(clause.Where); ok {
 			modifyExprs(where.Exprs)
@@ -155,6 +264,16 @@ func modifyExprs(exprs []clause.Expression) {
 						result.Set(reflectValue)
 						result.FieldByName("Column").Set(reflect.ValueOf(column))
 						exprs[idx] = result.Interface().(clause.Expression)
+					} else if columns, ok := field.Interface().([]clause.Column); ok {
+						unqualified := make([]clause.Column, len(columns))
+						for i, column := range columns {
+							column.Table = ""
+							unqualified[i] = column
+						}
+						result := reflect.New(reflectValue.Type()).Elem()
+						result.Set(reflectValue)
+						result.FieldByName("Column").Set(reflect.ValueOf(unqualified))
+						exprs[idx] = result.Interface().(clause.Expression)
 					}
 				}
 			}
@@ -165,7 +284,11 @@ func modifyExprs(exprs []clause.Expression) {
 func (dialector Dialector) ClauseBuilders() map[string]clause.ClauseBuilder {
 	clauseBuilders := map[string]clause.ClauseBuilder{
 		"DELETE": func(c clause.Clause, builder clause.Builder) {
-			builder.WriteString("ALTER TABLE ")
+			if dialector.UseLightweightDelete && !dialector.DontSupportLightweightDelete {
+				builder.WriteString("DELETE FROM ")
+			} else {
+				builder.WriteString("ALTER TABLE ")
+			}
 
 			var addedTable bool
 			if stmt, ok := builder.(*gorm.Statement); ok {
@@ -180,7 +303,10 @@ func (dialector Dialector) ClauseBuilders() map[string]clause.ClauseBuilder {
 			if !addedTable {
 				builder.WriteQuoted(clause.Table{Name: clause.CurrentTable})
 			}
-			builder.WriteString(" DELETE")
+
+			if !dialector.UseLightweightDelete || dialector.DontSupportLightweightDelete {
+				builder.WriteString(" DELETE")
+			}
 		},
 		"UPDATE": func(c clause.Clause, builder clause.Builder) {
 			builder.WriteString("ALTER TABLE ")
@@ -204,12 +330,20 @@ func (dialector Dialector) ClauseBuilders() map[string]clause.ClauseBuilder {
 			c.Name = ""
 			c.Build(builder)
 		},
+		"GROUP BY": buildGroupBy,
+		"SELECT":   buildSelect,
+		"FROM":     buildFrom,
+		"WHERE":    buildWithGlobal,
 	}
 
 	return clauseBuilders
 }
 
 func (dialector Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	if settings := dialector.distributedDDLSettings(); len(settings) > 0 {
+		db = WithSettings(db, settings)
+	}
+
 	return Migrator{
 		Migrator: migrator.Migrator{
 			Config: migrator.Config{
@@ -222,8 +356,25 @@ func (dialector Dialector) Migrator(db *gorm.DB) gorm.Migrator {
 }
 
 func (dialector Dialector) DataTypeOf(field *schema.Field) string {
+	if dialector.DataTypeMapper != nil {
+		if dataType := dialector.DataTypeMapper(field); dataType != "" {
+			return dataType
+		}
+	}
+
+	if values, ok := enumValuesOf(field); ok && field.TagSettings["TYPE"] == "" {
+		return enumType(values)
+	}
+
+	if isNested(field) && field.TagSettings["TYPE"] == "" {
+		return dialector.nestedType(field)
+	}
+
 	switch field.DataType {
 	case schema.Bool:
+		if dialector.UseBoolType && !dialector.DontSupportBoolType {
+			return "Bool"
+		}
 		return "UInt8"
 	case schema.Int, schema.Uint:
 		sqlType := "Int64"
@@ -254,6 +405,13 @@ func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 		return fmt.Sprintf("FixedString(%d)", field.Size)
 	case schema.Bytes:
 		return "String"
+	case "variant", "dynamic":
+		return "Dynamic"
+	case "json":
+		if dialector.DontSupportJSONType {
+			return "String"
+		}
+		return "JSON"
 	case schema.Time:
 		// TODO: support TimeZone
 		precision := ""
@@ -296,7 +454,7 @@ func (dialector Dialector) QuoteTo(writer clause.Writer, str string) {
 }
 
 func (dialector Dialector) Explain(sql string, vars ...interface{}) string {
-	return logger.ExplainSQL(sql, nil, `'`, vars...)
+	return logger.ExplainSQL(sql, nil, `'`, explainVars(vars)...)
 }
 
 func (dialectopr Dialector) SavePoint(tx *gorm.DB, name string) error {
@@ -0,0 +1,98 @@
+// Package clickhouseerr provides ClickHouse server exception codes and helpers
+// to branch on failures returned through gorm
+package clickhouseerr
+
+import (
+	"errors"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// Common server exception codes
+// See: https://github.com/ClickHouse/ClickHouse/blob/master/src/Common/ErrorCodes.cpp
+const (
+	UnexpectedEndOfFile        int32 = 3
+	DuplicateColumn            int32 = 15
+	NoSuchColumnInTable        int32 = 16
+	IllegalColumn              int32 = 44
+	UnknownIdentifier          int32 = 47
+	TableAlreadyExists         int32 = 57
+	UnknownTable               int32 = 60
+	SyntaxError                int32 = 62
+	UnknownDatabase            int32 = 81
+	DatabaseAlreadyExists      int32 = 82
+	TimeoutExceeded            int32 = 159
+	Readonly                   int32 = 164
+	TooManySimultaneousQueries int32 = 202
+	SocketTimeout              int32 = 209
+	NetworkError               int32 = 210
+	MemoryLimitExceeded        int32 = 241
+	TableIsReadOnly            int32 = 242
+	TooManyParts               int32 = 252
+	UnknownStatusOfInsert      int32 = 319
+	QueryWasCancelled          int32 = 394
+	AccessDenied               int32 = 497
+	AuthenticationFailed       int32 = 516
+	KeeperException            int32 = 999
+)
+
+// Code returns the exception code wrapped in err, or 0 if err is not a server exception
+func Code(err error) int32 {
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		return exception.Code
+	}
+	return 0
+}
+
+// Is reports whether err is a server exception with one of codes
+func Is(err error, codes ...int32) bool {
+	if code := Code(err); code != 0 {
+		for _, c := range codes {
+			if c == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsUnknownTable reports whether err is an UNKNOWN_TABLE exception
+func IsUnknownTable(err error) bool {
+	return Is(err, UnknownTable)
+}
+
+// IsUnknownDatabase reports whether err is an UNKNOWN_DATABASE exception
+func IsUnknownDatabase(err error) bool {
+	return Is(err, UnknownDatabase)
+}
+
+// IsMemoryLimitExceeded reports whether err is a MEMORY_LIMIT_EXCEEDED exception
+func IsMemoryLimitExceeded(err error) bool {
+	return Is(err, MemoryLimitExceeded)
+}
+
+// IsTooManyParts reports whether err is a TOO_MANY_PARTS exception, usually caused by too frequent small inserts
+func IsTooManyParts(err error) bool {
+	return Is(err, TooManyParts)
+}
+
+// IsTooManySimultaneousQueries reports whether err is a TOO_MANY_SIMULTANEOUS_QUERIES exception
+func IsTooManySimultaneousQueries(err error) bool {
+	return Is(err, TooManySimultaneousQueries)
+}
+
+// IsTimeout reports whether err is a TIMEOUT_EXCEEDED or SOCKET_TIMEOUT exception
+func IsTimeout(err error) bool {
+	return Is(err, TimeoutExceeded, SocketTimeout)
+}
+
+// IsSyntaxError reports whether err is a SYNTAX_ERROR exception
+func IsSyntaxError(err error) bool {
+	return Is(err, SyntaxError)
+}
+
+// IsAccessDenied reports whether err is an ACCESS_DENIED, READONLY or AUTHENTICATION_FAILED exception
+func IsAccessDenied(err error) bool {
+	return Is(err, AccessDenied, Readonly, AuthenticationFailed)
+}
@@ -0,0 +1,30 @@
+package clickhouseerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+)
+
+func TestIs(t *testing.T) {
+	err := fmt.Errorf("insert failed: %w", &clickhouse.Exception{Code: clickhouseerr.TooManyParts, Name: "TOO_MANY_PARTS"})
+
+	if clickhouseerr.Code(err) != clickhouseerr.TooManyParts {
+		t.Errorf("code should be %v, got %v", clickhouseerr.TooManyParts, clickhouseerr.Code(err))
+	}
+
+	if !clickhouseerr.IsTooManyParts(err) {
+		t.Errorf("error should be TOO_MANY_PARTS")
+	}
+
+	if clickhouseerr.IsUnknownTable(err) || clickhouseerr.IsMemoryLimitExceeded(err) {
+		t.Errorf("error should only match TOO_MANY_PARTS")
+	}
+
+	if clickhouseerr.Code(errors.New("client error")) != 0 || clickhouseerr.Is(nil, 0) {
+		t.Errorf("client errors should not have a code")
+	}
+}
@@ -0,0 +1,178 @@
+// Package clickhousetest provides throwaway ClickHouse servers and databases for tests of applications
+// using the driver, e.g.
+//
+//	var server *clickhousetest.Server
+//
+//	func TestMain(m *testing.M) {
+//		var err error
+//		if server, err = clickhousetest.Start(context.Background()); err != nil {
+//			log.Fatal(err)
+//		}
+//		code := m.Run()
+//		server.Stop()
+//		os.Exit(code)
+//	}
+//
+//	func TestEvents(t *testing.T) {
+//		db := server.Open(t)
+//		db.AutoMigrate(&Event{})
+//	}
+package clickhousetest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DSNEnv environment variable of the DSN of an existing server, used instead of starting a container
+const DSNEnv = "CLICKHOUSE_TEST_DSN"
+
+var (
+	// Image docker image of started servers
+	Image = "clickhouse/clickhouse-server:latest"
+	// StartTimeout how long Start waits for a started server to accept connections
+	StartTimeout = time.Minute
+)
+
+// Server a ClickHouse server for tests
+type Server struct {
+	DSN         string
+	containerID string
+}
+
+// Start returns the server of DSNEnv if set, otherwise starts a ClickHouse docker container listening on
+// a random local port and waits until it accepts connections, Stop removes the container
+func Start(ctx context.Context) (*Server, error) {
+	if dsn := os.Getenv(DSNEnv); dsn != "" {
+		return &Server{DSN: dsn}, nil
+	}
+
+	output, err := exec.CommandContext(ctx, "docker", "run", "-d", "--rm", "-p", "127.0.0.1::9000",
+		"-e", "CLICKHOUSE_USER=test", "-e", "CLICKHOUSE_PASSWORD=test", "-e", "CLICKHOUSE_DEFAULT_ACCESS_MANAGEMENT=1",
+		Image,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start clickhouse container: %w", err)
+	}
+	server := &Server{containerID: strings.TrimSpace(string(output))}
+
+	port, err := exec.CommandContext(ctx, "docker", "port", server.containerID, "9000/tcp").Output()
+	if err != nil {
+		server.Stop()
+		return nil, fmt.Errorf("failed to inspect clickhouse container: %w", err)
+	}
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(port)), "\n")
+	server.DSN = "clickhouse://test:test@" + addr + "/default"
+
+	if err := server.wait(ctx); err != nil {
+		server.Stop()
+		return nil, err
+	}
+	return server, nil
+}
+
+// wait pings the server until it accepts connections or StartTimeout elapses
+func (s *Server) wait(ctx context.Context) error {
+	options, err := clickhousego.ParseDSN(s.DSN)
+	if err != nil {
+		return err
+	}
+
+	conn := clickhousego.OpenDB(options)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, StartTimeout)
+	defer cancel()
+	for {
+		if err = conn.PingContext(ctx); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("clickhouse server not ready: %w", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// Stop removes the container started by Start, servers of DSNEnv are left running
+func (s *Server) Stop() error {
+	if s.containerID == "" {
+		return nil
+	}
+	return exec.Command("docker", "rm", "-f", s.containerID).Run()
+}
+
+var unsafeNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// Open creates a database named after the test, opens a gorm.DB using it with mutations_sync=2 so ALTER
+// UPDATE/DELETE are visible right away, and drops the database when the test finishes, config is
+// applied to the dialector config of the DB
+func (s *Server) Open(t testing.TB, config ...func(*clickhouse.Config)) *gorm.DB {
+	t.Helper()
+
+	options, err := clickhousego.ParseDSN(s.DSN)
+	if err != nil {
+		t.Fatalf("failed to parse dsn, got error %v", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatalf("failed to generate database name, got error %v", err)
+	}
+	database := strings.ToLower(unsafeNameRegexp.ReplaceAllString(t.Name(), "_"))
+	if len(database) > 40 {
+		database = database[:40]
+	}
+	database = "test_" + database + "_" + hex.EncodeToString(suffix)
+
+	adminOptions := *options
+	admin := clickhousego.OpenDB(&adminOptions)
+	defer admin.Close()
+	if _, err := admin.Exec("CREATE DATABASE `" + database + "`"); err != nil {
+		t.Fatalf("failed to create database %s, got error %v", database, err)
+	}
+
+	options.Auth.Database = database
+	if options.Settings == nil {
+		options.Settings = clickhousego.Settings{}
+	}
+	options.Settings["mutations_sync"] = 2
+
+	dialectorConfig := clickhouse.Config{Options: options}
+	for _, fc := range config {
+		fc(&dialectorConfig)
+	}
+
+	db, err := gorm.Open(clickhouse.New(dialectorConfig), &gorm.Config{Logger: logger.Default.LogMode(logger.Warn)})
+	if err != nil {
+		t.Fatalf("failed to open database %s, got error %v", database, err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+
+		admin := clickhousego.OpenDB(&adminOptions)
+		defer admin.Close()
+		if _, err := admin.Exec("DROP DATABASE IF EXISTS `" + database + "`"); err != nil {
+			t.Errorf("failed to drop database %s, got error %v", database, err)
+		}
+	})
+	return db
+}
@@ -0,0 +1,155 @@
+package clickhouse
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
+)
+
+var scanTypes = map[string]reflect.Type{
+	"Bool":    reflect.TypeOf(false),
+	"UInt8":   reflect.TypeOf(uint8(0)),
+	"UInt16":  reflect.TypeOf(uint16(0)),
+	"UInt32":  reflect.TypeOf(uint32(0)),
+	"UInt64":  reflect.TypeOf(uint64(0)),
+	"Int8":    reflect.TypeOf(int8(0)),
+	"Int16":   reflect.TypeOf(int16(0)),
+	"Int32":   reflect.TypeOf(int32(0)),
+	"Int64":   reflect.TypeOf(int64(0)),
+	"Float32": reflect.TypeOf(float32(0)),
+	"Float64": reflect.TypeOf(float64(0)),
+	"String":  reflect.TypeOf(""),
+	"Int128":  reflect.TypeOf(big.NewInt(0)),
+	"Int256":  reflect.TypeOf(big.NewInt(0)),
+	"UInt128": reflect.TypeOf(big.NewInt(0)),
+	"UInt256": reflect.TypeOf(big.NewInt(0)),
+}
+
+// unwrapLowCardinality returns the type of values stored in dataType, LowCardinality(T) returns T
+func unwrapLowCardinality(dataType string) string {
+	if inner, ok := strings.CutPrefix(dataType, "LowCardinality("); ok {
+		return strings.TrimSuffix(inner, ")")
+	}
+	return dataType
+}
+
+// scanTypeOf returns the go type clickhouse-go scans values of dataType into, nil if unknown
+func scanTypeOf(dataType string) reflect.Type {
+	dataType = unwrapLowCardinality(dataType)
+	if inner, ok := strings.CutPrefix(dataType, "Nullable("); ok {
+		if scanType := scanTypeOf(strings.TrimSuffix(inner, ")")); scanType != nil {
+			return reflect.PointerTo(scanType)
+		}
+		return nil
+	}
+
+	name, _, _ := strings.Cut(dataType, "(")
+	switch name {
+	case "FixedString", "Enum8", "Enum16":
+		return scanTypes["String"]
+	case "Date", "Date32", "DateTime", "DateTime64":
+		return reflect.TypeOf(time.Time{})
+	}
+	return scanTypes[name]
+}
+
+// decimalPrecisions precisions of the Decimal aliases taking only the scale
+var decimalPrecisions = map[string]int64{"Decimal32": 9, "Decimal64": 18, "Decimal128": 38, "Decimal256": 76}
+
+// decimalSizeOf returns the precision and scale of Decimal types and the precision of DateTime64 types,
+// e.g. 10, 2 for Decimal(10, 2), 18, 4 for Decimal64(4) and 3, 0 for DateTime64(3, 'UTC')
+func decimalSizeOf(dataType string) (precision, scale int64, ok bool) {
+	dataType = unwrapLowCardinality(dataType)
+	if inner, found := strings.CutPrefix(dataType, "Nullable("); found {
+		return decimalSizeOf(strings.TrimSuffix(inner, ")"))
+	}
+
+	name, args, found := strings.Cut(dataType, "(")
+	if !found {
+		return 0, 0, false
+	}
+
+	var values []int64
+	for _, arg := range splitTypeArgs(strings.TrimSuffix(args, ")")) {
+		if value, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			values = append(values, value)
+		}
+	}
+
+	switch {
+	case name == "Decimal" && len(values) == 2:
+		return values[0], values[1], true
+	case name == "Decimal" && len(values) == 1:
+		return values[0], 0, true
+	case decimalPrecisions[name] > 0 && len(values) == 1:
+		return decimalPrecisions[name], values[0], true
+	case name == "DateTime64" && len(values) > 0:
+		return values[0], 0, true
+	}
+	return 0, 0, false
+}
+
+// migrationColumnType hides the nullability of the column from gorm's MigrateColumn, Nullable(T) is part of
+// the ClickHouse type already compared with the field's data type
+type migrationColumnType struct {
+	gormColumnType
+}
+
+// gormColumnType embedded under another name as gorm.ColumnType has a ColumnType method
+type gormColumnType = gorm.ColumnType
+
+func (migrationColumnType) Nullable() (nullable bool, ok bool) {
+	return false, false
+}
+
+// tableColumnType a column returned by ColumnTypes with the properties MigrateColumn removes when the field
+// drops them, they are read with the other column types instead of a query per column
+type tableColumnType struct {
+	migratorColumnType
+	DefaultKind      string
+	CompressionCodec string
+	definitions      *columnDefinitions
+}
+
+// migratorColumnType embedded under another name as migrator.ColumnType has a ColumnType method
+type migratorColumnType = migrator.ColumnType
+
+// columnDefinitions the column definitions of SHOW CREATE TABLE, which lists a column per line, read once
+// for all the columns of a table when first needed
+type columnDefinitions struct {
+	migrator Migrator
+	table    string
+
+	once  sync.Once
+	lines []string
+	err   error
+}
+
+// definition returns the definition of column, "" when it isn't found
+func (d *columnDefinitions) definition(column string) (string, error) {
+	d.once.Do(func() {
+		var createStmt string
+		if d.err = d.migrator.DB.Raw("SHOW CREATE TABLE ?", clause.Table{Name: d.table}).Row().Scan(&createStmt); d.err == nil {
+			d.lines = strings.Split(createStmt, "\n")
+		}
+	})
+	if d.err != nil {
+		return "", d.err
+	}
+
+	prefix := "`" + column + "` "
+	for _, line := range d.lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, prefix) || strings.HasPrefix(line, column+" ") {
+			return strings.TrimSuffix(line, ","), nil
+		}
+	}
+	return "", nil
+}
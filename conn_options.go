@@ -0,0 +1,190 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+)
+
+// DialFunc dials the address of a server, e.g. through an SSH tunnel, a SOCKS proxy or a mTLS sidecar,
+// connections of the native protocol dialed by it are used as is, without the TLS config of the options
+type DialFunc func(ctx context.Context, addr string) (net.Conn, error)
+
+// hasConnOptions reports whether the config has connection settings applied to clickhouse-go options
+func (dialector Dialector) hasConnOptions() bool {
+	return dialector.DialContext != nil || dialector.KeepAlive != 0 || dialector.ConnOpenStrategy != 0 ||
+		dialector.BlockBufferSize != 0 || len(dialector.RecycleConnCodes) > 0
+}
+
+// connOptions returns a copy of opts with the connection settings of the config applied
+func (dialector Dialector) connOptions(opts clickhouse.Options) clickhouse.Options {
+	if dialector.ConnOpenStrategy != 0 {
+		opts.ConnOpenStrategy = dialector.ConnOpenStrategy
+	}
+	if dialector.BlockBufferSize != 0 {
+		opts.BlockBufferSize = dialector.BlockBufferSize
+	}
+
+	if dialector.DialContext != nil {
+		opts.DialContext = dialector.DialContext
+	} else if dialector.KeepAlive != 0 && opts.DialContext == nil {
+		timeout := opts.DialTimeout
+		if timeout == 0 {
+			timeout = 30 * time.Second
+		}
+		dialer, tlsConfig := &net.Dialer{Timeout: timeout, KeepAlive: dialector.KeepAlive}, opts.TLS
+		opts.DialContext = func(ctx context.Context, addr string) (net.Conn, error) {
+			if tlsConfig != nil {
+				return (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+			}
+			return dialer.DialContext(ctx, "tcp", addr)
+		}
+	}
+	return opts
+}
+
+// openDB opens opts with the connection settings of the config applied, connections failing with the
+// exceptions of RecycleConnCodes are discarded instead of returned to the pool
+func (dialector Dialector) openDB(opts clickhouse.Options) *sql.DB {
+	opts = dialector.connOptions(opts)
+	if len(dialector.RecycleConnCodes) == 0 {
+		return clickhouse.OpenDB(&opts)
+	}
+
+	sqlDB := sql.OpenDB(recycleConnector{Connector: clickhouse.Connector(&opts), codes: dialector.RecycleConnCodes})
+
+	// pool defaults of clickhouse.OpenDB
+	maxIdleConns, maxOpenConns, connMaxLifetime := opts.MaxIdleConns, opts.MaxOpenConns, opts.ConnMaxLifetime
+	if maxIdleConns <= 0 {
+		maxIdleConns = 5
+	}
+	if maxOpenConns <= 0 {
+		maxOpenConns = maxIdleConns + 5
+	}
+	if connMaxLifetime == 0 {
+		connMaxLifetime = time.Hour
+	}
+	sqlDB.SetMaxIdleConns(maxIdleConns)
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+	sqlDB.SetConnMaxLifetime(connMaxLifetime)
+	return sqlDB
+}
+
+type recycleConnector struct {
+	driver.Connector
+	codes []int32
+}
+
+func (c recycleConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &recycleConn{Conn: conn, codes: c.codes}, nil
+}
+
+// recycleConn marks the connection bad after statements fail with the exceptions of codes,
+// database/sql closes bad connections instead of reusing them
+type recycleConn struct {
+	driver.Conn
+	codes []int32
+	bad   bool
+}
+
+func (c *recycleConn) check(err error) error {
+	if err != nil && clickhouseerr.Is(err, c.codes...) {
+		c.bad = true
+	}
+	return err
+}
+
+func (c *recycleConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	result, err := execer.ExecContext(ctx, query, args)
+	return result, c.check(err)
+}
+
+func (c *recycleConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	return rows, c.check(err)
+}
+
+func (c *recycleConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *recycleConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *recycleConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *recycleConn) Ping(ctx context.Context) error {
+	if c.bad {
+		return driver.ErrBadConn
+	}
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *recycleConn) ResetSession(ctx context.Context) error {
+	if c.bad {
+		return driver.ErrBadConn
+	}
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator, bad connections are closed when returned to the pool
+func (c *recycleConn) IsValid() bool {
+	return !c.bad
+}
+
+// httpClient returns the client of the HTTP interface, dialing with the TLS config of the options and
+// Config.DialContext
+func (dialector *Dialector) httpClient() *http.Client {
+	if dialector.options.TLS == nil && dialector.DialContext == nil {
+		return http.DefaultClient
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if dialector.options.TLS != nil {
+		transport.TLSClientConfig = dialector.options.TLS.Clone()
+	}
+	if dial := dialector.DialContext; dial != nil {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dial(ctx, addr)
+		}
+	}
+	return &http.Client{Transport: transport}
+}
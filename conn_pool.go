@@ -0,0 +1,176 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+	"gorm.io/gorm"
+)
+
+// configurePool applies the pool settings of the config to sqlDB, zero values keep the settings of sqlDB
+func (dialector Dialector) configurePool(sqlDB *sql.DB) {
+	if dialector.MaxOpenConns != 0 {
+		sqlDB.SetMaxOpenConns(dialector.MaxOpenConns)
+	}
+	if dialector.MaxIdleConns != 0 {
+		sqlDB.SetMaxIdleConns(dialector.MaxIdleConns)
+	}
+	if dialector.ConnMaxLifetime != 0 {
+		sqlDB.SetConnMaxLifetime(dialector.ConnMaxLifetime)
+	}
+	if dialector.ConnMaxIdleTime != 0 {
+		sqlDB.SetConnMaxIdleTime(dialector.ConnMaxIdleTime)
+	}
+}
+
+// RetryPolicy retries idempotent statements failing with transient errors
+type RetryPolicy struct {
+	MaxAttempts    int           // total attempts including the first one, defaults to 3
+	Backoff        time.Duration // initial backoff doubled after every attempt, defaults to 100ms
+	MaxBackoff     time.Duration // upper bound of the backoff, defaults to 5s
+	RetryInserts   bool          // also retry INSERTs, safe when inserts are deduplicated by the server
+	RetryableCodes []int32       // exception codes to retry, defaults to DefaultRetryableCodes
+}
+
+// DefaultRetryableCodes exception codes considered transient
+var DefaultRetryableCodes = []int32{
+	clickhouseerr.UnexpectedEndOfFile,
+	clickhouseerr.TimeoutExceeded,
+	clickhouseerr.TooManySimultaneousQueries,
+	clickhouseerr.SocketTimeout,
+	clickhouseerr.NetworkError,
+	clickhouseerr.TableIsReadOnly,
+	clickhouseerr.TooManyParts,
+	clickhouseerr.UnknownStatusOfInsert,
+	clickhouseerr.KeeperException,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	if p.RetryableCodes == nil {
+		p.RetryableCodes = DefaultRetryableCodes
+	}
+	return p
+}
+
+// Retryable reports whether err is a network error or a retryable exception
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if clickhouseerr.Code(err) != 0 {
+		return clickhouseerr.Is(err, p.RetryableCodes...)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, driver.ErrBadConn)
+}
+
+// connPool wraps the connection pool opened by the dialector
+type connPool struct {
+	gorm.ConnPool
+	retry *RetryPolicy
+}
+
+func (p *connPool) retryable(query string) bool {
+	if p.retry == nil {
+		return false
+	}
+
+	if isReadStatement(query) {
+		return true
+	}
+	return p.retry.RetryInserts && statementKind(query) == "INSERT"
+}
+
+// do runs fn and retries it according to the retry policy
+func (p *connPool) do(ctx context.Context, query string, fn func() error) (err error) {
+	if !p.retryable(query) {
+		return fn()
+	}
+
+	backoff := p.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= p.retry.MaxAttempts || !p.retry.Retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > p.retry.MaxBackoff {
+			backoff = p.retry.MaxBackoff
+		}
+	}
+}
+
+func (p *connPool) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	err = p.do(ctx, query, func() (err error) {
+		result, err = p.ConnPool.ExecContext(ctx, query, args...)
+		return
+	})
+	return
+}
+
+func (p *connPool) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	err = p.do(ctx, query, func() (err error) {
+		rows, err = p.ConnPool.QueryContext(ctx, query, args...)
+		return
+	})
+	return
+}
+
+func (p *connPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		return beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		return beginner.BeginTx(ctx, opts)
+	}
+	return nil, gorm.ErrInvalidTransaction
+}
+
+func (p *connPool) Ping() error {
+	if pinger, ok := p.ConnPool.(interface{ Ping() error }); ok {
+		return pinger.Ping()
+	}
+	return nil
+}
+
+func (p *connPool) GetDBConn() (*sql.DB, error) {
+	if sqlDB, ok := p.ConnPool.(*sql.DB); ok {
+		return sqlDB, nil
+	}
+	if connector, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// statementKind returns the upper cased first keyword of query
+func statementKind(query string) string {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	if idx := strings.IndexAny(query, " \t\r\n("); idx > 0 {
+		query = query[:idx]
+	}
+	return strings.ToUpper(query)
+}
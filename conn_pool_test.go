@@ -0,0 +1,38 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := clickhouse.RetryPolicy{RetryableCodes: clickhouse.DefaultRetryableCodes}
+
+	for err, retryable := range map[error]bool{
+		io.EOF: true,
+		&clickhousego.Exception{Code: 202, Name: "TOO_MANY_SIMULTANEOUS_QUERIES"}: true,
+		&clickhousego.Exception{Code: 60, Name: "UNKNOWN_TABLE"}:                  false,
+		errors.New("syntax error"):                                                false,
+	} {
+		if policy.Retryable(err) != retryable {
+			t.Errorf("retryable of %v should be %v", err, retryable)
+		}
+	}
+
+	DB := newTestDB(t, clickhouse.Config{
+		Retry: &clickhouse.RetryPolicy{MaxAttempts: 2},
+	})
+
+	var count int64
+	if err := DB.Model(&User{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count users, got error %v", err)
+	}
+
+	if _, err := DB.DB(); err != nil {
+		t.Fatalf("failed to get sql.DB, got error %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package clickhouse
+
+import (
+	"io"
+
+	"gorm.io/gorm"
+)
+
+// CopyNative streams the rows of query on src into table on dst in the Native format over the HTTP
+// interfaces of both servers, blocks are passed through without decoding, so tables can be copied
+// between clusters or environments, query is a raw SQL string or a *gorm.DB query, e.g.
+//
+//	clickhouse.CopyNative(prodDB, prodDB.Model(&Event{}).Where("day = ?", day), stagingDB, "events")
+func CopyNative(src *gorm.DB, query interface{}, dst *gorm.DB, table string) error {
+	pr, pw := io.Pipe()
+
+	exported := make(chan error, 1)
+	go func() {
+		err := Export(src, query, FormatNative, pw)
+		pw.CloseWithError(err)
+		exported <- err
+	}()
+
+	err := Import(dst, table, FormatNative, pr)
+	// unblocks the export when the insert stopped reading early
+	pr.CloseWithError(err)
+
+	if exportErr := <-exported; exportErr != nil {
+		return exportErr
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package clickhouse_test
+
+import (
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestCopyNative(t *testing.T) {
+	type NativeUser struct {
+		ID   uint64
+		Name string
+		Age  int64 `gorm:"type:Nullable(Int64)"`
+	}
+
+	DB.Migrator().DropTable(&NativeUser{})
+	if err := DB.AutoMigrate(&NativeUser{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	users := []User{{ID: 701, Name: "native1", Age: 18}, {ID: 702, Name: "native2", Age: 20}}
+	if err := DB.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users, got error %v", err)
+	}
+
+	query := DB.Model(&User{}).Select("id", "name", "age").Where("id IN ?", []uint64{701, 702})
+	if err := clickhouse.CopyNative(DB, query, DB, "native_users"); err != nil {
+		t.Fatalf("failed to copy users, got error %v", err)
+	}
+
+	var copied []NativeUser
+	if err := DB.Order("id").Find(&copied).Error; err != nil {
+		t.Fatalf("failed to query copied users, got error %v", err)
+	}
+
+	if len(copied) != 2 || copied[0].Name != "native1" || copied[1].Age != 20 {
+		t.Errorf("expects copied users, got %+v", copied)
+	}
+
+	if err := clickhouse.CopyNative(DB, "SELECT * FROM not_exists_table", DB, "native_users"); err == nil {
+		t.Errorf("should return error when copying from an unknown table")
+	}
+}
@@ -1,6 +1,12 @@
 package clickhouse
 
 import (
+	"context"
+	"database/sql"
+	"reflect"
+	"slices"
+	"time"
+
 	"gorm.io/gorm"
 	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
@@ -19,24 +25,30 @@ func (dialector *Dialector) Create(db *gorm.DB) {
 			db.Statement.AddClauseIfNotExists(clause.Insert{})
 
 			if values := callbacks.ConvertToCreateValues(db.Statement); len(values.Values) >= 1 {
-				prepareValues := clause.Values{
-					Columns: values.Columns,
-					Values:  [][]interface{}{values.Values[0]},
+				dialector.localizeValues(db.Statement, values)
+				variantValues(values)
+				values, err := dialector.nestedValues(db.Statement, values)
+				if db.AddError(err) != nil {
+					return
 				}
-				db.Statement.AddClause(prepareValues)
-				db.Statement.Build("INSERT", "VALUES", "ON CONFLICT")
 
-				stmt, err := db.Statement.ConnPool.PrepareContext(db.Statement.Context, db.Statement.SQL.String())
-				if db.AddError(err) != nil {
+				if blocks := dialector.splitInsertBlocks(values.Values); len(blocks) > 1 {
+					dialector.createInBlocks(db, values.Columns, blocks)
 					return
 				}
-				defer stmt.Close()
 
-				for _, value := range values.Values {
-					if _, err := stmt.Exec(value...); db.AddError(err) != nil {
-						return
-					}
+				// DryRun builds the statement with all values instead of preparing a batch
+				if db.DryRun {
+					db.Statement.AddClause(values)
+					db.Statement.Build("INSERT", "VALUES", "ON CONFLICT")
+					return
 				}
+
+				result := insertBatch(db, db.Statement.ConnPool, values.Columns, values.Values)
+				if db.Error != nil {
+					return
+				}
+				setCreateResult(db, result, int64(len(values.Values)))
 				return
 			}
 		}
@@ -51,3 +63,155 @@ func (dialector *Dialector) Create(db *gorm.DB) {
 		}
 	}
 }
+
+// insertBatch prepares the INSERT of columns as a native batch on pool and appends rows to it, clickhouse-go
+// sends the batch when the transaction of pool commits
+func insertBatch(db *gorm.DB, pool gorm.ConnPool, columns []clause.Column, rows [][]interface{}) (result sql.Result) {
+	db.Statement.SQL.Reset()
+	db.Statement.Vars = nil
+	db.Statement.AddClause(clause.Values{Columns: columns, Values: rows[:1]})
+	db.Statement.Build("INSERT", "VALUES", "ON CONFLICT")
+	if db.Error != nil {
+		return nil
+	}
+
+	stmt, err := pool.PrepareContext(db.Statement.Context, db.Statement.SQL.String())
+	if db.AddError(err) != nil {
+		return nil
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if result, err = stmt.Exec(row...); db.AddError(err) != nil {
+			return nil
+		}
+	}
+	return result
+}
+
+// setCreateResult sets the rows affected by the insert
+func setCreateResult(db *gorm.DB, result sql.Result, rowsAffected int64) {
+	db.RowsAffected = rowsAffected
+	if db.Statement.Result != nil {
+		db.Statement.Result.Result = result
+		db.Statement.Result.RowsAffected = rowsAffected
+	}
+}
+
+// beginBlock begins the transaction of an insert block on pool
+func beginBlock(ctx context.Context, pool gorm.ConnPool) (gorm.ConnPool, error) {
+	switch beginner := pool.(type) {
+	case gorm.TxBeginner:
+		return beginner.BeginTx(ctx, nil)
+	case gorm.ConnPoolBeginner:
+		return beginner.BeginTx(ctx, nil)
+	}
+	return nil, gorm.ErrInvalidTransaction
+}
+
+// createInBlocks sends every block as a native batch of its own, so a single oversized slice never turns
+// into one giant batch, clickhouse-go sends only the batch prepared last when a transaction commits, so every
+// block is prepared in a transaction of its own, the rows are sent in a single batch in a transaction of the caller
+func (dialector *Dialector) createInBlocks(db *gorm.DB, columns []clause.Column, blocks [][][]interface{}) {
+	if db.DryRun {
+		for _, block := range blocks {
+			db.Statement.SQL.Reset()
+			db.Statement.Vars = nil
+			db.Statement.AddClause(clause.Values{Columns: columns, Values: block})
+			db.Statement.Build("INSERT", "VALUES", "ON CONFLICT")
+			if db.Error != nil {
+				return
+			}
+			recordDryRunSQL(db)
+		}
+		return
+	}
+
+	// the transaction started by gorm for the insert can't send more than one batch
+	pool := db.Statement.ConnPool
+	if _, ok := db.InstanceGet("gorm:started_transaction"); ok {
+		pool = unwrapConnPool(db.ConnPool)
+	} else if _, ok := pool.(gorm.TxCommitter); ok {
+		rows := slices.Concat(blocks...)
+		if result := insertBatch(db, pool, columns, rows); db.Error == nil {
+			setCreateResult(db, result, int64(len(rows)))
+		}
+		return
+	}
+
+	var rowsAffected int64
+	for _, block := range blocks {
+		startedAt := time.Now()
+		tx, err := beginBlock(db.Statement.Context, pool)
+		if db.AddError(err) != nil {
+			return
+		}
+
+		result := insertBatch(db, tx, columns, block)
+		if db.Error != nil {
+			tx.(gorm.TxCommitter).Rollback()
+			return
+		}
+		if db.AddError(tx.(gorm.TxCommitter).Commit()) != nil {
+			return
+		}
+		rowsAffected += int64(len(block))
+		setCreateResult(db, result, rowsAffected)
+		dialector.observeInsertBlock(db, int64(len(block)), time.Since(startedAt))
+	}
+}
+
+// splitInsertBlocks splits rows into blocks honoring MaxInsertBlockRows and MaxInsertBlockBytes
+func (dialector *Dialector) splitInsertBlocks(rows [][]interface{}) (blocks [][][]interface{}) {
+	if dialector.MaxInsertBlockRows <= 0 && dialector.MaxInsertBlockBytes <= 0 {
+		return [][][]interface{}{rows}
+	}
+
+	var start, size int
+	for idx, row := range rows {
+		rowSize := estimateSize(reflect.ValueOf(row))
+		if idx > start && ((dialector.MaxInsertBlockRows > 0 && idx-start >= dialector.MaxInsertBlockRows) ||
+			(dialector.MaxInsertBlockBytes > 0 && size+rowSize > dialector.MaxInsertBlockBytes)) {
+			blocks = append(blocks, rows[start:idx])
+			start, size = idx, 0
+		}
+		size += rowSize
+	}
+	return append(blocks, rows[start:])
+}
+
+// estimateSize roughly estimates the encoded size of value in bytes, of the rows of MaxInsertBlockBytes and
+// the buffered rows of Writer MaxBytes
+func estimateSize(value reflect.Value) (size int) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			return estimateSize(value.Elem())
+		}
+	case reflect.String:
+		return value.Len()
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return value.Len()
+		}
+		for i := 0; i < value.Len(); i++ {
+			size += estimateSize(value.Index(i))
+		}
+		return size
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key()) + estimateSize(iter.Value())
+		}
+		return size
+	case reflect.Struct:
+		if value.Type() == reflect.TypeOf(time.Time{}) {
+			return 8
+		}
+		for i := 0; i < value.NumField(); i++ {
+			size += estimateSize(value.Field(i))
+		}
+		return size
+	}
+	return 8
+}
@@ -1,9 +1,14 @@
 package clickhouse_test
 
 import (
+	"database/sql"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
 	"gorm.io/gorm/utils/tests"
 )
 
@@ -85,3 +90,112 @@ func TestCreateWithMap(t *testing.T) {
 	user.UpdatedAt = result.UpdatedAt
 	tests.AssertEqual(t, result, user)
 }
+
+func TestCreateInBlocks(t *testing.T) {
+	DB := newTestDB(t, clickhouse.Config{
+		MaxInsertBlockRows: 2,
+	})
+
+	users := []User{
+		{ID: 21, Name: "block_create_1", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: true, Salary: 6},
+		{ID: 22, Name: "block_create_2", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: false, Salary: 6.12},
+		{ID: 23, Name: "block_create_3", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: true, Salary: 6.1234},
+		{ID: 24, Name: "block_create_4", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: false, Salary: 6.123456},
+		{ID: 25, Name: "block_create_5", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: true, Salary: 6.5},
+	}
+
+	if err := DB.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users, got error %v", err)
+	}
+
+	for _, u := range users {
+		var result User
+		if err := DB.Find(&result, u.ID).Error; err != nil {
+			t.Fatalf("failed to query user, got error %v", err)
+		}
+
+		tests.AssertEqual(t, result, u)
+	}
+}
+
+// insertBlockMetrics records the rows of the insert blocks
+type insertBlockMetrics struct {
+	rows []int64
+}
+
+func (m *insertBlockMetrics) ObservePoolStats(sql.DBStats) {}
+
+func (m *insertBlockMetrics) ObserveInsertBlock(_ string, rows int64, _ time.Duration) {
+	m.rows = append(m.rows, rows)
+}
+
+func (m *insertBlockMetrics) ObserveError(string, int32) {}
+
+func TestCreateInBlocksOfBytes(t *testing.T) {
+	type BlockEvent struct {
+		ID      uint64
+		Counts  map[string]uint64 `gorm:"type:Map(String, UInt64)"`
+		Matrix  [][]string        `gorm:"type:Array(Array(String))"`
+		Comment *string
+	}
+
+	metrics := &insertBlockMetrics{}
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{MaxInsertBlockBytes: 100, Metrics: metrics})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	comment := "0123456789"
+	events := []BlockEvent{
+		{ID: 1, Counts: map[string]uint64{"0123456789": 1, "abcdefghij": 2}},
+		{ID: 2, Matrix: [][]string{{"0123456789", "abcdefghij"}, {"0123456789"}}},
+		{ID: 3, Comment: &comment},
+		{ID: 4, Comment: &comment},
+	}
+	tx := mockDB.Create(&events)
+	if tx.Error != nil {
+		t.Fatalf("failed to create, got error %v", tx.Error)
+	}
+	if tx.RowsAffected != 4 {
+		t.Errorf("expects 4 rows affected, got %v", tx.RowsAffected)
+	}
+
+	// rows of 52, 46, 18 and 18 bytes, maps, nested slices and pointers are estimated by their contents
+	if !slices.Equal(metrics.rows, []int64{2, 2}) {
+		t.Errorf("expects 2 blocks of 2 rows, got %v", metrics.rows)
+	}
+
+	// blocks are native batches, prepared inserts are recorded once for each row
+	statements := mock.Statements()
+	if len(statements) != 4 {
+		t.Fatalf("expects 4 rows, got %v", mock.SQL())
+	}
+	for idx, statement := range statements {
+		if !strings.HasPrefix(statement.SQL, "INSERT INTO `block_events`") || statement.Args[len(statement.Args)-1] != uint64(idx+1) {
+			t.Errorf("expects row %d appended to a batch, got %+v", idx+1, statement)
+		}
+	}
+}
+
+func TestCreateWithPrepareStmt(t *testing.T) {
+	DB := newTestDB(t, clickhouse.Config{}, &gorm.Config{PrepareStmt: true})
+
+	for _, tx := range []*gorm.DB{DB, DB.Session(&gorm.Session{PrepareStmt: true})} {
+		users := []User{
+			{ID: 31, Name: "prepare_create_1", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: true, Salary: 6},
+			{ID: 32, Name: "prepare_create_2", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: false, Salary: 6.12},
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := tx.Create(&users).Error; err != nil {
+				t.Fatalf("failed to create users, got error %v", err)
+			}
+		}
+
+		var result User
+		if err := tx.Find(&result, users[0].ID).Error; err != nil {
+			t.Fatalf("failed to query user, got error %v", err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package clickhouse
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeduplicationInterface models implementing it are read deduplicated by Find and Count, e.g. models of
+// ReplacingMergeTree or CollapsingMergeTree tables whose rows are only deduplicated by background merges,
+// unless disabled with NoFinal
+//
+//	func (Session) ClickhouseDeduplication() clickhouse.Deduplication {
+//		return clickhouse.Deduplication{SignColumn: "sign"}
+//	}
+type DeduplicationInterface interface {
+	ClickhouseDeduplication() Deduplication
+}
+
+// Deduplication how the rows of a model are deduplicated when read
+type Deduplication struct {
+	Final      bool   // read with FINAL, e.g. ReplacingMergeTree
+	SignColumn string // sign column of CollapsingMergeTree, Count sums the signs, Find reads with FINAL the rows of sign 1
+}
+
+const noFinalName = "gorm:clickhouse:no_final"
+
+// NoFinal returns a session reading the rows of models implementing DeduplicationInterface as stored,
+// without FINAL or sign filtering, e.g. to inspect row versions or for queries deduplicating on their own
+func NoFinal(db *gorm.DB) *gorm.DB {
+	return db.Set(noFinalName, true)
+}
+
+// deduplicate applies the deduplication of the model to Find and Count queries
+func deduplicate(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Schema == nil || db.Error != nil {
+		return
+	}
+	if noFinal, ok := db.Get(noFinalName); ok && noFinal == true {
+		return
+	}
+
+	model, ok := reflect.New(stmt.Schema.ModelType).Interface().(DeduplicationInterface)
+	if !ok {
+		return
+	}
+
+	dedup := model.ClickhouseDeduplication()
+	if dedup.SignColumn != "" {
+		if isCountAll(stmt) {
+			stmt.AddClause(clause.Select{Expression: clause.Expr{SQL: "sum(?)", Vars: []interface{}{clause.Column{Name: dedup.SignColumn}}}})
+			return
+		}
+		stmt.AddClause(FinalClause{})
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: clause.Column{Name: dedup.SignColumn}, Value: 1}}})
+	} else if dedup.Final {
+		stmt.AddClause(FinalClause{})
+	}
+}
+
+// isCountAll reports whether stmt is built by Count without selected columns or groups
+func isCountAll(stmt *gorm.Statement) bool {
+	if _, ok := stmt.Clauses["GROUP BY"]; ok {
+		return false
+	}
+	if c, ok := stmt.Clauses["SELECT"]; ok {
+		if expr, ok := c.Expression.(clause.Expr); ok {
+			return expr.SQL == "count(*)" && len(expr.Vars) == 0
+		}
+	}
+	return false
+}
+
+func registerDeduplicationCallbacks(db *gorm.DB) {
+	db.Callback().Query().Before("gorm:query").Register("clickhouse:deduplicate", deduplicate)
+}
@@ -0,0 +1,79 @@
+package clickhouse_test
+
+import (
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+type DedupVisit struct {
+	ID      uint64
+	Page    string
+	Version uint64
+}
+
+func (DedupVisit) ClickhouseEngine() string  { return "ReplacingMergeTree(version)" }
+func (DedupVisit) ClickhouseOrderBy() string { return "id" }
+func (DedupVisit) ClickhouseDeduplication() clickhouse.Deduplication {
+	return clickhouse.Deduplication{Final: true}
+}
+
+type DedupSession struct {
+	ID   uint64
+	Page string
+	Sign int8
+}
+
+func (DedupSession) ClickhouseEngine() string  { return "CollapsingMergeTree(sign)" }
+func (DedupSession) ClickhouseOrderBy() string { return "id" }
+func (DedupSession) ClickhouseDeduplication() clickhouse.Deduplication {
+	return clickhouse.Deduplication{SignColumn: "sign"}
+}
+
+func TestDeduplication(t *testing.T) {
+	DB.Migrator().DropTable(&DedupVisit{}, &DedupSession{})
+	if err := DB.AutoMigrate(&DedupVisit{}, &DedupSession{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	for _, visits := range [][]DedupVisit{{{ID: 1, Page: "home", Version: 1}, {ID: 2, Page: "docs", Version: 1}}, {{ID: 1, Page: "pricing", Version: 2}}} {
+		if err := DB.Create(&visits).Error; err != nil {
+			t.Fatalf("failed to create visits, got error %v", err)
+		}
+	}
+
+	var count int64
+	if err := DB.Model(&DedupVisit{}).Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("expects 2 deduplicated visits, got %v, error %v", count, err)
+	}
+
+	var visits []DedupVisit
+	if err := DB.Order("id").Find(&visits).Error; err != nil {
+		t.Fatalf("failed to find visits, got error %v", err)
+	}
+	if len(visits) != 2 || visits[0].Page != "pricing" {
+		t.Errorf("expects latest versions of visits, got %+v", visits)
+	}
+
+	if err := clickhouse.NoFinal(DB).Model(&DedupVisit{}).Count(&count).Error; err != nil || count != 3 {
+		t.Errorf("expects 3 stored visits without final, got %v, error %v", count, err)
+	}
+
+	for _, sessions := range [][]DedupSession{{{ID: 1, Page: "home", Sign: 1}, {ID: 2, Page: "docs", Sign: 1}}, {{ID: 1, Page: "home", Sign: -1}, {ID: 1, Page: "pricing", Sign: 1}}} {
+		if err := DB.Create(&sessions).Error; err != nil {
+			t.Fatalf("failed to create sessions, got error %v", err)
+		}
+	}
+
+	if err := DB.Model(&DedupSession{}).Count(&count).Error; err != nil || count != 2 {
+		t.Errorf("expects 2 collapsed sessions, got %v, error %v", count, err)
+	}
+
+	var sessions []DedupSession
+	if err := DB.Order("id").Find(&sessions).Error; err != nil {
+		t.Fatalf("failed to find sessions, got error %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Page != "pricing" || sessions[1].Page != "docs" {
+		t.Errorf("expects collapsed sessions, got %+v", sessions)
+	}
+}
@@ -1,9 +1,12 @@
 package clickhouse_test
 
 import (
+	"regexp"
 	"testing"
 	"time"
 
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
 	"gorm.io/gorm/utils/tests"
 )
 
@@ -30,3 +33,86 @@ func TestDelete(t *testing.T) {
 		t.Fatalf("should raise ErrRecordNotFound, got error %v", err)
 	}
 }
+
+func TestLightweightDelete(t *testing.T) {
+	lightweightDB := newTestDB(t, clickhouse.Config{UseLightweightDelete: true})
+
+	dialector := lightweightDB.Dialector.(*clickhouse.Dialector)
+	if dialector.Version == "" {
+		t.Fatalf("server version should be detected")
+	}
+
+	sql := lightweightDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(&User{ID: 3})
+	})
+
+	expects := "^DELETE FROM `users` WHERE `id` = 3$"
+	if dialector.DontSupportLightweightDelete {
+		expects = "^ALTER TABLE `users` DELETE WHERE"
+	}
+	if !regexp.MustCompile(expects).MatchString(sql) {
+		t.Errorf("expects delete matching %v, got %v", expects, sql)
+	}
+
+	user := User{ID: 3, Name: "lightweight_delete", Age: 18}
+	if err := lightweightDB.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user, got error %v", err)
+	}
+
+	if err := lightweightDB.Delete(&user).Error; err != nil {
+		t.Fatalf("failed to delete user, got error %v", err)
+	}
+
+	var count int64
+	if err := lightweightDB.Model(&User{}).Where("id = ?", user.ID).Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("lightweight deleted user should not be found, got %v, error %v", count, err)
+	}
+}
+
+func TestDeleteCompositePrimaryKey(t *testing.T) {
+	type CompositeKey struct {
+		TenantID uint64 `gorm:"primaryKey"`
+		Code     string `gorm:"primaryKey"`
+		Name     string
+	}
+
+	DB.Migrator().DropTable(&CompositeKey{})
+	if err := DB.AutoMigrate(&CompositeKey{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var sortingKey string
+	if err := DB.Raw("SELECT sorting_key FROM system.tables WHERE database = currentDatabase() AND name = 'composite_keys'").Scan(&sortingKey).Error; err != nil || sortingKey != "tenant_id, code" {
+		t.Errorf("table should be sorted by the primary keys, got %v, error %v", sortingKey, err)
+	}
+
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(&CompositeKey{}, [][]interface{}{{1, "a"}})
+	})
+	if expects := "ALTER TABLE `composite_keys` DELETE WHERE (`tenant_id`,`code`) IN ((1,'a'))"; sql != expects {
+		t.Errorf("expects %v, got %v", expects, sql)
+	}
+
+	keys := []CompositeKey{{TenantID: 2, Code: "b", Name: "b"}, {TenantID: 1, Code: "b", Name: "ab"}, {TenantID: 1, Code: "a", Name: "aa"}}
+	if err := DB.Create(&keys).Error; err != nil {
+		t.Fatalf("failed to create, got error %v", err)
+	}
+
+	var first, last CompositeKey
+	if err := DB.First(&first).Error; err != nil || first.Name != "aa" {
+		t.Errorf("first should order by all primary keys, got %+v, error %v", first, err)
+	}
+	if err := DB.Last(&last).Error; err != nil || last.Name != "b" {
+		t.Errorf("last should order by all primary keys, got %+v, error %v", last, err)
+	}
+
+	if err := DB.Delete(&CompositeKey{TenantID: 1, Code: "a"}).Error; err != nil {
+		t.Fatalf("failed to delete, got error %v", err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	var names []string
+	if err := DB.Model(&CompositeKey{}).Order("name").Pluck("name", &names).Error; err != nil || len(names) != 2 || names[0] != "ab" || names[1] != "b" {
+		t.Errorf("only the row matching both primary keys should be deleted, got %v, error %v", names, err)
+	}
+}
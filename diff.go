@@ -0,0 +1,153 @@
+package clickhouse
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// TableDiff drift between a model and its table in the current database
+type TableDiff struct {
+	Table          string
+	Missing        bool // the table doesn't exist
+	MissingColumns []string
+	ExtraColumns   []string // columns of the table without a field in the model
+	ColumnTypes    []ColumnTypeDiff
+	MissingIndexes []string
+	Engine         *Drift // engine name drift, e.g. MergeTree and ReplacingMergeTree
+	OrderBy        *Drift // sorting key drift
+	StoragePolicy  *Drift // storage policy drift, tables without storage_policy setting use the default policy
+}
+
+// ColumnTypeDiff column type of a field differs from the type of the column
+type ColumnTypeDiff struct {
+	Column   string
+	Expected string
+	Actual   string
+}
+
+// Drift expected value of the model and actual value of the table
+type Drift struct {
+	Expected string
+	Actual   string
+}
+
+// Empty reports whether the table matches the model
+func (diff TableDiff) Empty() bool {
+	return !diff.Missing && len(diff.MissingColumns) == 0 && len(diff.ExtraColumns) == 0 && len(diff.ColumnTypes) == 0 &&
+		len(diff.MissingIndexes) == 0 && diff.Engine == nil && diff.OrderBy == nil && diff.StoragePolicy == nil
+}
+
+var (
+	engineRegexp        = regexp.MustCompile(`(?i)ENGINE\s*=?\s*(\w+)`)
+	orderByRegexp       = regexp.MustCompile(`(?is)ORDER BY\s+(.+?)\s*(?:\b(?:PARTITION BY|PRIMARY KEY|SAMPLE BY|TTL|SETTINGS|COMMENT)\b|$)`)
+	storagePolicyRegexp = regexp.MustCompile(`(?i)storage_policy\s*=\s*'([^']*)'`)
+)
+
+// normalizeSortingKey strips quotes, spaces and outer parentheses of a sorting key
+func normalizeSortingKey(key string) string {
+	key = strings.NewReplacer("`", "", " ", "").Replace(key)
+	if strings.EqualFold(key, "tuple()") {
+		return ""
+	}
+	if strings.HasPrefix(key, "(") && strings.HasSuffix(key, ")") {
+		key = key[1 : len(key)-1]
+	}
+	return key
+}
+
+// Diff compares models with the tables of the current database without changing them,
+// returns the drift of tables that don't match their model
+func (m Migrator) Diff(models ...interface{}) ([]TableDiff, error) {
+	var diffs []TableDiff
+	for _, model := range models {
+		if err := m.RunWithValue(model, func(stmt *gorm.Statement) error {
+			diff := TableDiff{Table: stmt.Table}
+			if !m.DB.Migrator().HasTable(stmt.Table) {
+				diff.Missing = true
+				diffs = append(diffs, diff)
+				return nil
+			}
+
+			var columns []struct {
+				Name string
+				Type string
+			}
+			if err := m.DB.Raw(
+				"SELECT name, type FROM system.columns WHERE database = ? AND table = ? ORDER BY position",
+				m.CurrentDatabase(), stmt.Table,
+			).Scan(&columns).Error; err != nil {
+				return err
+			}
+
+			modelColumns, err := m.modelColumns(stmt)
+			if err != nil {
+				return err
+			}
+			expectedTypes := make(map[string]string, len(modelColumns))
+			for _, column := range modelColumns {
+				expectedTypes[column.Name] = column.DataType
+			}
+
+			actualTypes := make(map[string]string, len(columns))
+			for _, column := range columns {
+				actualTypes[column.Name] = column.Type
+				if _, ok := expectedTypes[column.Name]; !ok {
+					diff.ExtraColumns = append(diff.ExtraColumns, column.Name)
+				}
+			}
+
+			for _, column := range modelColumns {
+				expected := column.DataType
+				if actual, ok := actualTypes[column.Name]; !ok {
+					diff.MissingColumns = append(diff.MissingColumns, column.Name)
+				} else if !strings.EqualFold(strings.ReplaceAll(expected, " ", ""), strings.ReplaceAll(actual, " ", "")) &&
+					!slices.Contains(m.GetTypeAliases(actual), strings.ToLower(expected)) {
+					diff.ColumnTypes = append(diff.ColumnTypes, ColumnTypeDiff{Column: column.Name, Expected: expected, Actual: actual})
+				}
+			}
+
+			for _, index := range stmt.Schema.ParseIndexes() {
+				if !m.DB.Migrator().HasIndex(model, index.Name) {
+					diff.MissingIndexes = append(diff.MissingIndexes, index.Name)
+				}
+			}
+
+			var engine, sortingKey, storagePolicy string
+			if err := m.DB.Raw(
+				"SELECT engine, sorting_key, storage_policy FROM system.tables WHERE database = ? AND name = ?",
+				m.CurrentDatabase(), stmt.Table,
+			).Row().Scan(&engine, &sortingKey, &storagePolicy); err != nil {
+				return err
+			}
+
+			_, tableOpts := m.tableEngineOpts(stmt)
+
+			if matches := engineRegexp.FindStringSubmatch(tableOpts); len(matches) > 1 && matches[1] != engine {
+				diff.Engine = &Drift{Expected: matches[1], Actual: engine}
+			}
+
+			if matches := orderByRegexp.FindStringSubmatch(tableOpts); len(matches) > 1 && normalizeSortingKey(matches[1]) != normalizeSortingKey(sortingKey) {
+				diff.OrderBy = &Drift{Expected: matches[1], Actual: sortingKey}
+			}
+
+			expectedPolicy := "default"
+			if matches := storagePolicyRegexp.FindStringSubmatch(tableOpts); len(matches) > 1 {
+				expectedPolicy = matches[1]
+			}
+			if storagePolicy != "" && expectedPolicy != storagePolicy {
+				diff.StoragePolicy = &Drift{Expected: expectedPolicy, Actual: storagePolicy}
+			}
+
+			if !diff.Empty() {
+				diffs = append(diffs, diff)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return diffs, nil
+}
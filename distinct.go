@@ -0,0 +1,70 @@
+package clickhouse
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const distinctOnName = "DISTINCT ON"
+
+// DistinctOnClause selects the first row of every distinct combination of columns, e.g.
+//
+//	db.Clauses(clickhouse.DistinctOn("user_id")).Order("user_id, ts DESC").Find(&events)
+type DistinctOnClause struct {
+	Columns []clause.Column
+}
+
+// DistinctOn builds a DISTINCT ON (columns) clause
+func DistinctOn(columns ...string) DistinctOnClause {
+	distinctOn := DistinctOnClause{}
+	for _, column := range columns {
+		distinctOn.Columns = append(distinctOn.Columns, clause.Column{Name: column})
+	}
+	return distinctOn
+}
+
+// Name implements clause.Interface interface
+func (DistinctOnClause) Name() string {
+	return distinctOnName
+}
+
+// Build implements clause.Expression interface
+func (d DistinctOnClause) Build(builder clause.Builder) {
+	builder.WriteString("DISTINCT ON (")
+	for idx, column := range d.Columns {
+		if idx > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteQuoted(column)
+	}
+	builder.WriteByte(')')
+}
+
+// MergeClause implements clause.Interface interface
+func (d DistinctOnClause) MergeClause(c *clause.Clause) {
+	c.Expression = d
+}
+
+// buildSelect writes DISTINCT ON right after SELECT when specified
+func buildSelect(c clause.Clause, builder clause.Builder) {
+	var distinctOn clause.Clause
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		distinctOn = stmt.Clauses[distinctOnName]
+	}
+
+	if distinctOn.Expression == nil {
+		c.Build(builder)
+		return
+	}
+
+	builder.WriteString("SELECT ")
+	distinctOn.Expression.Build(builder)
+	builder.WriteByte(' ')
+
+	if s, ok := c.Expression.(clause.Select); ok {
+		s.Distinct = false
+		c.Expression = s
+	}
+	c.Name = ""
+	c.Build(builder)
+}
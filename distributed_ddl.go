@@ -0,0 +1,47 @@
+package clickhouse
+
+import (
+	"math"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// DistributedDDLEntry an ON CLUSTER statement of system.distributed_ddl_queue on one host
+type DistributedDDLEntry struct {
+	Entry           string
+	Cluster         string
+	Host            string
+	Status          string // Inactive, Active, Finished, Removing or Unknown
+	Query           string
+	QueryCreateTime time.Time
+	ExceptionCode   uint16
+	ExceptionText   string
+}
+
+// distributedDDLSettings returns the settings of migrator statements waiting for ON CLUSTER DDL
+func (dialector Dialector) distributedDDLSettings() clickhouse.Settings {
+	settings := clickhouse.Settings{}
+	if dialector.DistributedDDLOutputMode != "" {
+		settings["distributed_ddl_output_mode"] = dialector.DistributedDDLOutputMode
+	}
+	if dialector.DistributedDDLTaskTimeout < 0 {
+		settings["distributed_ddl_task_timeout"] = -1
+	} else if dialector.DistributedDDLTaskTimeout > 0 {
+		settings["distributed_ddl_task_timeout"] = int64(math.Ceil(dialector.DistributedDDLTaskTimeout.Seconds()))
+	}
+	return settings
+}
+
+// PendingDistributedDDL returns the ON CLUSTER statements not finished by all hosts olderThan after they were
+// queued, check it after migrating to find hosts stuck or lagging behind
+func (m Migrator) PendingDistributedDDL(olderThan time.Duration) (entries []DistributedDDLEntry, err error) {
+	err = m.DB.Raw(
+		"SELECT entry, cluster, ifNull(toString(host), '') AS host, ifNull(toString(status), '') AS status, query, query_create_time, "+
+			"ifNull(exception_code, 0) AS exception_code, ifNull(exception_text, '') AS exception_text "+
+			"FROM system.distributed_ddl_queue WHERE (status IS NULL OR status != 'Finished') AND query_create_time <= now() - toIntervalSecond(?) "+
+			"ORDER BY entry, host",
+		int64(olderThan.Seconds()),
+	).Scan(&entries).Error
+	return
+}
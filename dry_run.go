@@ -0,0 +1,37 @@
+package clickhouse
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const dryRunSQLsName = "gorm:clickhouse:dry_run_sqls"
+
+type dryRunSQLs struct {
+	sqls []string
+}
+
+// recordDryRunSQL records the current statement when ToSQL collects
+// every statement generated by a single operation, e.g. block inserts
+func recordDryRunSQL(db *gorm.DB) {
+	if v, ok := db.Statement.Settings.Load(dryRunSQLsName); ok {
+		if collector, ok := v.(*dryRunSQLs); ok {
+			collector.sqls = append(collector.sqls, db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...))
+		}
+	}
+}
+
+// ToSQL returns the SQL the driver would execute for queryFn without executing it,
+// including ClickHouse specific rewrites like ALTER TABLE mutations, local table
+// updates and inserts split into blocks, which are joined with ";\n"
+func ToSQL(db *gorm.DB, queryFn func(tx *gorm.DB) *gorm.DB) string {
+	collector := &dryRunSQLs{}
+	tx := queryFn(db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true}).Set(dryRunSQLsName, collector))
+	if len(collector.sqls) > 0 {
+		return strings.Join(collector.sqls, ";\n")
+	}
+
+	stmt := tx.Statement
+	return db.Dialector.Explain(stmt.SQL.String(), stmt.Vars...)
+}
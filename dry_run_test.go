@@ -0,0 +1,41 @@
+package clickhouse_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestToSQL(t *testing.T) {
+	users := []User{{ID: 61, Name: "dry_run_1"}, {ID: 62, Name: "dry_run_2"}, {ID: 63, Name: "dry_run_3"}}
+
+	sql := clickhouse.ToSQL(DB, func(tx *gorm.DB) *gorm.DB {
+		return tx.Create(&users)
+	})
+	if !regexp.MustCompile("INSERT INTO `users` .* VALUES \\(.*61.*\\),\\(.*62.*\\),\\(.*63.*\\)").MatchString(sql) {
+		t.Errorf("dry run insert should contain all values, got %v", sql)
+	}
+
+	sql = clickhouse.ToSQL(DB, func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(&users[0])
+	})
+	if !regexp.MustCompile("ALTER TABLE `users` DELETE WHERE `id` = 61").MatchString(sql) {
+		t.Errorf("dry run delete should be a mutation, got %v", sql)
+	}
+
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{MaxInsertBlockRows: 2})
+	blockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	sql = clickhouse.ToSQL(blockDB, func(tx *gorm.DB) *gorm.DB {
+		return tx.Create(&users)
+	})
+	if statements := strings.Split(sql, ";\n"); len(statements) != 2 {
+		t.Errorf("dry run insert should be split into 2 blocks, got %v", sql)
+	}
+}
@@ -0,0 +1,60 @@
+package clickhouse
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+// DSN builds connection strings with user supplied values escaped, e.g.
+//
+//	clickhouse.DSN{Hosts: []string{"host1:9000", "host2:9000"}, Database: "analytics", Username: "app", Password: password}.String()
+type DSN struct {
+	Hosts       []string                     // host:port of the servers, connected in order
+	Database    string                       // defaults to the default database of the user
+	Username    string                       // defaults to default
+	Password    string                       // password of Username
+	Settings    clickhouse.Settings          // settings sent with every query, e.g. max_execution_time
+	Compression clickhouse.CompressionMethod // e.g. clickhouse.CompressionLZ4, CompressionNone disables compression
+	Secure      bool                         // connect with TLS
+	HTTP        bool                         // connect with the HTTP protocol instead of the native protocol
+}
+
+// String returns the connection string accepted by clickhouse-go
+func (dsn DSN) String() string {
+	u := url.URL{Scheme: "clickhouse", Host: strings.Join(dsn.Hosts, ","), Path: "/" + dsn.Database}
+	if dsn.HTTP {
+		u.Scheme = "http"
+		if dsn.Secure {
+			u.Scheme = "https"
+		}
+	}
+
+	if dsn.Username != "" || dsn.Password != "" {
+		u.User = url.UserPassword(dsn.Username, dsn.Password)
+	}
+
+	params := url.Values{}
+	for key, value := range dsn.Settings {
+		if custom, ok := value.(clickhouse.CustomSetting); ok {
+			value = custom.Value
+		}
+		params.Set(key, fmt.Sprint(value))
+	}
+	if dsn.Compression != clickhouse.CompressionNone {
+		params.Set("compress", dsn.Compression.String())
+	}
+	if dsn.Secure {
+		params.Set("secure", "true")
+	}
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+// OpenConfig returns a dialector connecting with dsn
+func OpenConfig(dsn DSN) gorm.Dialector {
+	return Open(dsn.String())
+}
@@ -0,0 +1,48 @@
+package clickhouse
+
+import (
+	"fmt"
+	"io"
+
+	"gorm.io/gorm/clause"
+)
+
+// DumpSchema writes the SHOW CREATE output of tables, dictionaries and views of the current database to w,
+// tables are written first and views last, so the dump can be replayed in order, e.g.
+//
+//	db.Migrator().(clickhouse.Migrator).DumpSchema(file)
+func (m Migrator) DumpSchema(w io.Writer) error {
+	type table struct {
+		Name   string
+		Engine string
+	}
+
+	var (
+		database = m.CurrentDatabase()
+		tables   []table
+	)
+	if err := m.DB.Raw(
+		"SELECT name, engine FROM system.tables WHERE database = ? AND is_temporary = 0 AND NOT startsWith(name, '.inner') "+
+			"ORDER BY engine LIKE '%View', engine = 'Dictionary', name",
+		database,
+	).Scan(&tables).Error; err != nil {
+		return err
+	}
+
+	for _, t := range tables {
+		showSQL := "SHOW CREATE TABLE ?"
+		if t.Engine == "Dictionary" {
+			showSQL = "SHOW CREATE DICTIONARY ?"
+		}
+
+		var createSQL string
+		if err := m.DB.Raw(showSQL, clause.Table{Name: database + "." + t.Name}).Row().Scan(&createSQL); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s;\n\n", createSQL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
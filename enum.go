@@ -0,0 +1,132 @@
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrEnumMismatch the Enum definition of a column doesn't match the values of its field type
+var ErrEnumMismatch = errors.New("enum definition mismatch")
+
+// EnumValuesInterface field types implementing it are mapped to Enum8 columns of the values, e.g.
+//
+//	type Status string
+//
+//	func (Status) EnumValues() map[string]int8 {
+//		return map[string]int8{"active": 1, "blocked": 2}
+//	}
+type EnumValuesInterface interface {
+	EnumValues() map[string]int8
+}
+
+var enumValueRegexp = regexp.MustCompile(`'((?:[^'\\]|\\.)*)'\s*=\s*(-?\d+)`)
+
+// enumValuesOf returns the values of the field type implementing EnumValuesInterface
+func enumValuesOf(field *schema.Field) (map[string]int8, bool) {
+	if enum, ok := reflect.New(field.IndirectFieldType).Interface().(EnumValuesInterface); ok {
+		return enum.EnumValues(), true
+	}
+	return nil, false
+}
+
+// enumType renders values as an Enum8 type ordered by value as the server describes it
+func enumType(values map[string]int8) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if values[names[i]] != values[names[j]] {
+			return values[names[i]] < values[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	pairs := make([]string, len(names))
+	for idx, name := range names {
+		pairs[idx] = fmt.Sprintf("'%s' = %d", strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(name), values[name])
+	}
+	return "Enum8(" + strings.Join(pairs, ", ") + ")"
+}
+
+// parseEnumType returns the values of an Enum8 or Enum16 column type, false for other types
+func parseEnumType(columnType string) (map[string]int8, bool) {
+	columnType = strings.TrimSpace(columnType)
+	for _, wrapper := range []string{"LowCardinality(", "Nullable("} {
+		if strings.HasPrefix(columnType, wrapper) && strings.HasSuffix(columnType, ")") {
+			columnType = columnType[len(wrapper) : len(columnType)-1]
+		}
+	}
+	if !strings.HasPrefix(columnType, "Enum8(") && !strings.HasPrefix(columnType, "Enum16(") {
+		return nil, false
+	}
+
+	values := map[string]int8{}
+	for _, match := range enumValueRegexp.FindAllStringSubmatch(columnType, -1) {
+		value, err := strconv.ParseInt(match[2], 10, 16)
+		if err != nil || value != int64(int8(value)) {
+			return nil, false
+		}
+		name := strings.NewReplacer(`\\`, `\`, `\'`, `'`).Replace(match[1])
+		values[name] = int8(value)
+	}
+	return values, true
+}
+
+// ValidateEnums returns ErrEnumMismatch when the Enum definition of an existing column differs from the
+// values of its field type implementing EnumValuesInterface, missing tables and columns are skipped,
+// run by AutoMigrate before migrating if ValidateEnums is set, e.g. on startup
+//
+//	err := db.Migrator().(clickhouse.Migrator).ValidateEnums(&User{})
+func (m Migrator) ValidateEnums(values ...interface{}) error {
+	for _, value := range values {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			if stmt.Schema == nil {
+				return nil
+			}
+
+			var columns []struct {
+				Name string
+				Type string
+			}
+			if err := m.DB.Raw(
+				"SELECT name, type FROM system.columns WHERE database = ? AND table = ?",
+				m.CurrentDatabase(), stmt.Table,
+			).Scan(&columns).Error; err != nil {
+				return err
+			}
+
+			columnTypes := make(map[string]string, len(columns))
+			for _, column := range columns {
+				columnTypes[column.Name] = column.Type
+			}
+
+			for _, field := range stmt.Schema.Fields {
+				expected, ok := enumValuesOf(field)
+				if !ok || field.DBName == "" {
+					continue
+				}
+				columnType, ok := columnTypes[field.DBName]
+				if !ok {
+					continue
+				}
+				if actual, ok := parseEnumType(columnType); !ok || !reflect.DeepEqual(actual, expected) {
+					return fmt.Errorf("%w: column %s.%s is %s, %s expects %s",
+						ErrEnumMismatch, stmt.Table, field.DBName, columnType, field.FieldType, enumType(expected))
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
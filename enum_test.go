@@ -0,0 +1,62 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+type EnumStatus string
+
+func (EnumStatus) EnumValues() map[string]int8 {
+	return map[string]int8{"active": 1, "blocked": 2, "it's": 3}
+}
+
+type EnumUser struct {
+	ID     uint64
+	Status EnumStatus
+}
+
+func TestValidateEnums(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{ValidateEnums: true})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	stmt := &gorm.Statement{DB: mockDB}
+	if err := stmt.Parse(&EnumUser{}); err != nil {
+		t.Fatalf("failed to parse, got error %v", err)
+	}
+	expected := `Enum8('active' = 1, 'blocked' = 2, 'it\'s' = 3)`
+	if dataType := mockDB.Migrator().(clickhouse.Migrator).FullDataTypeOf(stmt.Schema.LookUpField("status")).SQL; dataType != expected {
+		t.Errorf("expects %v, got %v", expected, dataType)
+	}
+
+	mock.Returns("FROM system.columns", []string{"name", "type"},
+		[]interface{}{"id", "UInt64"}, []interface{}{"status", `Enum8('active' = 1, 'it\'s' = 3, 'blocked' = 2)`})
+	if err := mockDB.Migrator().(clickhouse.Migrator).ValidateEnums(&EnumUser{}); err != nil {
+		t.Errorf("expects matching enum, got error %v", err)
+	}
+
+	mock.Returns("FROM system.columns", []string{"name", "type"},
+		[]interface{}{"id", "UInt64"}, []interface{}{"status", `Enum8('active' = 1, 'deleted' = 2)`})
+	if err := mockDB.AutoMigrate(&EnumUser{}); !errors.Is(err, clickhouse.ErrEnumMismatch) {
+		t.Errorf("expects ErrEnumMismatch, got %v", err)
+	}
+
+	mock.Reset()
+	mock.Returns("FROM system.columns", []string{"name", "type"},
+		[]interface{}{"id", "UInt64"}, []interface{}{"status", `Enum8('active' = 1, 'deleted' = 2)`})
+	if err := mockDB.Set("clickhouse:create_or_replace", true).AutoMigrate(&EnumUser{}); !errors.Is(err, clickhouse.ErrEnumMismatch) {
+		t.Errorf("expects ErrEnumMismatch before replacing the table, got %v", err)
+	}
+	for _, sql := range mock.SQL() {
+		if strings.Contains(sql, "CREATE OR REPLACE TABLE") {
+			t.Errorf("table shouldn't be replaced after ErrEnumMismatch, got %v", sql)
+		}
+	}
+}
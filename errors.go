@@ -0,0 +1,63 @@
+package clickhouse
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+	"gorm.io/gorm"
+)
+
+// ErrReadOnly returned when writing with a ReadOnly session
+var ErrReadOnly = errors.New("write operation is not allowed in read-only session")
+
+// Error a ClickHouse exception translated by the dialector, it matches both the
+// gorm error it was translated to and the original *clickhouse.Exception with errors.Is/As
+type Error struct {
+	Code    int32
+	Name    string
+	Message string
+
+	translated error
+	exception  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("code: %d, %s: %s", e.Code, e.Name, e.Message)
+}
+
+func (e *Error) Unwrap() []error {
+	if e.translated != nil {
+		return []error{e.translated, e.exception}
+	}
+	return []error{e.exception}
+}
+
+// errorTranslations gorm errors of exception codes, DDL conflicts like TABLE_ALREADY_EXISTS are left to
+// Error and clickhouseerr as they aren't duplicated rows
+var errorTranslations = map[int32]error{
+	clickhouseerr.IllegalColumn:       gorm.ErrInvalidField,
+	clickhouseerr.NoSuchColumnInTable: gorm.ErrInvalidField,
+	clickhouseerr.UnknownIdentifier:   gorm.ErrInvalidField,
+}
+
+// Translate implements gorm.ErrorTranslator interface
+func (dialector Dialector) Translate(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return gorm.ErrRecordNotFound
+	}
+
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		return &Error{
+			Code:       exception.Code,
+			Name:       exception.Name,
+			Message:    exception.Message,
+			translated: errorTranslations[exception.Code],
+			exception:  err,
+		}
+	}
+	return err
+}
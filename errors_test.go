@@ -0,0 +1,45 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"testing"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestTranslate(t *testing.T) {
+	dialector := clickhouse.Dialector{}
+
+	translated := dialector.Translate(&clickhousego.Exception{Code: 16, Name: "NO_SUCH_COLUMN_IN_TABLE", Message: "no such column"})
+	if !errors.Is(translated, gorm.ErrInvalidField) {
+		t.Errorf("error should be translated to ErrInvalidField, got %v", translated)
+	}
+
+	var chErr *clickhouse.Error
+	if !errors.As(translated, &chErr) || chErr.Code != 16 || chErr.Name != "NO_SUCH_COLUMN_IN_TABLE" {
+		t.Errorf("error should expose the exception code, got %#v", translated)
+	}
+
+	var exception *clickhousego.Exception
+	if !errors.As(translated, &exception) {
+		t.Errorf("error should wrap the original exception, got %#v", translated)
+	}
+
+	translated = dialector.Translate(&clickhousego.Exception{Code: 44, Name: "ILLEGAL_COLUMN", Message: "illegal column"})
+	if !errors.Is(translated, gorm.ErrInvalidField) || errors.Is(translated, gorm.ErrDuplicatedKey) {
+		t.Errorf("illegal column should be translated to ErrInvalidField, got %v", translated)
+	}
+
+	translated = dialector.Translate(&clickhousego.Exception{Code: 57, Name: "TABLE_ALREADY_EXISTS", Message: "table already exists"})
+	if errors.Is(translated, gorm.ErrDuplicatedKey) || !errors.As(translated, &chErr) || chErr.Code != 57 {
+		t.Errorf("table already exists should only be exposed as Error, got %#v", translated)
+	}
+
+	tx := newTestDB(t, clickhouse.Config{}, &gorm.Config{TranslateError: true})
+
+	if err := tx.Table("users").Select("not_exists_column").Find(&[]User{}).Error; !errors.Is(err, gorm.ErrInvalidField) {
+		t.Errorf("querying unknown column should return ErrInvalidField, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package clickhouse
+
+import (
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+var (
+	experimentalDDLRegexp       = regexp.MustCompile(`(?is)^\s*(?:CREATE|ALTER)\s`)
+	experimentalIndexTypeRegexp = regexp.MustCompile(`(?i)\bTYPE\s+(\w+)`)
+)
+
+// experimentalFeatures settings required by the experimental features matched in DDL statements
+var experimentalFeatures = []struct {
+	regexp   *regexp.Regexp
+	settings clickhouse.Settings
+}{
+	{regexp.MustCompile(`\bJSON\b`), clickhouse.Settings{"allow_experimental_json_type": 1}},
+	{regexp.MustCompile(`\bObject\s*\(`), clickhouse.Settings{"allow_experimental_object_type": 1}},
+	{regexp.MustCompile(`\b(?:Variant\s*\(|Dynamic\b)`), variantSettings},
+	{regexp.MustCompile(`(?i)\bSTATISTICS\b`), statisticsSettings},
+	{regexp.MustCompile(`(?i)\bWINDOW\s+VIEW\b`), clickhouse.Settings{"allow_experimental_window_view": 1}},
+	{regexp.MustCompile(`(?i)\bLIVE\s+VIEW\b`), clickhouse.Settings{"allow_experimental_live_view": 1}},
+	{regexp.MustCompile(`\bTimeSeries\b`), clickhouse.Settings{"allow_experimental_time_series_table": 1}},
+}
+
+// experimentalSettings returns the allow_experimental_* settings required by the features used in the
+// CREATE or ALTER statement sql, e.g. JSON columns, inverted indexes or window views
+func experimentalSettings(sql string) clickhouse.Settings {
+	if !experimentalDDLRegexp.MatchString(sql) {
+		return nil
+	}
+
+	sql = stripQuoted(sql)
+	settings := clickhouse.Settings{}
+	for _, feature := range experimentalFeatures {
+		if feature.regexp.MatchString(sql) {
+			maps.Copy(settings, feature.settings)
+		}
+	}
+	for _, match := range experimentalIndexTypeRegexp.FindAllStringSubmatch(sql, -1) {
+		if setting, ok := experimentalIndexSettings[strings.ToLower(match[1])]; ok {
+			settings[setting] = 1
+		}
+	}
+	return settings
+}
+
+// stripQuoted returns sql without the contents of its string literals and quoted identifiers, so comments and
+// column names like `json` aren't matched as features
+func stripQuoted(sql string) string {
+	var (
+		result strings.Builder
+		quote  byte
+	)
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case quote == 0:
+			result.WriteByte(c)
+			if c == '\'' || c == '"' || c == '`' {
+				quote = c
+			}
+		case c == '\\':
+			i++
+		case c == quote && i+1 < len(sql) && sql[i+1] == quote:
+			i++
+		case c == quote:
+			result.WriteByte(c)
+			quote = 0
+		}
+	}
+	return result.String()
+}
+
+// enableExperimentalSettings sends the settings required by the experimental features of the DDL statement,
+// settings applied with WithSettings take precedence
+func enableExperimentalSettings(db *gorm.DB) {
+	settings := experimentalSettings(db.Statement.SQL.String())
+	if len(settings) == 0 {
+		return
+	}
+
+	for key := range SettingsFromContext(db.Statement.Context) {
+		delete(settings, key)
+	}
+	db.Statement.Context = settingsContext(db.Statement.Context, settings)
+}
+
+func (dialector *Dialector) registerExperimentalSettingsCallbacks(db *gorm.DB) {
+	if !dialector.EnableExperimentalSettings {
+		return
+	}
+
+	db.Callback().Raw().Before("gorm:raw").Register("clickhouse:experimental_settings", enableExperimentalSettings)
+}
@@ -0,0 +1,199 @@
+package clickhouse
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+// Format data format of the server, see https://clickhouse.com/docs/en/interfaces/formats
+type Format string
+
+const (
+	FormatCSV          Format = "CSV"
+	FormatCSVWithNames Format = "CSVWithNames"
+	FormatTSV          Format = "TabSeparated"
+	FormatTSVWithNames Format = "TabSeparatedWithNames"
+	FormatJSONEachRow  Format = "JSONEachRow"
+	FormatParquet      Format = "Parquet"
+	FormatArrow        Format = "Arrow"
+	FormatNative       Format = "Native"
+	FormatRowBinary    Format = "RowBinary"
+	FormatORC          Format = "ORC"
+	FormatAvro         Format = "Avro"
+	FormatJSONCompact  Format = "JSONCompactEachRow"
+)
+
+// querySQL returns the SQL of query with inlined vars, query is a raw SQL string or a *gorm.DB query
+func querySQL(query interface{}) (string, error) {
+	switch q := query.(type) {
+	case string:
+		return q, nil
+	case *gorm.DB:
+		stmt := q.Session(&gorm.Session{DryRun: true}).Find(&[]map[string]interface{}{}).Statement
+		if stmt.Error != nil {
+			return "", stmt.Error
+		}
+		return inlineVars(stmt.SQL.String(), explainVars(stmt.Vars))
+	}
+	return "", fmt.Errorf("%w: unsupported query type %T", gorm.ErrInvalidData, query)
+}
+
+// inlineVars replaces the placeholders of sql outside its literals and quoted identifiers with the literals of vars,
+// unlike Explain the result is safe to execute
+func inlineVars(sql string, vars []interface{}) (string, error) {
+	var (
+		result strings.Builder
+		quote  byte
+		idx    int
+	)
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case quote == 0 && c == '?' && idx < len(vars):
+			value, err := literal(vars[idx])
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(value)
+			idx++
+			continue
+		case quote == 0:
+			if c == '\'' || c == '"' || c == '`' {
+				quote = c
+			}
+		case c == '\\' && i+1 < len(sql):
+			result.WriteByte(c)
+			i++
+			c = sql[i]
+		case c == quote && i+1 < len(sql) && sql[i+1] == quote:
+			result.WriteByte(c)
+			i++
+		case c == quote:
+			quote = 0
+		}
+		result.WriteByte(sql[i])
+	}
+
+	if idx != len(vars) {
+		return "", fmt.Errorf("%w: %d vars for %d placeholders", gorm.ErrInvalidData, len(vars), idx)
+	}
+	return result.String(), nil
+}
+
+// literal returns the ClickHouse literal of value, times are written in UTC with their nanoseconds so the server
+// doesn't read them in its own time zone
+func literal(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case time.Time:
+		return "toDateTime64(" + quoteString(v.UTC().Format("2006-01-02 15:04:05.999999999")) + ", 9, 'UTC')", nil
+	case []byte:
+		return quoteString(string(v)), nil
+	case driver.Valuer:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return "NULL", nil
+		}
+		driverValue, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		if _, ok := driverValue.(driver.Valuer); ok && reflect.TypeOf(driverValue) == rv.Type() {
+			return "", fmt.Errorf("%w: unsupported var %T", gorm.ErrInvalidData, value)
+		}
+		return literal(driverValue)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return literal(rv.Elem().Interface())
+	case rv.Type().ConvertibleTo(reflect.TypeOf(time.Time{})):
+		return literal(rv.Convert(reflect.TypeOf(time.Time{})).Interface())
+	case rv.Kind() == reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case rv.CanInt():
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case rv.CanUint():
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case rv.CanFloat():
+		switch f := rv.Float(); {
+		case math.IsNaN(f):
+			return "nan", nil
+		case math.IsInf(f, 1):
+			return "inf", nil
+		case math.IsInf(f, -1):
+			return "-inf", nil
+		}
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	case rv.Kind() == reflect.String:
+		return quoteString(rv.String()), nil
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		return quoteString(string(rv.Bytes())), nil
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		values := make([]string, rv.Len())
+		for i := range values {
+			value, err := literal(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			values[i] = value
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("%w: unsupported var %T", gorm.ErrInvalidData, value)
+}
+
+// Export runs query with FORMAT format over the HTTP interface and streams the
+// server formatted output to w without decoding rows, query is a raw SQL string or a *gorm.DB query, e.g.
+//
+//	clickhouse.Export(db, db.Model(&Event{}).Where("day = ?", day), clickhouse.Format("CSVWithNames"), file)
+func Export(db *gorm.DB, query interface{}, format Format, w io.Writer) error {
+	dialector, err := dialectorOf(db)
+	if err != nil {
+		return err
+	}
+
+	sql, err := querySQL(query)
+	if err != nil {
+		return err
+	}
+
+	resp, err := dialector.httpDo(db.Statement.Context, sql+" FORMAT "+string(format), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// ToParquet writes the rows of query to w as a Parquet file, the file is written by the server and streamed to w
+// in row groups of output_format_parquet_row_group_size rows without buffering it, e.g.
+//
+//	file, _ := os.Create("events.parquet")
+//	err := clickhouse.ToParquet(db, db.Model(&Event{}).Where("day = ?", day), file)
+//
+// Column types are mapped by the server: integers and floats to the Parquet types of the same width, String
+// to STRING (BYTE_ARRAY without output_format_parquet_string_as_string), Date to DATE, DateTime to UINT32
+// seconds, DateTime64 to TIMESTAMP, Decimal to DECIMAL, Array to LIST, Tuple to STRUCT and Map to MAP,
+// Nullable columns are optional and LowCardinality columns use the type of their values
+func ToParquet(db *gorm.DB, query interface{}, w io.Writer) error {
+	if _, ok := SettingsFromContext(db.Statement.Context)["output_format_parquet_string_as_string"]; !ok {
+		db = WithSettings(db, clickhouse.Settings{"output_format_parquet_string_as_string": 1})
+	}
+	return Export(db, query, FormatParquet, w)
+}
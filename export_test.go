@@ -0,0 +1,94 @@
+package clickhouse_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestExport(t *testing.T) {
+	users := []User{{ID: 501, Name: "export1", Age: 18}, {ID: 502, Name: "export2", Age: 20}}
+	if err := DB.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users, got error %v", err)
+	}
+
+	var buf bytes.Buffer
+	query := DB.Model(&User{}).Select("id", "name").Where("id IN ?", []uint64{501, 502}).Order("id")
+	if err := clickhouse.Export(DB, query, clickhouse.FormatCSVWithNames, &buf); err != nil {
+		t.Fatalf("failed to export users, got error %v", err)
+	}
+
+	if expects := "\"id\",\"name\"\n501,\"export1\"\n502,\"export2\"\n"; buf.String() != expects {
+		t.Errorf("expects exported csv %q, got %q", expects, buf.String())
+	}
+
+	buf.Reset()
+	if err := clickhouse.Export(DB, "SELECT name FROM users WHERE id = 501", clickhouse.FormatJSONEachRow, &buf); err != nil {
+		t.Fatalf("failed to export users, got error %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `{"name":"export1"}`) {
+		t.Errorf("expects exported json row, got %q", buf.String())
+	}
+
+	if err := clickhouse.Export(DB, "SELECT * FROM not_exists_table", clickhouse.FormatCSV, &buf); err == nil {
+		t.Errorf("should return error when exporting from an unknown table")
+	}
+}
+
+func TestToParquet(t *testing.T) {
+	users := []User{{ID: 511, Name: "parquet1", Age: 18}, {ID: 512, Name: "parquet2", Age: 20}}
+	if err := DB.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users, got error %v", err)
+	}
+
+	var buf bytes.Buffer
+	query := DB.Model(&User{}).Select("id", "name").Where("id IN ?", []uint64{511, 512})
+	if err := clickhouse.ToParquet(DB, query, &buf); err != nil {
+		t.Fatalf("failed to export users, got error %v", err)
+	}
+
+	if data := buf.Bytes(); !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Errorf("expects a parquet file, got %q", data)
+	}
+}
+
+func TestExportLiterals(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query = string(body)
+	}))
+	defer server.Close()
+
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{HTTPURL: server.URL})
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	createdAt := time.Date(2024, 1, 2, 11, 4, 5, 123456789, time.FixedZone("UTC+8", 8*3600))
+	tx := db.Model(&User{}).Select("id").
+		Where("name <> '?'").Where("name = ? OR name = ?", `jinzhu\`, "' OR 1 = 1 --").
+		Where("created_at > ? AND name <> ? AND age = ?", createdAt, []byte{'a', 0, 0xff}, 18)
+	if err := clickhouse.Export(db, tx, clickhouse.FormatCSV, io.Discard); err != nil {
+		t.Fatalf("failed to export, got error %v", err)
+	}
+
+	expects := "SELECT `id` FROM `users` WHERE name <> '?' AND (name = 'jinzhu\\\\' OR name = '\\' OR 1 = 1 --') AND (created_at > " +
+		"toDateTime64('2024-01-02 03:04:05.123456789', 9, 'UTC') AND name <> 'a\\x00\\xFF' AND age = 18) FORMAT CSV"
+	if query != expects {
+		t.Errorf("expects query %q, got %q", expects, query)
+	}
+
+	if err := clickhouse.Export(db, db.Model(&User{}).Where("name = ?", struct{}{}), clickhouse.FormatCSV, io.Discard); err == nil {
+		t.Errorf("should return error when exporting unsupported vars")
+	}
+}
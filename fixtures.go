@@ -0,0 +1,206 @@
+package clickhouse
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// fixtureTimeFormats the YAML timestamp formats, parsed by fixtureValue
+var fixtureTimeFormats = []string{
+	"2006-1-2T15:4:5.999999999Z07:00",
+	"2006-1-2t15:4:5.999999999Z07:00",
+	"2006-1-2 15:4:5.999999999Z07:00",
+	"2006-1-2 15:4:5.999999999",
+	"2006-1-2",
+}
+
+// GenerateRandom inserts rows of random data into the table of model server side with the generateRandom
+// table function, the structure is derived from the fields of model, e.g.
+//
+//	clickhouse.GenerateRandom(db, &Event{}, 10_000_000)
+func GenerateRandom(db *gorm.DB, model interface{}, rows int64) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	columns := make([]clause.Column, 0, len(stmt.Schema.DBNames))
+	structure := make([]string, 0, len(stmt.Schema.DBNames))
+	for _, dbName := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[dbName]
+		columns = append(columns, clause.Column{Name: dbName})
+		structure = append(structure, stmt.Quote(dbName)+" "+db.Dialector.DataTypeOf(field))
+	}
+
+	return db.Exec(
+		"INSERT INTO ? (?) SELECT * FROM generateRandom(?) LIMIT ?",
+		clause.Table{Name: stmt.Table}, columns, strings.Join(structure, ", "), rows,
+	).Error
+}
+
+// Fixture rows loaded into the table of Model by Seed, Rows is a slice of the model, e.g. []User, or of
+// maps from column or field names to values
+type Fixture struct {
+	Model interface{}
+	Rows  interface{}
+}
+
+// ParseFixtures parses YAML or JSON fixtures keyed by the table names of models in db, e.g.
+//
+//	users:
+//	  - id: 1
+//	    name: jinzhu
+//	    created_at: 2024-01-01 00:00:00
+func ParseFixtures(db *gorm.DB, data []byte, models ...interface{}) ([]Fixture, error) {
+	var (
+		document yaml.Node
+		tables   map[string][]map[string]interface{}
+	)
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	keepTimestamps(&document)
+	if err := document.Decode(&tables); err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		if rows, ok := tables[stmt.Table]; ok {
+			fixtures = append(fixtures, Fixture{Model: model, Rows: rows})
+			delete(tables, stmt.Table)
+		}
+	}
+
+	if len(tables) > 0 {
+		names := make([]string, 0, len(tables))
+		for table := range tables {
+			names = append(names, table)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("no model of fixtures tables %s", strings.Join(names, ", "))
+	}
+	return fixtures, nil
+}
+
+// keepTimestamps keeps the timestamps of node as strings, yaml.v3 decodes those without a timezone in UTC,
+// fixtureValue parses them in the timezone of their column
+func keepTimestamps(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!timestamp" {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		keepTimestamps(child)
+	}
+}
+
+// Seed truncates the tables of fixtures and inserts their rows in native batches, for integration test setup, e.g.
+//
+//	clickhouse.Seed(db, clickhouse.Fixture{Model: &User{}, Rows: []User{{ID: 1, Name: "jinzhu"}}})
+func Seed(db *gorm.DB, fixtures ...Fixture) error {
+	stmts := make([]*gorm.Statement, len(fixtures))
+	truncated := map[string]bool{}
+	for idx, fixture := range fixtures {
+		stmt := &gorm.Statement{DB: db, Context: db.Statement.Context}
+		if err := stmt.Parse(fixture.Model); err != nil {
+			return err
+		}
+		stmts[idx] = stmt
+
+		if !truncated[stmt.Table] {
+			if err := db.Exec("TRUNCATE TABLE ?", clause.Table{Name: stmt.Table}).Error; err != nil {
+				return err
+			}
+			truncated[stmt.Table] = true
+		}
+	}
+
+	for idx, fixture := range fixtures {
+		rows, err := fixtureRows(stmts[idx], fixture.Rows)
+		if err != nil {
+			return err
+		}
+		if reflect.Indirect(rows).Len() == 0 {
+			continue
+		}
+		if err := db.Table(stmts[idx].Table).Create(rows.Interface()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixtureRows returns a pointer to a slice of the model of stmt with the rows of a fixture
+func fixtureRows(stmt *gorm.Statement, rows interface{}) (reflect.Value, error) {
+	rowsValue := reflect.Indirect(reflect.ValueOf(rows))
+	if rowsValue.Kind() != reflect.Slice && rowsValue.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("fixture rows of %s should be a slice, got %T", stmt.Table, rows)
+	}
+
+	result := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if rowsValue.Type().Elem() == stmt.Schema.ModelType {
+		result.Elem().Set(reflect.AppendSlice(result.Elem(), rowsValue))
+		return result, nil
+	}
+
+	for i := 0; i < rowsValue.Len(); i++ {
+		row := reflect.Indirect(rowsValue.Index(i))
+		switch {
+		case row.Type() == stmt.Schema.ModelType:
+			result.Elem().Set(reflect.Append(result.Elem(), row))
+		case row.Kind() == reflect.Map && row.Type().Key().Kind() == reflect.String:
+			model := reflect.New(stmt.Schema.ModelType).Elem()
+			iter := row.MapRange()
+			for iter.Next() {
+				field := stmt.Schema.LookUpField(iter.Key().String())
+				if field == nil {
+					return reflect.Value{}, fmt.Errorf("unknown column %s of fixture table %s", iter.Key().String(), stmt.Table)
+				}
+				if err := field.Set(stmt.Context, model, fixtureValue(stmt, field, iter.Value().Interface())); err != nil {
+					return reflect.Value{}, fmt.Errorf("column %s of fixture table %s: %w", field.DBName, stmt.Table, err)
+				}
+			}
+			result.Elem().Set(reflect.Append(result.Elem(), model))
+		default:
+			return reflect.Value{}, fmt.Errorf("fixture row of %s should be %v or a map, got %v", stmt.Table, stmt.Schema.ModelType, row.Type())
+		}
+	}
+	return result, nil
+}
+
+// fixtureValue parses the strings of time fields in the timezone of their column, UTC for columns without one,
+// instead of the local timezone field.Set parses them in, strings with a timezone keep it
+func fixtureValue(stmt *gorm.Statement, field *schema.Field, value interface{}) interface{} {
+	str, ok := value.(string)
+	if !ok || field.IndirectFieldType != schema.TimeReflectType {
+		return value
+	}
+
+	dataType := field.TagSettings["TYPE"]
+	if dataType == "" {
+		dataType = stmt.DB.Dialector.DataTypeOf(field)
+	}
+	loc := columnLocation(dataType)
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	for _, format := range fixtureTimeFormats {
+		if t, err := time.ParseInLocation(format, strings.TrimSpace(str), loc); err == nil {
+			return t
+		}
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+package clickhouse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestGenerateRandom(t *testing.T) {
+	type RandomEvent struct {
+		ID    uint64
+		Name  string
+		Score float64
+	}
+
+	DB.Migrator().DropTable(&RandomEvent{})
+	if err := DB.AutoMigrate(&RandomEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	if err := clickhouse.GenerateRandom(DB, &RandomEvent{}, 1000); err != nil {
+		t.Fatalf("failed to generate random rows, got error %v", err)
+	}
+
+	var count int64
+	if err := DB.Model(&RandomEvent{}).Count(&count).Error; err != nil || count != 1000 {
+		t.Errorf("expects 1000 random rows, got %v, error %v", count, err)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	type SeedEvent struct {
+		ID        uint64
+		Name      string
+		CreatedAt time.Time
+	}
+
+	DB.Migrator().DropTable(&SeedEvent{})
+	if err := DB.AutoMigrate(&SeedEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	if err := DB.Create(&SeedEvent{ID: 100, Name: "stale"}).Error; err != nil {
+		t.Fatalf("failed to create, got error %v", err)
+	}
+
+	fixtures, err := clickhouse.ParseFixtures(DB, []byte(`
+seed_events:
+  - id: 1
+    name: yaml
+    created_at: 2024-01-01 10:00:00
+  - {"id": 2, "name": "json"}
+`), &SeedEvent{})
+	if err != nil {
+		t.Fatalf("failed to parse fixtures, got error %v", err)
+	}
+
+	fixtures = append(fixtures, clickhouse.Fixture{Model: &SeedEvent{}, Rows: []SeedEvent{{ID: 3, Name: "struct"}}})
+	if err := clickhouse.Seed(DB, fixtures...); err != nil {
+		t.Fatalf("failed to seed, got error %v", err)
+	}
+
+	var events []SeedEvent
+	if err := DB.Order("id").Find(&events).Error; err != nil {
+		t.Fatalf("failed to query, got error %v", err)
+	}
+	if len(events) != 3 || events[0].Name != "yaml" || !events[0].CreatedAt.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) || events[2].Name != "struct" {
+		t.Errorf("expects seeded events only, got %+v", events)
+	}
+
+	if _, err := clickhouse.ParseFixtures(DB, []byte(`{"unknown_table": []}`), &SeedEvent{}); err == nil {
+		t.Errorf("fixtures of tables without model should fail")
+	}
+}
+
+func TestSeedTimeZone(t *testing.T) {
+	type SeedLog struct {
+		ID        uint64
+		CreatedAt time.Time
+		TokyoAt   time.Time `gorm:"type:DateTime('Asia/Tokyo')"`
+	}
+
+	local := time.Local
+	defer func() { time.Local = local }()
+	time.Local = time.FixedZone("UTC+8", 8*60*60)
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	fixtures, err := clickhouse.ParseFixtures(mockDB, []byte(`
+seed_logs:
+  - id: 1
+    created_at: 2024-01-01 10:00:00
+    tokyo_at: 2024-01-01 10:00:00
+  - id: 2
+    created_at: 2024-01-01T10:00:00+02:00
+    tokyo_at: "2024-01-01 10:00:00"
+`), &SeedLog{})
+	if err != nil {
+		t.Fatalf("failed to parse fixtures, got error %v", err)
+	}
+	if err := clickhouse.Seed(mockDB, fixtures...); err != nil {
+		t.Fatalf("failed to seed, got error %v", err)
+	}
+
+	statements := mock.Statements()
+	if len(statements) != 3 || len(statements[1].Args) != 3 || len(statements[2].Args) != 3 {
+		t.Fatalf("expects truncate and insert, got %v", mock.SQL())
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	expects := []time.Time{
+		time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 10, 0, 0, 0, tokyo),
+		time.Date(2024, 1, 1, 10, 0, 0, 0, time.FixedZone("", 2*60*60)),
+		time.Date(2024, 1, 1, 10, 0, 0, 0, tokyo),
+	}
+	for idx, arg := range []interface{}{statements[1].Args[0], statements[1].Args[1], statements[2].Args[0], statements[2].Args[1]} {
+		if value, ok := arg.(time.Time); !ok || !value.Equal(expects[idx]) {
+			t.Errorf("expects time %d %v, got %v", idx, expects[idx], arg)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package clickhouse
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+var commonInitialisms = map[string]string{
+	"id": "ID", "ip": "IP", "url": "URL", "uri": "URI", "uuid": "UUID", "api": "API",
+	"http": "HTTP", "json": "JSON", "sql": "SQL", "utc": "UTC", "ttl": "TTL",
+}
+
+// goName converts a snake case column or table name to an exported go identifier
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if initialism, ok := commonInitialisms[strings.ToLower(part)]; ok {
+			b.WriteString(initialism)
+		} else {
+			runes := []rune(part)
+			b.WriteRune(unicode.ToUpper(runes[0]))
+			b.WriteString(string(runes[1:]))
+		}
+	}
+
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "F" + b.String()
+	}
+	return b.String()
+}
+
+// splitTypeArgs splits the arguments of a parametric type at top level commas
+func splitTypeArgs(args string) []string {
+	var (
+		results []string
+		depth   int
+		start   int
+	)
+	for idx, r := range args {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				results = append(results, strings.TrimSpace(args[start:idx]))
+				start = idx + 1
+			}
+		}
+	}
+	return append(results, strings.TrimSpace(args[start:]))
+}
+
+// goTypeOf returns the go type used to scan columns of the clickhouse type and the imports it requires
+func goTypeOf(typ string, imports map[string]bool) string {
+	name, args := typ, ""
+	if idx := strings.IndexByte(typ, '('); idx > 0 && strings.HasSuffix(typ, ")") {
+		name, args = typ[:idx], typ[idx+1:len(typ)-1]
+	}
+
+	switch name {
+	case "Nullable":
+		return "*" + goTypeOf(args, imports)
+	case "LowCardinality":
+		return goTypeOf(args, imports)
+	case "Array":
+		return "[]" + goTypeOf(args, imports)
+	case "Map":
+		if kv := splitTypeArgs(args); len(kv) == 2 {
+			return "map[" + goTypeOf(kv[0], imports) + "]" + goTypeOf(kv[1], imports)
+		}
+	case "Tuple":
+		return "[]interface{}"
+	case "Bool":
+		return "bool"
+	case "Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64":
+		return strings.ToLower(name)
+	case "Int128", "Int256", "UInt128", "UInt256":
+		imports["math/big"] = true
+		return "big.Int"
+	case "Float32", "Float64":
+		return strings.ToLower(name)
+	case "String", "FixedString", "UUID", "Enum8", "Enum16":
+		return "string"
+	case "Date", "Date32", "DateTime", "DateTime64":
+		imports["time"] = true
+		return "time.Time"
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		imports["github.com/shopspring/decimal"] = true
+		return "decimal.Decimal"
+	case "IPv4", "IPv6":
+		imports["net"] = true
+		return "net.IP"
+	}
+	return "interface{}"
+}
+
+// GenModels generates go structs with gorm tags for tables of database into outDir, one file for each table
+// named after the lower case struct name, so table names can't write outside outDir or end in _test or a
+// build constraint, the package name is the name of outDir, e.g.
+//
+//	clickhouse.GenModels(db, "warehouse", "./models")
+func GenModels(db *gorm.DB, database string, outDir string) error {
+	var columns []struct {
+		Table          string
+		Name           string
+		Type           string
+		Comment        string
+		IsInPrimaryKey uint8
+	}
+	if err := db.Raw(
+		"SELECT table, name, type, comment, is_in_primary_key FROM system.columns WHERE database = ? AND NOT startsWith(table, '.inner') ORDER BY table, position",
+		database,
+	).Scan(&columns).Error; err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	absDir, err := filepath.Abs(outDir)
+	if err != nil {
+		return err
+	}
+	pkg := strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, filepath.Base(absDir)))
+
+	files := map[string]string{}
+	for start := 0; start < len(columns); {
+		end := start
+		for end < len(columns) && columns[end].Table == columns[start].Table {
+			end++
+		}
+
+		var (
+			table   = columns[start].Table
+			imports = map[string]bool{}
+			fields  bytes.Buffer
+		)
+		for _, column := range columns[start:end] {
+			tag := "column:" + column.Name + ";type:" + column.Type
+			if column.IsInPrimaryKey == 1 {
+				tag += ";primaryKey"
+			}
+			if column.Comment != "" {
+				tag += ";comment:" + strings.NewReplacer(";", `\;`, "`", "'").Replace(column.Comment)
+			}
+			fmt.Fprintf(&fields, "\t%s %s `gorm:%s`\n", goName(column.Name), goTypeOf(column.Type, imports), strconv.Quote(tag))
+		}
+
+		var src bytes.Buffer
+		fmt.Fprintf(&src, "// Code generated by clickhouse.GenModels. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+		if len(imports) > 0 {
+			paths := make([]string, 0, len(imports))
+			for path := range imports {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			src.WriteString("import (\n")
+			for _, path := range paths {
+				fmt.Fprintf(&src, "\t%q\n", path)
+			}
+			src.WriteString(")\n\n")
+		}
+		structName := goName(table)
+		fileName := strings.ToLower(structName) + ".go"
+		if other, ok := files[fileName]; ok {
+			return fmt.Errorf("%w: tables %s and %s generate the same model %s", gorm.ErrInvalidData, other, table, structName)
+		}
+		files[fileName] = table
+		fmt.Fprintf(&src, "type %s struct {\n%s}\n\n", structName, fields.String())
+		fmt.Fprintf(&src, "func (%s) TableName() string {\n\treturn %q\n}\n", structName, table)
+
+		formatted, err := format.Source(src.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to format model of table %s: %w", table, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(outDir, fileName), formatted, 0o644); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
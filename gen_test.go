@@ -0,0 +1,80 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestGenModels(t *testing.T) {
+	outDir := filepath.Join(t.TempDir(), "models")
+	if err := clickhouse.GenModels(DB, "gorm", outDir); err != nil {
+		t.Fatalf("failed to generate models, got error %v", err)
+	}
+
+	src, err := os.ReadFile(filepath.Join(outDir, "users.go"))
+	if err != nil {
+		t.Fatalf("failed to read generated model, got error %v", err)
+	}
+
+	for _, expects := range []string{
+		`package models`,
+		`type Users struct {`,
+		`ID\s+uint64\s+` + "`" + `gorm:"column:id;type:UInt64"` + "`",
+		`Age\s+\*int64\s+` + "`" + `gorm:"column:age;type:Nullable\(Int64\)"` + "`",
+		`Attrs\s+map\[string\]string\s+` + "`" + `gorm:"column:attrs;type:Map\(String, String\)"` + "`",
+		`return "users"`,
+	} {
+		if !regexp.MustCompile(expects).Match(src) {
+			t.Errorf("generated model should match %q, got %v", expects, string(src))
+		}
+	}
+}
+
+func TestGenModelsFileNames(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	columns := []string{"table", "name", "type", "comment", "is_in_primary_key"}
+	mock.Returns(`FROM system.columns`, columns,
+		[]interface{}{"../escaped", "id", "UInt64", "", uint8(1)},
+		[]interface{}{"events_test", "id", "UInt64", "", uint8(1)},
+		[]interface{}{"events_linux", "id", "UInt64", "", uint8(1)},
+	)
+
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "models")
+	if err := clickhouse.GenModels(mockDB, "gorm", outDir); err != nil {
+		t.Fatalf("failed to generate models, got error %v", err)
+	}
+
+	var files []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if expects := []string{"models/escaped.go", "models/eventslinux.go", "models/eventstest.go"}; !reflect.DeepEqual(files, expects) {
+		t.Errorf("expects model files %v, got %v", expects, files)
+	}
+
+	mock.Returns(`FROM system.columns`, columns,
+		[]interface{}{"events_test", "id", "UInt64", "", uint8(1)},
+		[]interface{}{"eventsTest", "id", "UInt64", "", uint8(1)},
+	)
+	if err := clickhouse.GenModels(mockDB, "gorm", outDir); !errors.Is(err, gorm.ErrInvalidData) {
+		t.Errorf("expects ErrInvalidData for tables generating the same model, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package clickhouse
+
+import (
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const globalName = "gorm:clickhouse:global"
+
+var (
+	globalInRegexp   = regexp.MustCompile(`(?i)\b(GLOBAL\s+)?((?:NOT\s+)?IN)\s*\(?\s*$`)
+	globalJoinRegexp = regexp.MustCompile(`(?i)^\s*(GLOBAL\s+)?((?:(?:ANY|ALL|ASOF|INNER|LEFT|RIGHT|FULL|CROSS|OUTER|SEMI|ANTI|PASTE)\s+)*JOIN)\b`)
+)
+
+// GlobalClause rewrites IN subqueries and JOINs of the statement to GLOBAL IN / GLOBAL JOIN,
+// which is required for subqueries against distributed tables to return correct results
+type GlobalClause struct{}
+
+// ModifyStatement implements gorm.StatementModifier interface
+func (GlobalClause) ModifyStatement(stmt *gorm.Statement) {
+	stmt.Settings.Store(globalName, true)
+}
+
+// Build implements clause.Expression interface
+func (GlobalClause) Build(clause.Builder) {
+}
+
+// Global scope rewriting IN subqueries and JOINs to GLOBAL IN / GLOBAL JOIN, subqueries are found by their
+// *gorm.DB vars, subqueries written in the SQL text are kept as they are, e.g.
+//
+//	db.Scopes(clickhouse.Global).Where("user_id IN (?)", subQuery).Find(&events)
+func Global(db *gorm.DB) *gorm.DB {
+	return db.Clauses(GlobalClause{})
+}
+
+func isGlobal(stmt *gorm.Statement) bool {
+	v, ok := stmt.Settings.Load(globalName)
+	return ok && v == true
+}
+
+// buildWithGlobal builds the clause with GLOBAL IN subqueries and GLOBAL JOINs when required
+func buildWithGlobal(c clause.Clause, builder clause.Builder) {
+	if stmt, ok := builder.(*gorm.Statement); ok && isGlobal(stmt) {
+		c.Expression = globalExpression(c.Expression)
+	}
+	c.Build(builder)
+}
+
+// globalExpression returns expr with its IN subqueries and joins rewritten to GLOBAL IN and GLOBAL JOIN, the
+// subqueries should be passed as *gorm.DB vars, e.g. Where("id IN (?)", subQuery), to be found
+func globalExpression(expr clause.Expression) clause.Expression {
+	switch e := expr.(type) {
+	case clause.Where:
+		return clause.Where{Exprs: globalExpressions(e.Exprs)}
+	case clause.AndConditions:
+		return clause.AndConditions{Exprs: globalExpressions(e.Exprs)}
+	case clause.OrConditions:
+		return clause.OrConditions{Exprs: globalExpressions(e.Exprs)}
+	case clause.NotConditions:
+		return clause.NotConditions{Exprs: globalExpressions(e.Exprs)}
+	case clause.IN:
+		for _, value := range e.Values {
+			if _, ok := value.(*gorm.DB); ok {
+				return globalIn(e)
+			}
+		}
+	case clause.Expr:
+		e.SQL = globalInSQL(e.SQL, e.Vars)
+		return e
+	case clause.NamedExpr:
+		e.SQL = globalInSQL(e.SQL, e.Vars)
+		return e
+	case clause.From:
+		joins := make([]clause.Join, len(e.Joins))
+		for idx, join := range e.Joins {
+			joins[idx] = globalJoin(join)
+		}
+		e.Joins = joins
+		return e
+	}
+	return expr
+}
+
+func globalExpressions(exprs []clause.Expression) []clause.Expression {
+	results := make([]clause.Expression, len(exprs))
+	for idx, expr := range exprs {
+		results[idx] = globalExpression(expr)
+	}
+	return results
+}
+
+// globalInSQL adds GLOBAL to the IN before the placeholders of the *gorm.DB vars of sql
+func globalInSQL(sql string, vars []interface{}) string {
+	var (
+		result strings.Builder
+		last   int
+		idx    int
+	)
+	for pos := 0; pos < len(sql) && idx < len(vars); pos++ {
+		if sql[pos] != '?' {
+			continue
+		}
+		if _, ok := vars[idx].(*gorm.DB); ok {
+			if match := globalInRegexp.FindStringSubmatchIndex(sql[last:pos]); match != nil && match[2] < 0 {
+				result.WriteString(sql[last : last+match[4]])
+				result.WriteString("GLOBAL ")
+				result.WriteString(sql[last+match[4] : pos])
+				last = pos
+			}
+		}
+		idx++
+	}
+	if last == 0 {
+		return sql
+	}
+	result.WriteString(sql[last:])
+	return result.String()
+}
+
+// globalJoin returns join written as GLOBAL JOIN, ARRAY JOINs are kept as they are
+func globalJoin(join clause.Join) clause.Join {
+	switch e := join.Expression.(type) {
+	case nil:
+		join.Type = clause.JoinType(strings.TrimSpace("GLOBAL " + string(join.Type)))
+		join.ON = clause.Where{Exprs: globalExpressions(join.ON.Exprs)}
+	case Join:
+		e.Kind = globalJoinKind(e.Kind)
+		join.Expression = e
+	case ASOFJoinClause:
+		if e.Kind == "" {
+			e.Kind = "ASOF LEFT"
+		}
+		e.Kind = globalJoinKind(e.Kind)
+		join.Expression = e
+	case clause.Expr:
+		e.SQL = globalJoinSQL(e.SQL)
+		join.Expression = globalExpression(e)
+	case clause.NamedExpr:
+		e.SQL = globalJoinSQL(e.SQL)
+		join.Expression = globalExpression(e)
+	}
+	return join
+}
+
+func globalJoinKind(kind string) string {
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(kind)), "GLOBAL") {
+		return kind
+	}
+	return strings.TrimSpace("GLOBAL " + kind)
+}
+
+// globalJoinSQL adds GLOBAL to the join keywords the raw join sql starts with, e.g. Joins("LEFT JOIN ...")
+func globalJoinSQL(sql string) string {
+	if match := globalJoinRegexp.FindStringSubmatchIndex(sql); match != nil && match[2] < 0 {
+		return sql[:match[4]] + "GLOBAL " + sql[match[4]:]
+	}
+	return sql
+}
@@ -0,0 +1,134 @@
+package clickhouse
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const groupModifierName = "GROUP BY MODIFIER"
+
+// GroupModifier adds WITH ROLLUP / WITH CUBE / WITH TOTALS to the GROUP BY clause, e.g.
+//
+//	db.Model(&Event{}).Select("country, count()").Group("country").Clauses(clickhouse.WithTotals)
+type GroupModifier struct {
+	Rollup bool
+	Cube   bool
+	Totals bool
+}
+
+var (
+	WithRollup = GroupModifier{Rollup: true}
+	WithCube   = GroupModifier{Cube: true}
+	WithTotals = GroupModifier{Totals: true}
+)
+
+// Name implements clause.Interface interface
+func (GroupModifier) Name() string {
+	return groupModifierName
+}
+
+// Build implements clause.Expression interface
+func (m GroupModifier) Build(builder clause.Builder) {
+	switch {
+	case m.Rollup:
+		builder.WriteString("WITH ROLLUP")
+	case m.Cube:
+		builder.WriteString("WITH CUBE")
+	}
+
+	if m.Totals {
+		if m.Rollup || m.Cube {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString("WITH TOTALS")
+	}
+}
+
+// MergeClause implements clause.Interface interface
+func (m GroupModifier) MergeClause(c *clause.Clause) {
+	if v, ok := c.Expression.(GroupModifier); ok {
+		m.Rollup = m.Rollup || v.Rollup
+		m.Cube = m.Cube || v.Cube
+		m.Totals = m.Totals || v.Totals
+	}
+	c.Expression = m
+}
+
+// buildGroupBy writes the group by modifiers between GROUP BY columns and HAVING conditions
+func buildGroupBy(c clause.Clause, builder clause.Builder) {
+	var modifier clause.Clause
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		modifier = stmt.Clauses[groupModifierName]
+	}
+
+	groupBy, ok := c.Expression.(clause.GroupBy)
+	if !ok || modifier.Expression == nil {
+		c.Build(builder)
+		return
+	}
+
+	having := groupBy.Having
+	groupBy.Having = nil
+	c.Expression = groupBy
+	c.Build(builder)
+
+	builder.WriteByte(' ')
+	modifier.Expression.Build(builder)
+
+	if len(having) > 0 {
+		builder.WriteString(" HAVING ")
+		clause.Where{Exprs: having}.Build(builder)
+	}
+}
+
+// FindWithTotals runs the query WITH TOTALS, scans the rows into dest and the totals row into totals
+func FindWithTotals(db *gorm.DB, dest interface{}, totals interface{}) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%w: FindWithTotals dest should be a pointer to slice, got %T", gorm.ErrInvalidData, dest)
+	}
+
+	rows, err := db.Clauses(WithTotals).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		sliceValue = destValue.Elem()
+		elemType   = sliceValue.Type().Elem()
+		isPtr      = elemType.Kind() == reflect.Ptr
+	)
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	sliceValue.SetLen(0)
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := db.ScanRows(rows, elem.Interface()); err != nil {
+			return err
+		}
+
+		if isPtr {
+			sliceValue = reflect.Append(sliceValue, elem)
+		} else {
+			sliceValue = reflect.Append(sliceValue, elem.Elem())
+		}
+	}
+	destValue.Elem().Set(sliceValue)
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if rows.NextResultSet() && rows.Next() {
+		if err := db.ScanRows(rows, totals); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
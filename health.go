@@ -0,0 +1,39 @@
+package clickhouse
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ServerHealth state of the connected server returned by Health
+type ServerHealth struct {
+	Version          string
+	Uptime           time.Duration
+	ReadOnly         bool          // the session can't write, the readonly setting is set or replicated tables are read only
+	ReadOnlyReplicas uint64        // replicated tables in read only mode, e.g. lost Keeper session
+	ReplicationDelay time.Duration // max delay of the replicated tables of the server behind their other replicas
+}
+
+// Health returns the version, uptime, read only mode and replication delay of the connected server in a
+// single query, e.g. for readiness probes
+//
+//	health, err := clickhouse.Health(db.WithContext(ctx))
+//	ready := err == nil && !health.ReadOnly && health.ReplicationDelay < time.Minute
+func Health(db *gorm.DB) (health ServerHealth, err error) {
+	var (
+		uptime, delay uint64
+		readonly      uint64
+	)
+	if err = db.Raw(
+		"SELECT version(), uptime(), toUInt64(getSetting('readonly')), "+
+			"(SELECT countIf(is_readonly) FROM system.replicas), (SELECT max(absolute_delay) FROM system.replicas)",
+	).Row().Scan(&health.Version, &uptime, &readonly, &health.ReadOnlyReplicas, &delay); err != nil {
+		return health, err
+	}
+
+	health.Uptime = time.Duration(uptime) * time.Second
+	health.ReplicationDelay = time.Duration(delay) * time.Second
+	health.ReadOnly = readonly != 0 || health.ReadOnlyReplicas > 0
+	return health, nil
+}
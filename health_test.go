@@ -0,0 +1,27 @@
+package clickhouse_test
+
+import (
+	"testing"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestHealth(t *testing.T) {
+	health, err := clickhouse.Health(DB)
+	if err != nil {
+		t.Fatalf("failed to check health, got error %v", err)
+	}
+
+	if health.Version == "" || health.Uptime <= 0 {
+		t.Errorf("expects server version and uptime, got %+v", health)
+	}
+	if health.ReadOnly {
+		t.Errorf("test server should be writable, got %+v", health)
+	}
+
+	readOnly, err := clickhouse.Health(clickhouse.WithSettings(DB, clickhousego.Settings{"readonly": 2}))
+	if err != nil || !readOnly.ReadOnly {
+		t.Errorf("session with readonly setting should be read only, got %+v, %v", readOnly, err)
+	}
+}
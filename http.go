@@ -0,0 +1,91 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+// dialectorOf returns the clickhouse dialector of db
+func dialectorOf(db *gorm.DB) (*Dialector, error) {
+	if dialector, ok := db.Dialector.(*Dialector); ok {
+		return dialector, nil
+	}
+	return nil, fmt.Errorf("%w: not a clickhouse dialector", gorm.ErrUnsupportedDriver)
+}
+
+// httpURL returns the url of the HTTP interface, HTTPURL or the address of the DSN when using the HTTP protocol
+func (dialector *Dialector) httpURL() (*url.URL, error) {
+	if dialector.HTTPURL != "" {
+		return url.Parse(dialector.HTTPURL)
+	}
+
+	if dialector.options.Protocol == clickhouse.HTTP && len(dialector.options.Addr) > 0 {
+		u := &url.URL{Scheme: "http", Host: dialector.options.Addr[0], Path: dialector.options.HttpUrlPath}
+		if dialector.options.TLS != nil {
+			u.Scheme = "https"
+		}
+		return u, nil
+	}
+	return nil, fmt.Errorf("%w: HTTPURL is required for the HTTP interface", gorm.ErrUnsupportedDriver)
+}
+
+// httpDo sends query to the HTTP interface of the server, body is sent as the data of the query
+// when not nil, the caller is responsible to close the response body
+func (dialector *Dialector) httpDo(ctx context.Context, query string, body io.Reader) (*http.Response, error) {
+	u, err := dialector.httpURL()
+	if err != nil {
+		return nil, err
+	}
+
+	params := u.Query()
+	if dialector.options.Auth.Database != "" && params.Get("database") == "" {
+		params.Set("database", dialector.options.Auth.Database)
+	}
+	for key, value := range SettingsFromContext(ctx) {
+		params.Set(key, fmt.Sprint(value))
+	}
+	if key, ok := QuotaKeyFromContext(ctx); ok {
+		params.Set("quota_key", key)
+	}
+
+	if body == nil {
+		body = strings.NewReader(query)
+	} else {
+		params.Set("query", query)
+	}
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.User == nil && dialector.options.Auth.Username != "" {
+		req.Header.Set("X-ClickHouse-User", dialector.options.Auth.Username)
+		req.Header.Set("X-ClickHouse-Key", dialector.options.Auth.Password)
+	}
+	for key, value := range dialector.options.HttpHeaders {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := dialector.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(resp.Body)
+		code, _ := strconv.Atoi(resp.Header.Get("X-ClickHouse-Exception-Code"))
+		return nil, &clickhouse.Exception{Code: int32(code), Message: strings.TrimSpace(string(message))}
+	}
+	return resp, nil
+}
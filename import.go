@@ -0,0 +1,98 @@
+package clickhouse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Import streams formatted data from r into table over the HTTP interface without decoding it into structs, e.g.
+//
+//	clickhouse.Import(db, "events", clickhouse.FormatParquet, file)
+func Import(db *gorm.DB, table string, format Format, r io.Reader) error {
+	dialector, err := dialectorOf(db)
+	if err != nil {
+		return err
+	}
+
+	resp, err := dialector.httpDo(db.Statement.Context, "INSERT INTO "+db.Statement.Quote(table)+" FORMAT "+string(format), r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// fileFormats formats of file extensions, CSV and TSV files are expected to start with a header
+var fileFormats = map[string]Format{
+	".csv":     FormatCSVWithNames,
+	".tsv":     FormatTSVWithNames,
+	".parquet": FormatParquet,
+	".arrow":   FormatArrow,
+	".orc":     FormatORC,
+	".avro":    FormatAvro,
+	".native":  FormatNative,
+	".json":    FormatJSONEachRow,
+	".jsonl":   FormatJSONEachRow,
+	".ndjson":  FormatJSONEachRow,
+}
+
+// ImportFile streams the local file at path into the table of model like Import, the format is detected
+// from the file extension when empty, progress is called with the bytes sent and the file size while the
+// file is read when not nil, e.g.
+//
+//	clickhouse.ImportFile(db, &Event{}, "events.csv", "", func(sent, size int64) {
+//		log.Printf("imported %d%%", sent*100/size)
+//	})
+func ImportFile(db *gorm.DB, model interface{}, path string, format Format, progress func(sent, size int64)) error {
+	if format == "" {
+		var ok bool
+		if format, ok = fileFormats[strings.ToLower(filepath.Ext(path))]; !ok {
+			return fmt.Errorf("%w: unknown format of file %s", gorm.ErrInvalidData, path)
+		}
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if progress != nil {
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		r = &progressReader{r: file, size: info.Size(), progress: progress}
+	}
+	return Import(db, stmt.Table, format, r)
+}
+
+// progressReader reports the bytes read from r
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	size     int64
+	progress func(sent, size int64)
+}
+
+func (r *progressReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.progress(r.sent, r.size)
+	}
+	return n, err
+}
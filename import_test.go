@@ -0,0 +1,58 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestImport(t *testing.T) {
+	data := `{"id":601,"name":"import1","age":18}` + "\n" + `{"id":602,"name":"import2","age":20}` + "\n"
+	if err := clickhouse.Import(DB, "users", clickhouse.FormatJSONEachRow, strings.NewReader(data)); err != nil {
+		t.Fatalf("failed to import users, got error %v", err)
+	}
+
+	var users []User
+	if err := DB.Where("id IN ?", []uint64{601, 602}).Order("id").Find(&users).Error; err != nil {
+		t.Fatalf("failed to query users, got error %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "import1" || users[1].Age != 20 {
+		t.Errorf("expects imported users, got %+v", users)
+	}
+}
+
+func TestImportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	data := "id,name,age\n611,import_file1,18\n612,import_file2,20\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write file, got error %v", err)
+	}
+
+	var sent, size int64
+	if err := clickhouse.ImportFile(DB, &User{}, path, "", func(s, total int64) { sent, size = s, total }); err != nil {
+		t.Fatalf("failed to import file, got error %v", err)
+	}
+
+	if sent != int64(len(data)) || size != int64(len(data)) {
+		t.Errorf("expects progress of the whole file, got %v of %v", sent, size)
+	}
+
+	var users []User
+	if err := DB.Where("id IN ?", []uint64{611, 612}).Order("id").Find(&users).Error; err != nil {
+		t.Fatalf("failed to query users, got error %v", err)
+	}
+
+	if len(users) != 2 || users[0].Name != "import_file1" || users[1].Age != 20 {
+		t.Errorf("expects imported users, got %+v", users)
+	}
+
+	if err := clickhouse.ImportFile(DB, &User{}, filepath.Join(t.TempDir(), "users.unknown"), "", nil); !errors.Is(err, gorm.ErrInvalidData) {
+		t.Errorf("expects invalid data error for unknown extension, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IntervalUnit unit of an INTERVAL
+type IntervalUnit string
+
+const (
+	IntervalNanosecond  IntervalUnit = "NANOSECOND"
+	IntervalMicrosecond IntervalUnit = "MICROSECOND"
+	IntervalMillisecond IntervalUnit = "MILLISECOND"
+	IntervalSecond      IntervalUnit = "SECOND"
+	IntervalMinute      IntervalUnit = "MINUTE"
+	IntervalHour        IntervalUnit = "HOUR"
+	IntervalDay         IntervalUnit = "DAY"
+	IntervalWeek        IntervalUnit = "WEEK"
+	IntervalMonth       IntervalUnit = "MONTH"
+	IntervalQuarter     IntervalUnit = "QUARTER"
+	IntervalYear        IntervalUnit = "YEAR"
+)
+
+// intervalDurations durations of the units of a fixed length
+var intervalDurations = map[IntervalUnit]time.Duration{
+	IntervalNanosecond:  time.Nanosecond,
+	IntervalMicrosecond: time.Microsecond,
+	IntervalMillisecond: time.Millisecond,
+	IntervalSecond:      time.Second,
+	IntervalMinute:      time.Minute,
+	IntervalHour:        time.Hour,
+	IntervalDay:         24 * time.Hour,
+	IntervalWeek:        7 * 24 * time.Hour,
+}
+
+// IntervalExpr an INTERVAL expression, used as a var of conditions or rendered into TTL rules, scans the
+// Interval values of results, e.g.
+//
+//	db.Where("created_at > now() - ?", clickhouse.Interval(90, clickhouse.IntervalDay)).Find(&events)
+//	clickhouse.TTLDelete(clickhouse.Interval(90, clickhouse.IntervalDay).After("created_at"))
+type IntervalExpr struct {
+	Value int64
+	Unit  IntervalUnit
+}
+
+// Interval returns an INTERVAL of value units, units are case insensitive, e.g. Interval(90, "day")
+func Interval(value int64, unit IntervalUnit) IntervalExpr {
+	return IntervalExpr{Value: value, Unit: IntervalUnit(strings.ToUpper(string(unit)))}
+}
+
+// String returns the INTERVAL expression, e.g. INTERVAL 90 DAY
+func (i IntervalExpr) String() string {
+	return "INTERVAL " + strconv.FormatInt(i.Value, 10) + " " + string(i.Unit)
+}
+
+// After returns the expression of the interval after expr, e.g. created_at + INTERVAL 90 DAY
+func (i IntervalExpr) After(expr string) string {
+	return expr + " + " + i.String()
+}
+
+// Duration returns the duration of the interval, false for units without a fixed length, e.g. months
+func (i IntervalExpr) Duration() (time.Duration, bool) {
+	unit, ok := intervalDurations[i.Unit]
+	return time.Duration(i.Value) * unit, ok
+}
+
+// valid reports whether the unit of the interval is known
+func (i IntervalExpr) valid() bool {
+	_, ok := intervalDurations[i.Unit]
+	return ok || i.Unit == IntervalMonth || i.Unit == IntervalQuarter || i.Unit == IntervalYear
+}
+
+// Build implements clause.Expression interface
+func (i IntervalExpr) Build(builder clause.Builder) {
+	if !i.valid() {
+		builder.AddError(fmt.Errorf("%w: invalid interval unit %s", gorm.ErrInvalidData, i.Unit))
+		return
+	}
+	builder.WriteString(i.String())
+}
+
+// Scan implements sql.Scanner interface, scans Interval values formatted by clickhouse-go, e.g. 90 Days
+func (i *IntervalExpr) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("%w: can not scan %T into IntervalExpr", gorm.ErrInvalidData, src)
+	}
+
+	value, unit, ok := strings.Cut(strings.TrimSpace(s), " ")
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if !ok || err != nil {
+		return fmt.Errorf("%w: invalid interval %q", gorm.ErrInvalidData, s)
+	}
+
+	*i = Interval(parsed, IntervalUnit(strings.TrimSuffix(strings.ToUpper(unit), "S")))
+	if !i.valid() {
+		return fmt.Errorf("%w: invalid interval %q", gorm.ErrInvalidData, s)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package clickhouse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestInterval(t *testing.T) {
+	users := []User{{ID: 801, Name: "interval_old", CreatedAt: time.Now().AddDate(0, 0, -100)}, {ID: 802, Name: "interval_new", CreatedAt: time.Now()}}
+	if err := DB.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users, got error %v", err)
+	}
+
+	var names []string
+	if err := DB.Model(&User{}).Where("id IN ?", []uint64{801, 802}).
+		Where("created_at > now() - ?", clickhouse.Interval(90, clickhouse.IntervalDay)).Pluck("name", &names).Error; err != nil {
+		t.Fatalf("failed to query users, got error %v", err)
+	}
+	if len(names) != 1 || names[0] != "interval_new" {
+		t.Errorf("expects users created within 90 days, got %v", names)
+	}
+
+	var interval clickhouse.IntervalExpr
+	if err := DB.Raw("SELECT ?", clickhouse.Interval(90, "day")).Row().Scan(&interval); err != nil {
+		t.Fatalf("failed to scan interval, got error %v", err)
+	}
+	if duration, ok := interval.Duration(); interval != clickhouse.Interval(90, clickhouse.IntervalDay) || !ok || duration != 90*24*time.Hour {
+		t.Errorf("expects scanned interval of 90 days, got %v", interval)
+	}
+
+	if err := DB.Model(&User{}).Where("created_at > now() - ?", clickhouse.Interval(1, "fortnight")).Pluck("name", &names).Error; err == nil {
+		t.Errorf("should return error for an invalid interval unit")
+	}
+}
@@ -0,0 +1,145 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var joinKeywords = map[string]bool{
+	"GLOBAL": true, "ANY": true, "ALL": true, "ASOF": true, "SEMI": true, "ANTI": true, "PASTE": true,
+	"INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true, "OUTER": true,
+}
+
+// Join a join with ClickHouse strictness and kind, e.g.
+//
+//	db.Clauses(clickhouse.Join{Kind: "ANY LEFT", Table: "users", On: "users.id = events.user_id"}).Find(&events)
+//	db.Clauses(clickhouse.Join{Kind: "LEFT SEMI", Subquery: db.Model(&Order{}).Select("user_id"), Alias: "o", Using: []string{"user_id"}})
+type Join struct {
+	Kind     string // [GLOBAL] [ANY|ALL|ASOF] [INNER|LEFT|RIGHT|FULL|CROSS] [OUTER|SEMI|ANTI]
+	Table    string
+	Subquery interface{} // joins a subquery instead of Table, e.g. *gorm.DB
+	Alias    string
+	On       string
+	Vars     []interface{} // vars of On
+	Using    []string
+}
+
+// Name implements clause.Interface interface
+func (Join) Name() string {
+	return "FROM"
+}
+
+// MergeClause implements clause.Interface interface, the join is appended to the joins of FROM clause
+func (join Join) MergeClause(c *clause.Clause) {
+	from, _ := c.Expression.(clause.From)
+	from.Joins = append(append([]clause.Join{}, from.Joins...), clause.Join{Expression: join})
+	c.Expression = from
+}
+
+// Build implements clause.Expression interface
+func (join Join) Build(builder clause.Builder) {
+	if kind := strings.ToUpper(strings.Join(strings.Fields(join.Kind), " ")); kind != "" {
+		for _, keyword := range strings.Split(kind, " ") {
+			if !joinKeywords[keyword] {
+				builder.AddError(fmt.Errorf("invalid join kind: %s", join.Kind))
+				return
+			}
+		}
+		builder.WriteString(kind)
+		builder.WriteByte(' ')
+	}
+	builder.WriteString("JOIN ")
+
+	if join.Subquery != nil {
+		builder.WriteByte('(')
+		builder.AddVar(builder, join.Subquery)
+		builder.WriteByte(')')
+	} else {
+		builder.WriteQuoted(clause.Table{Name: join.Table})
+	}
+
+	if join.Alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteQuoted(join.Alias)
+	}
+
+	if join.On != "" {
+		builder.WriteString(" ON ")
+		clause.Expr{SQL: join.On, Vars: join.Vars}.Build(builder)
+	} else if len(join.Using) > 0 {
+		builder.WriteString(" USING (")
+		for idx, column := range join.Using {
+			if idx > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteQuoted(column)
+		}
+		builder.WriteByte(')')
+	}
+}
+
+// ASOFJoinClause joins every row to the closest preceding row of model by TimeColumn
+type ASOFJoinClause struct {
+	Kind       string      // ASOF LEFT by default
+	Model      interface{} // model or table name to join
+	On         []string    // equality columns
+	TimeColumn string      // closest match column, the joined row satisfies joined.TimeColumn <= current.TimeColumn
+}
+
+// ASOFJoin aligns rows to the latest row of model at or before timeCol, e.g. events to slowly changing prices
+//
+//	db.Model(&Event{}).Clauses(clickhouse.ASOFJoin(&Price{}, []string{"symbol"}, "ts")).Find(&results)
+//
+// renders ASOF LEFT JOIN `prices` ON `events`.`symbol` = `prices`.`symbol` AND `events`.`ts` >= `prices`.`ts`
+func ASOFJoin(model interface{}, onCols []string, timeCol string) ASOFJoinClause {
+	return ASOFJoinClause{Kind: "ASOF LEFT", Model: model, On: onCols, TimeColumn: timeCol}
+}
+
+// Name implements clause.Interface interface
+func (ASOFJoinClause) Name() string {
+	return "FROM"
+}
+
+// MergeClause implements clause.Interface interface, the join is appended to the joins of FROM clause
+func (join ASOFJoinClause) MergeClause(c *clause.Clause) {
+	from, _ := c.Expression.(clause.From)
+	from.Joins = append(append([]clause.Join{}, from.Joins...), clause.Join{Expression: join})
+	c.Expression = from
+}
+
+// Build implements clause.Expression interface
+func (join ASOFJoinClause) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		builder.AddError(fmt.Errorf("%w: ASOF join requires a statement", gorm.ErrInvalidData))
+		return
+	}
+
+	table, ok := join.Model.(string)
+	if !ok {
+		joinStmt := &gorm.Statement{DB: stmt.DB}
+		if err := joinStmt.Parse(join.Model); err != nil {
+			builder.AddError(err)
+			return
+		}
+		table = joinStmt.Table
+	}
+
+	var (
+		current = stmt.Table
+		exprs   = make([]string, 0, len(join.On)+1)
+	)
+	for _, column := range join.On {
+		exprs = append(exprs, fmt.Sprintf("%s = %s", stmt.Quote(clause.Column{Table: current, Name: column}), stmt.Quote(clause.Column{Table: table, Name: column})))
+	}
+	exprs = append(exprs, fmt.Sprintf("%s >= %s", stmt.Quote(clause.Column{Table: current, Name: join.TimeColumn}), stmt.Quote(clause.Column{Table: table, Name: join.TimeColumn})))
+
+	kind := join.Kind
+	if kind == "" {
+		kind = "ASOF LEFT"
+	}
+	Join{Kind: kind, Table: table, On: strings.Join(exprs, " AND ")}.Build(builder)
+}
@@ -0,0 +1,197 @@
+package clickhouse
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cursor the key column values of the last row of a page of keyset pagination
+type Cursor []interface{}
+
+// cursorValue a typed value of an encoded Cursor, numbers are kept as strings to keep 64 bits integers
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// EncodeCursor encodes cursor as an opaque url safe string, values should be integers, floats, strings, bools
+// or times, pointers are dereferenced
+func EncodeCursor(cursor Cursor) (string, error) {
+	values := make([]cursorValue, len(cursor))
+	for idx, value := range cursor {
+		v := reflect.ValueOf(value)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+
+		switch {
+		case !v.IsValid() || v.Kind() == reflect.Ptr:
+			return "", fmt.Errorf("%w: cursor value %d is nil", gorm.ErrInvalidData, idx)
+		case v.Type() == reflect.TypeOf(time.Time{}):
+			values[idx] = cursorValue{Type: "time", Value: v.Interface().(time.Time).Format(time.RFC3339Nano)}
+		case v.CanInt():
+			values[idx] = cursorValue{Type: "int", Value: strconv.FormatInt(v.Int(), 10)}
+		case v.CanUint():
+			values[idx] = cursorValue{Type: "uint", Value: strconv.FormatUint(v.Uint(), 10)}
+		case v.CanFloat():
+			values[idx] = cursorValue{Type: "float", Value: strconv.FormatFloat(v.Float(), 'g', -1, 64)}
+		case v.Kind() == reflect.String:
+			values[idx] = cursorValue{Type: "string", Value: v.String()}
+		case v.Kind() == reflect.Bool:
+			values[idx] = cursorValue{Type: "bool", Value: strconv.FormatBool(v.Bool())}
+		default:
+			return "", fmt.Errorf("%w: unsupported cursor value %T", gorm.ErrInvalidData, value)
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor encoded by EncodeCursor, values are decoded as int64, uint64, float64,
+// string, bool or time.Time
+func DecodeCursor(encoded string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor: %v", gorm.ErrInvalidData, err)
+	}
+
+	var values []cursorValue
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor: %v", gorm.ErrInvalidData, err)
+	}
+
+	cursor := make(Cursor, len(values))
+	for idx, value := range values {
+		switch value.Type {
+		case "time":
+			cursor[idx], err = time.Parse(time.RFC3339Nano, value.Value)
+		case "int":
+			cursor[idx], err = strconv.ParseInt(value.Value, 10, 64)
+		case "uint":
+			cursor[idx], err = strconv.ParseUint(value.Value, 10, 64)
+		case "float":
+			cursor[idx], err = strconv.ParseFloat(value.Value, 64)
+		case "string":
+			cursor[idx] = value.Value
+		case "bool":
+			cursor[idx], err = strconv.ParseBool(value.Value)
+		default:
+			err = fmt.Errorf("unknown type %q", value.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor: %v", gorm.ErrInvalidData, err)
+		}
+	}
+	return cursor, nil
+}
+
+// keysetColumns parses the key columns of keyset pagination, columns sorted in descending order end with DESC
+func keysetColumns(columns []string) []clause.OrderByColumn {
+	orderBy := make([]clause.OrderByColumn, len(columns))
+	for idx, column := range columns {
+		column = strings.TrimSpace(column)
+		if name, ok := strings.CutSuffix(strings.ToUpper(column), " DESC"); ok {
+			orderBy[idx] = clause.OrderByColumn{Column: clause.Column{Name: strings.TrimSpace(column[:len(name)])}, Desc: true}
+		} else {
+			name, _ = strings.CutSuffix(strings.ToUpper(column), " ASC")
+			orderBy[idx] = clause.OrderByColumn{Column: clause.Column{Name: strings.TrimSpace(column[:len(name)])}}
+		}
+	}
+	return orderBy
+}
+
+// Keyset scope reading the page of limit rows after cursor ordered by columns, which should be unique together,
+// usually the sorting key of the table so the rows before the cursor are skipped with the primary index instead
+// of reading and discarding them like OFFSET, an empty cursor reads the first page, e.g.
+//
+//	db.Scopes(clickhouse.Keyset(cursor, 100, "created_at DESC", "id")).Where("user_id = ?", userID).Find(&events)
+//	next, err := clickhouse.NextCursor(db, &events, "created_at DESC", "id")
+func Keyset(cursor string, limit int, columns ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		orderBy := keysetColumns(columns)
+		if len(orderBy) == 0 {
+			db.AddError(fmt.Errorf("%w: keyset pagination requires key columns", gorm.ErrInvalidData))
+			return db
+		}
+		db = db.Order(clause.OrderBy{Columns: orderBy}).Limit(limit)
+		if cursor == "" {
+			return db
+		}
+
+		values, err := DecodeCursor(cursor)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		if len(values) != len(orderBy) {
+			db.AddError(fmt.Errorf("%w: cursor has %d values for %d key columns", gorm.ErrInvalidData, len(values), len(orderBy)))
+			return db
+		}
+		return db.Where(keysetCondition(orderBy, values))
+	}
+}
+
+// keysetCondition builds the condition of the rows after values, the first key column is bounded on its own
+// so the primary index is used, e.g. a >= 1 AND (a > 1 OR (a = 1 AND b > 2))
+func keysetCondition(orderBy []clause.OrderByColumn, values Cursor) clause.Expression {
+	greater := func(idx int) clause.Expression {
+		if orderBy[idx].Desc {
+			return clause.Lt{Column: orderBy[idx].Column, Value: values[idx]}
+		}
+		return clause.Gt{Column: orderBy[idx].Column, Value: values[idx]}
+	}
+
+	after := greater(len(orderBy) - 1)
+	for idx := len(orderBy) - 2; idx >= 0; idx-- {
+		after = clause.Or(greater(idx), clause.And(clause.Eq{Column: orderBy[idx].Column, Value: values[idx]}, after))
+	}
+
+	if len(orderBy) == 1 {
+		return after
+	}
+	if orderBy[0].Desc {
+		return clause.And(clause.Lte{Column: orderBy[0].Column, Value: values[0]}, after)
+	}
+	return clause.And(clause.Gte{Column: orderBy[0].Column, Value: values[0]}, after)
+}
+
+// NextCursor returns the cursor of the page after the rows of dest read with Keyset, the key column values
+// of its last row, "" when dest is empty
+func NextCursor(db *gorm.DB, dest interface{}, columns ...string) (string, error) {
+	rows := reflect.Indirect(reflect.ValueOf(dest))
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array {
+		return "", fmt.Errorf("%w: NextCursor dest should be a slice, got %T", gorm.ErrInvalidData, dest)
+	}
+	if rows.Len() == 0 {
+		return "", nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(dest); err != nil {
+		return "", err
+	}
+
+	last := rows.Index(rows.Len() - 1)
+	cursor := make(Cursor, 0, len(columns))
+	for _, column := range keysetColumns(columns) {
+		field := stmt.Schema.LookUpField(column.Column.Name)
+		if field == nil {
+			return "", fmt.Errorf("%w: unknown key column %s", gorm.ErrInvalidField, column.Column.Name)
+		}
+		value, _ := field.ValueOf(db.Statement.Context, last)
+		cursor = append(cursor, value)
+	}
+	return EncodeCursor(cursor)
+}
@@ -0,0 +1,69 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+type KeysetEvent struct {
+	ID        uint64
+	CreatedAt time.Time
+}
+
+func TestKeyset(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	mock.Returns("FROM `keyset_events`", []string{"id", "created_at"},
+		[]interface{}{uint64(1), createdAt.Add(time.Hour)}, []interface{}{uint64(7), createdAt})
+
+	var events []KeysetEvent
+	if err := mockDB.Scopes(clickhouse.Keyset("", 2, "created_at DESC", "id")).Find(&events).Error; err != nil {
+		t.Fatalf("failed to find the first page, got error %v", err)
+	}
+
+	cursor, err := clickhouse.NextCursor(mockDB, &events, "created_at DESC", "id")
+	if err != nil {
+		t.Fatalf("failed to get the next cursor, got error %v", err)
+	}
+	if values, err := clickhouse.DecodeCursor(cursor); err != nil || !reflect.DeepEqual(values, clickhouse.Cursor{createdAt, uint64(7)}) {
+		t.Fatalf("expects the key of the last row, got %#v, %v", values, err)
+	}
+
+	if err := mockDB.Scopes(clickhouse.Keyset(cursor, 2, "created_at DESC", "id")).Find(&events).Error; err != nil {
+		t.Fatalf("failed to find the next page, got error %v", err)
+	}
+
+	statements := mock.Statements()
+	expected := []string{
+		"SELECT * FROM `keyset_events` ORDER BY `created_at` DESC,`id` LIMIT ?",
+		"SELECT * FROM `keyset_events` WHERE `created_at` <= ? AND (`created_at` < ? OR (`created_at` = ? AND `id` > ?)) ORDER BY `created_at` DESC,`id` LIMIT ?",
+	}
+	if len(statements) != 2 || statements[0].SQL != expected[0] || statements[1].SQL != expected[1] {
+		t.Fatalf("expects %v, got %+v", expected, statements)
+	}
+	if args := statements[1].Args; !reflect.DeepEqual(args, []interface{}{createdAt, createdAt, createdAt, uint64(7), 2}) {
+		t.Errorf("expects the cursor values as args, got %v", args)
+	}
+
+	if cursor, err := clickhouse.NextCursor(mockDB, &[]KeysetEvent{}, "id"); err != nil || cursor != "" {
+		t.Errorf("expects no cursor after an empty page, got %v, %v", cursor, err)
+	}
+
+	if err := mockDB.Scopes(clickhouse.Keyset(cursor, 2, "id")).Find(&events).Error; !errors.Is(err, gorm.ErrInvalidData) || !strings.Contains(err.Error(), "2 values for 1 key columns") {
+		t.Errorf("expects cursor mismatch error, got %v", err)
+	}
+	if err := mockDB.Scopes(clickhouse.Keyset("!", 2, "id")).Find(&events).Error; !errors.Is(err, gorm.ErrInvalidData) {
+		t.Errorf("expects invalid cursor error, got %v", err)
+	}
+}
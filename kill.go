@@ -0,0 +1,98 @@
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+type queryIDCtxKey struct{}
+
+// WithQueryID runs the statements of db with queryID, so they can be found in system.query_log or cancelled with KillQuery
+func WithQueryID(db *gorm.DB, queryID string) *gorm.DB {
+	ctx := clickhouse.Context(db.Statement.Context, clickhouse.WithQueryID(queryID))
+	return db.WithContext(context.WithValue(ctx, queryIDCtxKey{}, queryID))
+}
+
+// killMode returns the mode of KILL statements
+func killMode(async bool) string {
+	if async {
+		return " ASYNC"
+	}
+	return " SYNC"
+}
+
+// KillQuery cancels the running query with queryID, waits until it's stopped unless async
+func KillQuery(db *gorm.DB, queryID string, async bool) error {
+	return db.Exec("KILL QUERY WHERE query_id = ?"+killMode(async), queryID).Error
+}
+
+// KillMutation cancels the mutation with mutationID of table, a model or table name, waits until it's stopped unless async
+func KillMutation(db *gorm.DB, table interface{}, mutationID string, async bool) error {
+	name, err := tableNameOf(db, table)
+	if err != nil {
+		return err
+	}
+	return db.Exec(
+		"KILL MUTATION WHERE database = currentDatabase() AND table = ? AND mutation_id = ?"+killMode(async),
+		name, mutationID,
+	).Error
+}
+
+const killOnCancelName = "clickhouse:kill_on_cancel"
+
+// killOnCancel assigns a query_id to every execution of the statement unless set with WithQueryID or by the
+// query stats, which run first, and kills the query on the server when the context of the statement is
+// cancelled before it finishes
+func killOnCancel(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil || ctx.Done() == nil {
+		return
+	}
+
+	queryID, ok := ctx.Value(queryIDCtxKey{}).(string)
+	if stats, hasStats := QueryStatsFromContext(ctx); !ok && hasStats {
+		queryID, ok = stats.queryID, true
+	}
+	if !ok {
+		queryID = newQueryID()
+		db.Statement.Context = clickhouse.Context(ctx, clickhouse.WithQueryID(queryID))
+	}
+
+	killer := db.Session(&gorm.Session{NewDB: true, Context: context.Background()})
+	stop := context.AfterFunc(ctx, func() {
+		if err := KillQuery(killer, queryID, true); err != nil {
+			db.Logger.Warn(context.Background(), "failed to kill query %s of cancelled context, got error %v", queryID, err)
+		}
+	})
+	db.InstanceSet(killOnCancelName, stop)
+}
+
+// stopKillOnCancel keeps the finished statement from being killed, Row callbacks finish once the first
+// block of rows is received, so queries of Rows() and Stream cancelled while their rows are read aren't
+// killed, cancelling closes their connection and the server stops them when it notices
+func stopKillOnCancel(db *gorm.DB) {
+	if stop, ok := db.InstanceGet(killOnCancelName); ok {
+		stop.(func() bool)()
+	}
+}
+
+func (dialector *Dialector) registerKillOnCancelCallbacks(db *gorm.DB) {
+	if !dialector.KillQueryOnCancel {
+		return
+	}
+
+	db.Callback().Create().Before("gorm:begin_transaction").Register("clickhouse:kill_on_cancel", killOnCancel)
+	db.Callback().Create().After("gorm:commit_or_rollback_transaction").Register("clickhouse:stop_kill_on_cancel", stopKillOnCancel)
+	db.Callback().Update().Before("gorm:begin_transaction").Register("clickhouse:kill_on_cancel", killOnCancel)
+	db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register("clickhouse:stop_kill_on_cancel", stopKillOnCancel)
+	db.Callback().Delete().Before("gorm:begin_transaction").Register("clickhouse:kill_on_cancel", killOnCancel)
+	db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register("clickhouse:stop_kill_on_cancel", stopKillOnCancel)
+	db.Callback().Query().Before("gorm:query").Register("clickhouse:kill_on_cancel", killOnCancel)
+	db.Callback().Query().After("gorm:query").Register("clickhouse:stop_kill_on_cancel", stopKillOnCancel)
+	db.Callback().Row().Before("gorm:row").Register("clickhouse:kill_on_cancel", killOnCancel)
+	db.Callback().Row().After("gorm:row").Register("clickhouse:stop_kill_on_cancel", stopKillOnCancel)
+	db.Callback().Raw().Before("gorm:raw").Register("clickhouse:kill_on_cancel", killOnCancel)
+	db.Callback().Raw().After("gorm:raw").Register("clickhouse:stop_kill_on_cancel", stopKillOnCancel)
+}
@@ -0,0 +1,88 @@
+package clickhouse_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestKillQuery(t *testing.T) {
+	queryID := "gorm-test-kill-" + time.Now().Format("150405.000000")
+
+	errs := make(chan error, 1)
+	go func() {
+		var count uint64
+		errs <- clickhouse.WithQueryID(DB, queryID).Raw("SELECT count() FROM system.numbers WHERE number % 1000000007 = 1000000006").Scan(&count).Error
+	}()
+
+	for i := 0; i < 100; i++ {
+		var running int64
+		DB.Raw("SELECT count() FROM system.processes WHERE query_id = ?", queryID).Scan(&running)
+		if running > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := clickhouse.KillQuery(DB, queryID, false); err != nil {
+		t.Fatalf("failed to kill query, got error %v", err)
+	}
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Errorf("killed query should return error")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("killed query should stop")
+	}
+
+	if err := clickhouse.KillMutation(DB, &User{}, "not-exists", true); err != nil {
+		t.Errorf("failed to kill mutation, got error %v", err)
+	}
+}
+
+func TestKillQueryOnCancel(t *testing.T) {
+	killDB := newTestDB(t, clickhouse.Config{
+		KillQueryOnCancel: true,
+	})
+
+	queryID := "gorm-test-kill-on-cancel-" + time.Now().Format("150405.000000")
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		var count uint64
+		errs <- clickhouse.WithQueryID(killDB.WithContext(ctx), queryID).Raw("SELECT count() FROM system.numbers WHERE number % 1000000007 = 1000000006").Scan(&count).Error
+	}()
+
+	for i := 0; i < 100; i++ {
+		var running int64
+		DB.Raw("SELECT count() FROM system.processes WHERE query_id = ?", queryID).Scan(&running)
+		if running > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Errorf("cancelled query should return error")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("cancelled query should stop")
+	}
+
+	for i := 0; i < 100; i++ {
+		var running int64
+		DB.Raw("SELECT count() FROM system.processes WHERE query_id = ?", queryID).Scan(&running)
+		if running == 0 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("cancelled query should be killed on the server")
+}
@@ -0,0 +1,68 @@
+package clickhouse
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+	"gorm.io/gorm"
+)
+
+const (
+	metricsStartedAtName     = "gorm:clickhouse:metrics_started_at"
+	metricsBlocksReportedKey = "gorm:clickhouse:metrics_blocks_reported"
+)
+
+// Metrics receives driver metrics, implement it with prometheus or any other metrics library
+type Metrics interface {
+	// ObservePoolStats reports the connection pool stats after each statement
+	ObservePoolStats(stats sql.DBStats)
+	// ObserveInsertBlock reports the rows and flush duration of an insert block
+	ObserveInsertBlock(table string, rows int64, duration time.Duration)
+	// ObserveError reports a failed statement, code is the ClickHouse exception code or 0 for client errors
+	ObserveError(operation string, code int32)
+}
+
+func (dialector *Dialector) registerMetricsCallbacks(db *gorm.DB) {
+	if dialector.Metrics == nil {
+		return
+	}
+
+	db.Callback().Create().Before("gorm:begin_transaction").Register("clickhouse:metrics_start", startMetrics)
+	db.Callback().Create().After("gorm:commit_or_rollback_transaction").Register("clickhouse:metrics_end", dialector.endMetrics("create"))
+	db.Callback().Update().Before("gorm:begin_transaction").Register("clickhouse:metrics_start", startMetrics)
+	db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register("clickhouse:metrics_end", dialector.endMetrics("update"))
+	db.Callback().Delete().Before("gorm:begin_transaction").Register("clickhouse:metrics_start", startMetrics)
+	db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register("clickhouse:metrics_end", dialector.endMetrics("delete"))
+	db.Callback().Query().After("gorm:after_query").Register("clickhouse:metrics_end", dialector.endMetrics("query"))
+	db.Callback().Row().After("gorm:row").Register("clickhouse:metrics_end", dialector.endMetrics("row"))
+	db.Callback().Raw().After("gorm:raw").Register("clickhouse:metrics_end", dialector.endMetrics("raw"))
+}
+
+func startMetrics(db *gorm.DB) {
+	db.Statement.Settings.Store(metricsStartedAtName, time.Now())
+}
+
+func (dialector *Dialector) endMetrics(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil {
+			dialector.Metrics.ObserveError(operation, clickhouseerr.Code(db.Error))
+		} else if _, reported := db.Statement.Settings.Load(metricsBlocksReportedKey); operation == "create" && !reported && !db.DryRun {
+			if startedAt, ok := db.Statement.Settings.Load(metricsStartedAtName); ok {
+				dialector.Metrics.ObserveInsertBlock(db.Statement.Table, db.RowsAffected, time.Since(startedAt.(time.Time)))
+			}
+		}
+
+		if sqlDB, err := db.DB(); err == nil {
+			dialector.Metrics.ObservePoolStats(sqlDB.Stats())
+		}
+	}
+}
+
+// observeInsertBlock reports an insert block sent by the Create callback directly
+func (dialector *Dialector) observeInsertBlock(db *gorm.DB, rows int64, duration time.Duration) {
+	if dialector.Metrics != nil {
+		db.Statement.Settings.Store(metricsBlocksReportedKey, true)
+		dialector.Metrics.ObserveInsertBlock(db.Statement.Table, rows, duration)
+	}
+}
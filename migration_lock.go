@@ -0,0 +1,152 @@
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMigrationLockTimeout returned by AutoMigrate when the migration lock can't be acquired in time
+var ErrMigrationLockTimeout = errors.New("timed out waiting for the migration lock")
+
+const migrationLockName = "auto_migrate"
+
+// MigrationLock table based lock acquired by AutoMigrate, so replicas starting at the same time
+// run DDL one after another, waiting replicas are granted the lock in the order they asked for it
+//
+// Use a replicated TableOptions, e.g. ON CLUSTER '{cluster}' ENGINE=ReplicatedMergeTree ORDER BY (name, acquired_at),
+// when replicas connect to different servers of a cluster
+type MigrationLock struct {
+	Table        string        // lock table, defaults to gorm_migration_lock
+	TableOptions string        // options of the lock table, defaults to ENGINE=MergeTree ORDER BY (name, acquired_at)
+	Timeout      time.Duration // how long to wait for the lock, defaults to 1 minute
+	Expiry       time.Duration // locks held longer are considered stale, defaults to 10 minutes
+	PollInterval time.Duration // how often to check the lock while waiting, defaults to 1 second
+}
+
+func (lock MigrationLock) withDefaults() MigrationLock {
+	if lock.Table == "" {
+		lock.Table = "gorm_migration_lock"
+	}
+	if lock.TableOptions == "" {
+		lock.TableOptions = "ENGINE=MergeTree ORDER BY (name, acquired_at)"
+	}
+	if lock.Timeout <= 0 {
+		lock.Timeout = time.Minute
+	}
+	if lock.Expiry <= 0 {
+		lock.Expiry = 10 * time.Minute
+	}
+	if lock.PollInterval <= 0 {
+		lock.PollInterval = time.Second
+	}
+	return lock
+}
+
+// AutoMigrate runs auto migration for models, holding the migration lock if Config.MigrationLock is set,
+// tables are recreated with CREATE OR REPLACE TABLE if clickhouse:create_or_replace is set, e.g.
+//
+//	db.Set("clickhouse:create_or_replace", true).AutoMigrate(&Country{})
+//
+// WARNING: replacing drops ALL DATA of EVERY table passed to that AutoMigrate call, pass only the tables to
+// reload and migrate the others in a separate call without the setting,
+//
+// columns without a field in the model are dropped if clickhouse:prune_columns is set, see PruneColumns,
+// columns of fields tagged `gorm:"previousName:old_name"` are renamed from old_name instead of added,
+// drifted settings of models implementing SettingsInterface are modified, Enum columns are checked with
+// ValidateEnums first if Config.ValidateEnums is set
+func (m Migrator) AutoMigrate(values ...interface{}) error {
+	migrate := func(values ...interface{}) error {
+		if m.Dialector.ValidateEnums {
+			if err := m.ValidateEnums(values...); err != nil {
+				return err
+			}
+		}
+		if m.createOrReplace() {
+			return m.CreateTable(values...)
+		}
+		if err := m.renamePreviousColumns(values...); err != nil {
+			return err
+		}
+		if err := m.Migrator.AutoMigrate(values...); err != nil {
+			return err
+		}
+		if err := m.reconcileTableSettings(values...); err != nil {
+			return err
+		}
+		if m.pruneColumns() {
+			_, err := m.PruneColumns(false, values...)
+			return err
+		}
+		return nil
+	}
+	if m.Dialector.MigrationLock == nil {
+		return migrate(values...)
+	}
+
+	release, err := m.acquireMigrationLock(m.Dialector.MigrationLock.withDefaults(), migrationLockName)
+	if err != nil {
+		return err
+	}
+
+	err = migrate(values...)
+	if releaseErr := release(); err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+// acquireMigrationLock enqueues an owner row of the named lock into the lock table and waits until it's the oldest live one
+func (m Migrator) acquireMigrationLock(lock MigrationLock, name string) (release func() error, err error) {
+	db := m.DB.Session(&gorm.Session{NewDB: true})
+	table := clause.Table{Name: lock.Table}
+
+	clusterOpts, tableOpts := isolateClusterOption(lock.TableOptions)
+	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS ?%s (name String, owner String, acquired_at DateTime64(6)) %s", clusterOpts, tableOpts)
+	if err = db.Exec(createSQL, table).Error; err != nil {
+		return nil, err
+	}
+
+	owner := newQueryID()
+	if err = db.Exec("INSERT INTO ? (name, owner, acquired_at) SELECT ?, ?, now64(6)", table, name, owner).Error; err != nil {
+		return nil, err
+	}
+
+	release = func() error {
+		return WithSettings(db, clickhouse.Settings{"mutations_sync": 2}).Exec(
+			"ALTER TABLE ? DELETE WHERE name = ? AND owner = ?", table, name, owner,
+		).Error
+	}
+
+	deadline := time.Now().Add(lock.Timeout)
+	for {
+		var holder string
+		if err = db.Raw(
+			"SELECT owner FROM ? WHERE name = ? AND acquired_at > now64(6) - toIntervalMillisecond(?) ORDER BY acquired_at, owner LIMIT 1",
+			table, name, lock.Expiry.Milliseconds(),
+		).Row().Scan(&holder); err != nil {
+			release()
+			return nil, err
+		}
+
+		if holder == owner {
+			return release, nil
+		}
+
+		if time.Now().After(deadline) {
+			release()
+			return nil, fmt.Errorf("%w: held by %s", ErrMigrationLockTimeout, holder)
+		}
+
+		select {
+		case <-db.Statement.Context.Done():
+			release()
+			return nil, db.Statement.Context.Err()
+		case <-time.After(lock.PollInterval):
+		}
+	}
+}
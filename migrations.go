@@ -0,0 +1,216 @@
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrIrreversibleMigration = errors.New("migration has no down step")
+	ErrDuplicateMigration    = errors.New("duplicate migration version")
+)
+
+// Migration a versioned schema change, steps are SQL scripts run with ExecScript or Go funcs, the func
+// takes precedence when both are set
+type Migration struct {
+	Version uint64
+	Name    string
+	UpSQL   string
+	Up      func(tx *gorm.DB) error
+	DownSQL string
+	Down    func(tx *gorm.DB) error
+}
+
+func (migration Migration) hasDown() bool {
+	return migration.Down != nil || migration.DownSQL != ""
+}
+
+// Migrations versioned migrations recorded in a schema_migrations table, e.g.
+//
+//	migrations := clickhouse.Migrations{Cluster: "{cluster}", Versions: []clickhouse.Migration{
+//		{Version: 1, Name: "create events", UpSQL: createEventsSQL, DownSQL: "DROP TABLE events"},
+//		{Version: 2, Name: "backfill events", Up: backfillEvents},
+//	}}
+//	err := migrations.Migrate(db)
+type Migrations struct {
+	Table        string         // table recording applied versions, defaults to schema_migrations
+	TableOptions string         // options of the table, defaults to ENGINE=MergeTree ORDER BY (version, applied_at), ReplicatedMergeTree with Cluster
+	Cluster      string         // create the tables and run DDL of SQL steps ON CLUSTER cluster, see ExecScriptOnCluster
+	Lock         *MigrationLock // lock held while migrating, defaults to Config.MigrationLock, replicated on Cluster if it has no TableOptions
+	Versions     []Migration
+}
+
+func (ms Migrations) withDefaults(db *gorm.DB) Migrations {
+	if ms.Table == "" {
+		ms.Table = "schema_migrations"
+	}
+	engine := "MergeTree"
+	if ms.Cluster != "" {
+		engine = "ReplicatedMergeTree"
+	}
+	if ms.TableOptions == "" {
+		ms.TableOptions = "ENGINE=" + engine + " ORDER BY (version, applied_at)"
+	}
+
+	lock := MigrationLock{}
+	if ms.Lock != nil {
+		lock = *ms.Lock
+	} else if dialector, err := dialectorOf(db); err == nil && dialector.MigrationLock != nil {
+		lock = *dialector.MigrationLock
+	}
+	if ms.Cluster != "" && lock.TableOptions == "" {
+		lock.TableOptions = "ON CLUSTER " + quoteString(ms.Cluster) + " ENGINE=" + engine + " ORDER BY (name, acquired_at)"
+	}
+	lock = lock.withDefaults()
+	ms.Lock = &lock
+
+	versions := make([]Migration, len(ms.Versions))
+	copy(versions, ms.Versions)
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	ms.Versions = versions
+	return ms
+}
+
+// Migrate applies all pending migrations
+func (ms Migrations) Migrate(db *gorm.DB) error {
+	if len(ms.Versions) == 0 {
+		return nil
+	}
+	return ms.MigrateTo(db, ms.latestVersion())
+}
+
+// MigrateTo applies the pending migrations up to version in ascending order and rolls back the applied
+// migrations above version in descending order, version 0 rolls back all migrations
+func (ms Migrations) MigrateTo(db *gorm.DB, version uint64) error {
+	ms = ms.withDefaults(db)
+	for idx := 1; idx < len(ms.Versions); idx++ {
+		if ms.Versions[idx].Version == ms.Versions[idx-1].Version {
+			return fmt.Errorf("%w: %d", ErrDuplicateMigration, ms.Versions[idx].Version)
+		}
+	}
+
+	migrator, ok := db.Migrator().(Migrator)
+	if !ok {
+		return fmt.Errorf("%w: not a clickhouse dialector", gorm.ErrUnsupportedDriver)
+	}
+
+	if err := ms.createTable(db); err != nil {
+		return err
+	}
+
+	release, err := migrator.acquireMigrationLock(*ms.Lock, "migrations:"+ms.Table)
+	if err != nil {
+		return err
+	}
+
+	err = ms.migrateTo(db, version)
+	if releaseErr := release(); err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+// Applied returns the applied versions in ascending order
+func (ms Migrations) Applied(db *gorm.DB) (versions []uint64, err error) {
+	ms = ms.withDefaults(db)
+	if !db.Migrator().HasTable(ms.Table) {
+		return nil, nil
+	}
+	err = db.Raw(
+		"SELECT version FROM ? GROUP BY version HAVING argMax(applied, applied_at) = 1 ORDER BY version",
+		clause.Table{Name: ms.Table},
+	).Scan(&versions).Error
+	return versions, err
+}
+
+// Version returns the highest applied version, 0 if none was applied
+func (ms Migrations) Version(db *gorm.DB) (uint64, error) {
+	versions, err := ms.Applied(db)
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (ms Migrations) latestVersion() (version uint64) {
+	for _, migration := range ms.Versions {
+		version = max(version, migration.Version)
+	}
+	return version
+}
+
+func (ms Migrations) createTable(db *gorm.DB) error {
+	clusterOpts, tableOpts := isolateClusterOption(ms.TableOptions)
+	if ms.Cluster != "" && clusterOpts == "" {
+		clusterOpts = " ON CLUSTER " + quoteString(ms.Cluster)
+	}
+
+	return db.Exec(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS ?%s (version UInt64, name String, applied UInt8, applied_at DateTime64(6)) %s", clusterOpts, tableOpts),
+		clause.Table{Name: ms.Table},
+	).Error
+}
+
+func (ms Migrations) migrateTo(db *gorm.DB, version uint64) error {
+	versions, err := ms.Applied(db)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[uint64]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+
+	for idx := len(ms.Versions) - 1; idx >= 0; idx-- {
+		if migration := ms.Versions[idx]; migration.Version > version && applied[migration.Version] {
+			if !migration.hasDown() {
+				return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, ErrIrreversibleMigration)
+			}
+			if err := ms.run(db, migration, migration.Down, migration.DownSQL, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, migration := range ms.Versions {
+		if migration.Version <= version && !applied[migration.Version] {
+			if err := ms.run(db, migration, migration.Up, migration.UpSQL, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run runs a step of migration and records whether it's applied afterwards
+func (ms Migrations) run(db *gorm.DB, migration Migration, fn func(tx *gorm.DB) error, script string, up bool) error {
+	tx := db.Session(&gorm.Session{NewDB: true})
+
+	var err error
+	if fn != nil {
+		err = fn(tx)
+	} else if script != "" {
+		err = execScript(tx, ms.Cluster, script)
+	}
+
+	if err == nil {
+		var applied uint8
+		if up {
+			applied = 1
+		}
+		err = tx.Exec(
+			"INSERT INTO ? (version, name, applied, applied_at) SELECT ?, ?, ?, now64(6)",
+			clause.Table{Name: ms.Table}, migration.Version, migration.Name, applied,
+		).Error
+	}
+
+	if err != nil {
+		return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestMigrations(t *testing.T) {
+	DB.Migrator().DropTable("test_schema_migrations", "migration_events")
+
+	migrations := clickhouse.Migrations{Table: "test_schema_migrations", Versions: []clickhouse.Migration{
+		{
+			Version: 2,
+			Name:    "add name",
+			Up: func(tx *gorm.DB) error {
+				return tx.Exec("ALTER TABLE migration_events ADD COLUMN name String").Error
+			},
+			DownSQL: "ALTER TABLE migration_events DROP COLUMN name",
+		},
+		{
+			Version: 1,
+			Name:    "create events",
+			UpSQL:   "CREATE TABLE migration_events (id UInt64) ENGINE = MergeTree ORDER BY id; INSERT INTO migration_events VALUES (1)",
+			DownSQL: "DROP TABLE migration_events",
+		},
+	}}
+
+	if err := migrations.MigrateTo(DB, 1); err != nil {
+		t.Fatalf("failed to migrate to 1, got error %v", err)
+	}
+	if version, err := migrations.Version(DB); err != nil || version != 1 {
+		t.Errorf("expects version 1, got %v, error %v", version, err)
+	}
+	if DB.Migrator().HasColumn("migration_events", "name") {
+		t.Errorf("migration 2 should not be applied")
+	}
+
+	if err := migrations.Migrate(DB); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+	if versions, err := migrations.Applied(DB); err != nil || len(versions) != 2 {
+		t.Errorf("expects versions 1 and 2 applied, got %v, error %v", versions, err)
+	}
+	if !DB.Migrator().HasColumn("migration_events", "name") {
+		t.Errorf("migration 2 should be applied")
+	}
+
+	if err := migrations.MigrateTo(DB, 0); err != nil {
+		t.Fatalf("failed to roll back, got error %v", err)
+	}
+	if version, err := migrations.Version(DB); err != nil || version != 0 {
+		t.Errorf("expects version 0, got %v, error %v", version, err)
+	}
+	if DB.Migrator().HasTable("migration_events") {
+		t.Errorf("migration 1 should be rolled back")
+	}
+
+	migrations.Versions = append(migrations.Versions, clickhouse.Migration{Version: 1, Name: "duplicate"})
+	if err := migrations.Migrate(DB); !errors.Is(err, clickhouse.ErrDuplicateMigration) {
+		t.Errorf("expects ErrDuplicateMigration, got %v", err)
+	}
+}
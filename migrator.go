@@ -5,10 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/ClickHouse/clickhouse-go/v2"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/migrator"
@@ -20,6 +23,7 @@ var (
 	ErrRenameColumnUnsupported = errors.New("renaming column is not supported in your clickhouse version < 20.4")
 	ErrRenameIndexUnsupported  = errors.New("renaming index is not supported")
 	ErrCreateIndexFailed       = errors.New("failed to create index with name")
+	ErrRenameNotAtomic         = errors.New("renaming table is not atomic")
 )
 
 type Migrator struct {
@@ -67,9 +71,19 @@ func (m Migrator) extractClusterOption() string {
 	if clusterOption, ok := m.DB.Get("gorm:table_cluster_options"); ok {
 		return formatClusterClause(fmt.Sprint(clusterOption))
 	}
+	if m.Dialector.DefaultCluster != "" {
+		return " ON CLUSTER " + quoteString(m.Dialector.DefaultCluster)
+	}
 	return ""
 }
 
+// createOrReplace reports whether tables are replaced by CREATE OR REPLACE TABLE instead of altered,
+// replacing a table drops its data, use it for small dimension tables reloaded after migrating
+func (m Migrator) createOrReplace() bool {
+	replace, ok := m.DB.Get("clickhouse:create_or_replace")
+	return ok && replace == true
+}
+
 // Database
 
 func (m Migrator) CurrentDatabase() (name string) {
@@ -85,8 +99,10 @@ func (m Migrator) FullDataTypeOf(field *schema.Field) (expr clause.Expr) {
 	// NULL and UNIQUE keyword is not supported in clickhouse.
 	// Hence, skipping checks for field.Unique and field.NotNull
 
-	// Build DEFAULT clause after DataTypeOf() expression optionally
-	if field.HasDefaultValue && (field.DefaultValueInterface != nil || field.DefaultValue != "") {
+	// Build MATERIALIZED clause instead of DEFAULT optionally, e.g. `gorm:"materialized:toYear(created_at)"`
+	if materialized, ok := field.TagSettings["MATERIALIZED"]; ok && materialized != "" {
+		expr.SQL += " MATERIALIZED " + materialized
+	} else if field.HasDefaultValue && (field.DefaultValueInterface != nil || field.DefaultValue != "") {
 		if field.DefaultValueInterface != nil {
 			defaultStmt := &gorm.Statement{Vars: []interface{}{field.DefaultValueInterface}}
 			m.Dialector.BindVarTo(defaultStmt, defaultStmt, field.DefaultValueInterface)
@@ -119,6 +135,11 @@ func (m Migrator) FullDataTypeOf(field *schema.Field) (expr clause.Expr) {
 		expr.SQL += codecSQL
 	}
 
+	// Build STATISTICS clause optionally, e.g. `gorm:"statistics:tdigest,uniq"`
+	if stats, ok := field.TagSettings["STATISTICS"]; ok && stats != "" {
+		expr.SQL += " STATISTICS(" + stats + ")"
+	}
+
 	return expr
 }
 
@@ -133,10 +154,28 @@ func (m Migrator) CreateTable(models ...interface{}) error {
 				args           = []interface{}{clause.Table{Name: stmt.Table}}
 			)
 
+			if m.createOrReplace() {
+				createTableSQL = "CREATE OR REPLACE TABLE ?%s (%s %s %s) %s"
+			}
+
+			settings := clickhouse.Settings{}
+
 			// Step 1. Build column datatype SQL string
 			columnSlice := make([]string, 0, len(stmt.Schema.DBNames))
 			for _, dbName := range stmt.Schema.DBNames {
 				field := stmt.Schema.FieldsByDBName[dbName]
+				maps.Copy(settings, m.ddlSettings(field))
+				if isFlattened(field) {
+					columns, err := m.Dialector.flattenedColumns(field)
+					if err != nil {
+						return err
+					}
+					for _, column := range columns {
+						columnSlice = append(columnSlice, "? ?")
+						args = append(args, clause.Expr{SQL: "`" + column.Name + "`"}, clause.Expr{SQL: column.DataType})
+					}
+					continue
+				}
 				columnSlice = append(columnSlice, "? ?")
 				args = append(args,
 					clause.Column{Name: dbName},
@@ -189,6 +228,7 @@ func (m Migrator) CreateTable(models ...interface{}) error {
 
 				// Stringify index builder
 				// TODO (iqdf): support granularity
+				maps.Copy(settings, m.indexSettings(indexType))
 				str := fmt.Sprintf("INDEX ? ? TYPE %s GRANULARITY %d", indexType, m.getIndexGranularityOption(index.Fields))
 				indexSlice = append(indexSlice, str)
 				args = append(args, clause.Expr{SQL: index.Name}, indexOptions)
@@ -199,15 +239,7 @@ func (m Migrator) CreateTable(models ...interface{}) error {
 			}
 
 			// Step 4. Finally assemble CREATE TABLE ... SQL string
-			engineOpts := m.Dialector.DefaultTableEngineOpts
-			tableOption, hasTableOption := m.DB.Get("gorm:table_options")
-			clusterOpts := ""
-			if hasTableOption {
-				tableOpts := fmt.Sprint(tableOption)
-				var cleanedOpts string
-				clusterOpts, cleanedOpts = isolateClusterOption(tableOpts)
-				engineOpts = cleanedOpts
-			}
+			clusterOpts, engineOpts := m.tableEngineOpts(stmt)
 
 			// Also support legacy gorm:table_cluster_options (for backward compatibility)
 			if clusterOption, ok := m.DB.Get("gorm:table_cluster_options"); ok {
@@ -215,10 +247,13 @@ func (m Migrator) CreateTable(models ...interface{}) error {
 					clusterOpts = " " + fmt.Sprint(clusterOption) + " "
 				}
 			}
+			if clusterOpts == "" {
+				clusterOpts = m.extractClusterOption()
+			}
 
 			createTableSQL = fmt.Sprintf(createTableSQL, clusterOpts, columnStr, constrStr, indexStr, engineOpts)
 
-			err = tx.Exec(createTableSQL, args...).Error
+			err = WithSettings(tx, settings).Exec(createTableSQL, args...).Error
 
 			return
 		}); err != nil {
@@ -228,6 +263,78 @@ func (m Migrator) CreateTable(models ...interface{}) error {
 	return nil
 }
 
+// DropTable drops the tables of values in reverse dependency order
+func (m Migrator) DropTable(values ...interface{}) error {
+	values = m.ReorderModels(values, false)
+	for i := len(values) - 1; i >= 0; i-- {
+		if err := m.RunWithValue(values[i], func(stmt *gorm.Statement) error {
+			return m.DB.Exec(fmt.Sprintf("DROP TABLE IF EXISTS ?%s", m.extractClusterOption()), m.CurrentTable(stmt)).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameTable renames the table oldName to newName, table names may be prefixed by their database
+func (m Migrator) RenameTable(oldName, newName interface{}) error {
+	oldTable, err := tableNameOf(m.DB, oldName)
+	if err != nil {
+		return err
+	}
+	newTable, err := tableNameOf(m.DB, newName)
+	if err != nil {
+		return err
+	}
+
+	return m.DB.Exec(
+		fmt.Sprintf("RENAME TABLE ? TO ?%s", m.extractClusterOption()),
+		clause.Table{Name: oldTable}, clause.Table{Name: newTable},
+	).Error
+}
+
+// RenameTableAtomic renames the table like RenameTable after verifying the databases of both tables use the
+// Atomic or Replicated engine, returns ErrRenameNotAtomic otherwise as other databases don't rename atomically
+func (m Migrator) RenameTableAtomic(oldName, newName interface{}) error {
+	oldTable, err := tableNameOf(m.DB, oldName)
+	if err != nil {
+		return err
+	}
+	newTable, err := tableNameOf(m.DB, newName)
+	if err != nil {
+		return err
+	}
+
+	databases := make([]string, 0, 2)
+	for _, table := range []string{oldTable, newTable} {
+		if database, _, ok := strings.Cut(table, "."); ok {
+			databases = append(databases, database)
+		} else {
+			databases = append(databases, m.CurrentDatabase())
+		}
+	}
+
+	var rows []struct {
+		Name   string
+		Engine string
+	}
+	if err := m.DB.Raw("SELECT name, engine FROM system.databases WHERE name IN ?", databases).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	engines := make(map[string]string, len(rows))
+	for _, row := range rows {
+		engines[row.Name] = row.Engine
+	}
+
+	for _, database := range databases {
+		if engine := engines[database]; engine != "Atomic" && engine != "Replicated" {
+			return fmt.Errorf("%w: database %s uses engine %q", ErrRenameNotAtomic, database, engine)
+		}
+	}
+	return m.RenameTable(oldTable, newTable)
+}
+
 func (m Migrator) HasTable(value interface{}) bool {
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
@@ -264,23 +371,112 @@ func (m Migrator) GetTables() (tableList []string, err error) {
 	return
 }
 
+// TableType returns the engine and comment of the table, views are reported as VIEW
+func (m Migrator) TableType(value interface{}) (gorm.TableType, error) {
+	var tableType migrator.TableType
+	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		var engine string
+		currentDatabase := m.CurrentDatabase()
+		if err := m.DB.Raw(
+			"SELECT name, engine, comment FROM system.tables WHERE database = ? AND name = ?",
+			currentDatabase, stmt.Table,
+		).Row().Scan(&tableType.NameValue, &engine, &tableType.CommentValue); err != nil {
+			return err
+		}
+
+		tableType.SchemaValue = currentDatabase
+		tableType.TypeValue = "BASE TABLE"
+		if strings.HasSuffix(engine, "View") {
+			tableType.TypeValue = "VIEW"
+		}
+		return nil
+	})
+	return tableType, err
+}
+
+// DetachTable detaches the table of value, the server forgets the table until AttachTable, a permanently
+// detached table stays detached after server restarts
+func (m Migrator) DetachTable(value interface{}, permanently bool) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		sql := fmt.Sprintf("DETACH TABLE ?%s", m.extractClusterOption())
+		if permanently {
+			sql += " PERMANENTLY"
+		}
+		return m.DB.Exec(sql, clause.Table{Name: stmt.Table}).Error
+	})
+}
+
+// AttachTable attaches the table of value detached by DetachTable
+func (m Migrator) AttachTable(value interface{}) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec(fmt.Sprintf("ATTACH TABLE ?%s", m.extractClusterOption()), clause.Table{Name: stmt.Table}).Error
+	})
+}
+
 // Columns
 
 func (m Migrator) AddColumn(value interface{}, field string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		if field := stmt.Schema.LookUpField(field); field != nil {
 			clusterOpts := m.extractClusterOption()
-			sQL := fmt.Sprintf("ALTER TABLE ?%s ADD COLUMN ? ?", clusterOpts)
-			return m.DB.Exec(
+			position, vars := columnPosition(stmt, field)
+			if isFlattened(field) {
+				return m.addFlattenedColumns(stmt, field, clusterOpts, position, vars)
+			}
+			sQL := fmt.Sprintf("ALTER TABLE ?%s ADD COLUMN ? ?%s", clusterOpts, position)
+			tx := WithSettings(m.DB, m.ddlSettings(field))
+			if err := tx.Exec(
 				sQL,
-				clause.Table{Name: stmt.Table}, clause.Column{Name: field.DBName},
-				m.FullDataTypeOf(field),
-			).Error
+				append([]interface{}{
+					clause.Table{Name: stmt.Table}, clause.Column{Name: field.DBName},
+					m.FullDataTypeOf(field),
+				}, vars...)...,
+			).Error; err != nil {
+				return err
+			}
+			if _, ok := field.TagSettings["MATERIALIZED"]; ok && m.materializeColumns() {
+				return m.MaterializeColumn(value, field.DBName, true)
+			}
+			return nil
 		}
 		return fmt.Errorf("failed to look up field with name: %s", field)
 	})
 }
 
+// columnPosition returns the position of a new column, `gorm:"after:column"` or `gorm:"first"`,
+// defaults to after the column of the previous field of the model to preserve the field order
+func columnPosition(stmt *gorm.Statement, field *schema.Field) (string, []interface{}) {
+	if after, ok := field.TagSettings["AFTER"]; ok {
+		if f := stmt.Schema.LookUpField(after); f != nil {
+			after = f.DBName
+		}
+		return " AFTER ?", []interface{}{clause.Column{Name: after}}
+	}
+	if _, ok := field.TagSettings["FIRST"]; ok {
+		return " FIRST", nil
+	}
+
+	var previous *schema.Field
+	for _, f := range stmt.Schema.Fields {
+		if f == field {
+			break
+		}
+		if f.DBName != "" && !f.IgnoreMigration {
+			previous = f
+		}
+	}
+
+	if previous == nil {
+		return " FIRST", nil
+	}
+	if isFlattened(previous) {
+		if fields, err := nestedFields(previous); err == nil && len(fields) > 0 {
+			return " AFTER ?", []interface{}{clause.Expr{SQL: "`" + previous.DBName + "." + fields[len(fields)-1].DBName + "`"}}
+		}
+	}
+	return " AFTER ?", []interface{}{clause.Column{Name: previous.DBName}}
+}
+
 func (m Migrator) DropColumn(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		if field := stmt.Schema.LookUpField(name); field != nil {
@@ -299,8 +495,12 @@ func (m Migrator) AlterColumn(value interface{}, field string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		if field := stmt.Schema.LookUpField(field); field != nil {
 			clusterOpts := m.extractClusterOption()
+			if isFlattened(field) {
+				return m.alterFlattenedColumns(stmt, field, clusterOpts)
+			}
 			sQL := fmt.Sprintf("ALTER TABLE ?%s MODIFY COLUMN ? ?", clusterOpts)
-			return m.DB.Exec(
+			tx := WithSettings(m.DB, m.ddlSettings(field))
+			return tx.Exec(
 				sQL,
 				clause.Table{Name: stmt.Table},
 				clause.Column{Name: field.DBName},
@@ -311,6 +511,68 @@ func (m Migrator) AlterColumn(value interface{}, field string) error {
 	})
 }
 
+// MigrateColumn migrates the column of field, then removes the default, comment, codec and TTL of the column
+// when the field doesn't define them anymore, as MODIFY COLUMN keeps the properties it doesn't mention, the
+// properties are read once per table by ColumnTypes
+func (m Migrator) MigrateColumn(value interface{}, field *schema.Field, columnType gorm.ColumnType) error {
+	if isFlattened(field) {
+		if columnType.DatabaseTypeName() != m.Dialector.nestedType(field) {
+			return m.AlterColumn(value, field.DBName)
+		}
+		return nil
+	}
+
+	if err := m.Migrator.MigrateColumn(value, field, migrationColumnType{columnType}); err != nil {
+		return err
+	}
+
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		column, ok := columnType.(tableColumnType)
+		if !ok {
+			columnTypes, err := m.ColumnTypes(value)
+			if err != nil {
+				return err
+			}
+			for _, c := range columnTypes {
+				if c, ok := c.(tableColumnType); ok && c.Name() == columnType.Name() {
+					column = c
+				}
+			}
+		}
+
+		var removes []string
+		if column.DefaultKind == "DEFAULT" && !(field.HasDefaultValue && (field.DefaultValueInterface != nil || field.DefaultValue != "")) {
+			removes = append(removes, "DEFAULT")
+		}
+		if _, ok := field.TagSettings["COMMENT"]; !ok && column.CommentValue.String != "" {
+			removes = append(removes, "COMMENT")
+		}
+		if codec := field.TagSettings["CODEC"]; codec == "" && column.CompressionCodec != "" {
+			removes = append(removes, "CODEC")
+		}
+		if ttl := field.TagSettings["TTL"]; ttl == "" && column.definitions != nil {
+			definition, err := column.definitions.definition(column.Name())
+			if err != nil {
+				return err
+			}
+			if strings.Contains(definition, " TTL ") {
+				removes = append(removes, "TTL")
+			}
+		}
+
+		clusterOpts := m.extractClusterOption()
+		for _, property := range removes {
+			if err := m.DB.Exec(
+				fmt.Sprintf("ALTER TABLE ?%s MODIFY COLUMN ? REMOVE %s", clusterOpts, property),
+				clause.Table{Name: stmt.Table}, clause.Column{Name: field.DBName},
+			).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // NOTE: Only supported after ClickHouse 20.4 and above.
 // See: https://github.com/ClickHouse/ClickHouse/issues/146
 func (m Migrator) RenameColumn(value interface{}, oldName, newName string) error {
@@ -341,6 +603,33 @@ func (m Migrator) RenameColumn(value interface{}, oldName, newName string) error
 	})
 }
 
+// renamePreviousColumns renames the columns of fields tagged `gorm:"previousName:old_name"` from old_name,
+// so a field renamed in go keeps the data of its column
+func (m Migrator) renamePreviousColumns(values ...interface{}) error {
+	for _, value := range values {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			if stmt.Schema == nil || !m.HasTable(value) {
+				return nil
+			}
+
+			for _, field := range stmt.Schema.Fields {
+				previousName, ok := field.TagSettings["PREVIOUSNAME"]
+				if !ok || field.DBName == "" || m.HasColumn(value, field.DBName) || !m.HasColumn(value, previousName) {
+					continue
+				}
+
+				if err := m.RenameColumn(value, previousName, field.DBName); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m Migrator) HasColumn(value interface{}, field string) bool {
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
@@ -380,10 +669,11 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 		var rawColumnTypes []*sql.ColumnType
 		rawColumnTypes, err = rows.ColumnTypes()
 
-		columnTypeSQL := "SELECT name, type, default_expression, comment, is_in_primary_key, character_octet_length, numeric_precision, numeric_precision_radix, numeric_scale, datetime_precision FROM system.columns WHERE database = ? AND table = ?"
+		columnTypeSQL := "SELECT name, type, default_expression, comment, is_in_primary_key, default_kind, compression_codec, character_octet_length FROM system.columns WHERE database = ? AND table = ?"
 		if m.Dialector.DontSupportColumnPrecision {
-			columnTypeSQL = "SELECT name, type, default_expression, comment, is_in_primary_key FROM system.columns WHERE database = ? AND table = ?"
+			columnTypeSQL = "SELECT name, type, default_expression, comment, is_in_primary_key, default_kind, compression_codec FROM system.columns WHERE database = ? AND table = ?"
 		}
+		definitions := &columnDefinitions{migrator: m, table: stmt.Table}
 		columns, rowErr := m.DB.Raw(columnTypeSQL, m.CurrentDatabase(), stmt.Table).Rows()
 		if rowErr != nil {
 			return rowErr
@@ -393,19 +683,16 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 
 		for columns.Next() {
 			var (
-				column            migrator.ColumnType
-				decimalSizeValue  *uint64
-				datetimePrecision *uint64
-				radixValue        *uint64
-				scaleValue        *uint64
-				lengthValue       *uint64
-				values            = []interface{}{
-					&column.NameValue, &column.DataTypeValue, &column.DefaultValueValue, &column.CommentValue, &column.PrimaryKeyValue, &lengthValue, &decimalSizeValue, &radixValue, &scaleValue, &datetimePrecision,
+				column      = tableColumnType{definitions: definitions}
+				lengthValue *uint64
+				values      = []interface{}{
+					&column.NameValue, &column.DataTypeValue, &column.DefaultValueValue, &column.CommentValue, &column.PrimaryKeyValue,
+					&column.DefaultKind, &column.CompressionCodec, &lengthValue,
 				}
 			)
 
 			if m.Dialector.DontSupportColumnPrecision {
-				values = []interface{}{&column.NameValue, &column.DataTypeValue, &column.DefaultValueValue, &column.CommentValue, &column.PrimaryKeyValue}
+				values = values[:len(values)-1]
 			}
 
 			if scanErr := columns.Scan(values...); scanErr != nil {
@@ -413,18 +700,11 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 			}
 
 			column.ColumnTypeValue = column.DataTypeValue
+			column.DataTypeValue.String = unwrapLowCardinality(column.DataTypeValue.String)
 
-			if decimalSizeValue != nil {
-				column.DecimalSizeValue.Int64 = int64(*decimalSizeValue)
-				column.DecimalSizeValue.Valid = true
-			} else if datetimePrecision != nil {
-				column.DecimalSizeValue.Int64 = int64(*datetimePrecision)
-				column.DecimalSizeValue.Valid = true
-			}
-
-			if scaleValue != nil {
-				column.ScaleValue.Int64 = int64(*scaleValue)
-				column.ScaleValue.Valid = true
+			if precision, scale, ok := decimalSizeOf(column.DataTypeValue.String); ok {
+				column.DecimalSizeValue = sql.NullInt64{Int64: precision, Valid: true}
+				column.ScaleValue = sql.NullInt64{Int64: scale, Valid: true}
 			}
 
 			if lengthValue != nil {
@@ -440,16 +720,30 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 				column.DefaultValueValue.Valid = false
 			}
 
+			column.NullableValue.Bool = strings.HasPrefix(column.DataTypeValue.String, "Nullable(")
+			column.NullableValue.Valid = true
+
 			for _, c := range rawColumnTypes {
 				if c.Name() == column.NameValue.String {
 					column.SQLColumnType = c
+					column.ScanTypeValue = c.ScanType()
 					break
 				}
 			}
 
+			// drivers wrapping clickhouse-go may not report scan types
+			if column.ScanTypeValue == nil || column.ScanTypeValue.Kind() == reflect.Interface {
+				if scanType := scanTypeOf(column.ColumnTypeValue.String); scanType != nil {
+					column.ScanTypeValue = scanType
+				}
+			}
+
 			columnTypes = append(columnTypes, column)
 		}
 
+		if stmt.Schema != nil {
+			columnTypes = append(columnTypes, m.flattenedColumnTypes(stmt, columnTypes)...)
+		}
 		return
 	})
 
@@ -488,14 +782,63 @@ func (m Migrator) CreateIndex(value interface{}, name string) error {
 
 			// NOTE: concept of UNIQUE | FULLTEXT | SPATIAL index
 			// is NOT supported in clickhouse
-			createIndexSQL := "ALTER TABLE ? ADD INDEX ? ? TYPE %s GRANULARITY %d"                             // TODO(iqdf): how to inject Granularity
-			createIndexSQL = fmt.Sprintf(createIndexSQL, indexType, m.getIndexGranularityOption(index.Fields)) // Granularity: 1 (default)
-			return m.DB.Exec(createIndexSQL, values...).Error
+			createIndexSQL := "ALTER TABLE ?%s ADD INDEX ? ? TYPE %s GRANULARITY %d"                                                     // TODO(iqdf): how to inject Granularity
+			createIndexSQL = fmt.Sprintf(createIndexSQL, m.extractClusterOption(), indexType, m.getIndexGranularityOption(index.Fields)) // Granularity: 1 (default)
+			return WithSettings(m.DB, m.indexSettings(indexType)).Exec(createIndexSQL, values...).Error
 		}
 		return ErrCreateIndexFailed
 	})
 }
 
+// MaterializeIndex builds the index for data inserted before the index was added
+func (m Migrator) MaterializeIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema != nil {
+			if idx := stmt.Schema.LookIndex(name); idx != nil {
+				name = idx.Name
+			}
+		}
+		clusterOpts := m.extractClusterOption()
+		return m.DB.Exec(
+			fmt.Sprintf("ALTER TABLE ?%s MATERIALIZE INDEX ?", clusterOpts),
+			clause.Table{Name: stmt.Table}, clause.Column{Name: name},
+		).Error
+	})
+}
+
+// MaterializeColumn computes the MATERIALIZED column name of the table of value for the parts written before
+// the column was added, waits until the mutation is done on all replicas if wait is set, AutoMigrate
+// materializes the MATERIALIZED columns it adds if clickhouse:materialize_columns is set, e.g.
+//
+//	type Event struct {
+//		CreatedAt time.Time
+//		Year      uint16 `gorm:"->;type:UInt16;materialized:toYear(created_at)"`
+//	}
+//
+//	db.Set("clickhouse:materialize_columns", true).AutoMigrate(&Event{})
+func (m Migrator) MaterializeColumn(value interface{}, name string, wait bool) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema != nil {
+			if field := stmt.Schema.LookUpField(name); field != nil {
+				name = field.DBName
+			}
+		}
+		tx := m.DB
+		if wait {
+			tx = WithSettings(tx, clickhouse.Settings{"mutations_sync": 2})
+		}
+		return tx.Exec(
+			fmt.Sprintf("ALTER TABLE ?%s MATERIALIZE COLUMN ?", m.extractClusterOption()),
+			clause.Table{Name: stmt.Table}, clause.Column{Name: name},
+		).Error
+	})
+}
+
+func (m Migrator) materializeColumns() bool {
+	materialize, ok := m.DB.Get("clickhouse:materialize_columns")
+	return ok && materialize == true
+}
+
 func (m Migrator) RenameIndex(value interface{}, oldName, newName string) error {
 	// TODO(iqdf): drop index and add the index again with different name
 	// DROP INDEX ?
@@ -510,7 +853,7 @@ func (m Migrator) DropIndex(value interface{}, name string) error {
 				name = idx.Name
 			}
 		}
-		dropIndexSQL := "ALTER TABLE ? DROP INDEX ?"
+		dropIndexSQL := fmt.Sprintf("ALTER TABLE ?%s DROP INDEX ?", m.extractClusterOption())
 		return m.DB.Exec(dropIndexSQL,
 			clause.Table{Name: stmt.Table},
 			clause.Column{Name: name}).Error
@@ -549,10 +892,105 @@ func (m Migrator) HasIndex(value interface{}, name string) bool {
 	return count > 0
 }
 
+// GetIndexes returns the data skipping indexes of the table
+func (m Migrator) GetIndexes(value interface{}) ([]gorm.Index, error) {
+	indexes := make([]gorm.Index, 0)
+	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		var rows []struct {
+			Name        string
+			Expr        string
+			Type        string
+			Granularity uint64
+		}
+		if err := m.DB.Raw(
+			"SELECT name, expr, type, granularity FROM system.data_skipping_indices WHERE database = ? AND table = ?",
+			m.CurrentDatabase(), stmt.Table,
+		).Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			columns := splitTypeArgs(strings.TrimSuffix(strings.TrimPrefix(row.Expr, "("), ")"))
+			for idx, column := range columns {
+				columns[idx] = strings.Trim(column, "`")
+			}
+			indexes = append(indexes, migrator.Index{
+				TableName:       stmt.Table,
+				NameValue:       row.Name,
+				ColumnList:      columns,
+				PrimaryKeyValue: sql.NullBool{Valid: true},
+				UniqueValue:     sql.NullBool{Valid: true},
+				OptionValue:     fmt.Sprintf("TYPE %s GRANULARITY %d", row.Type, row.Granularity),
+			})
+		}
+		return nil
+	})
+	return indexes, err
+}
+
+// GetTypeAliases returns the types sharing the same storage with databaseTypeName, AutoMigrate
+// passes lower case type names
+func (m Migrator) GetTypeAliases(databaseTypeName string) []string {
+	name := strings.ToLower(databaseTypeName)
+	switch name {
+	case "bool", "uint8":
+		return []string{"bool", "uint8", "lowcardinality(" + name + ")"}
+	}
+	// DatabaseTypeName unwraps LowCardinality, which doesn't change the values stored
+	return []string{"lowcardinality(" + name + ")"}
+}
+
 // Helper
 
 // Index
 
+// ddlSettings returns the settings required to create or modify the columns of fields, the experimental ones
+// only with EnableExperimentalSettings
+func (m Migrator) ddlSettings(fields ...*schema.Field) clickhouse.Settings {
+	settings := clickhouse.Settings{}
+	if hasStatistics(fields...) {
+		maps.Copy(settings, m.allowExperimental(statisticsSettings))
+	}
+	if m.Dialector.hasVariant(fields...) {
+		maps.Copy(settings, m.allowExperimental(variantSettings))
+	}
+	if hasNested(fields...) {
+		maps.Copy(settings, nestedSettings)
+	}
+	return settings
+}
+
+// experimentalIndexSettings settings required to create indexes of experimental types
+var experimentalIndexSettings = map[string]string{
+	"inverted":          "allow_experimental_inverted_index",
+	"full_text":         "allow_experimental_full_text_index",
+	"text":              "allow_experimental_full_text_index",
+	"vector_similarity": "allow_experimental_vector_similarity_index",
+	"usearch":           "allow_experimental_usearch_index",
+	"annoy":             "allow_experimental_annoy_index",
+}
+
+// allowExperimental returns the allow_experimental_* settings with EnableExperimentalSettings, nil otherwise
+func (m Migrator) allowExperimental(settings clickhouse.Settings) clickhouse.Settings {
+	if !m.Dialector.EnableExperimentalSettings {
+		return nil
+	}
+	return settings
+}
+
+// indexSettings returns the settings enabling the experimental index type, e.g. text(tokenizer = 'ngrams'),
+// with EnableExperimentalSettings
+func (m Migrator) indexSettings(indexType string) clickhouse.Settings {
+	name := strings.TrimSpace(indexType)
+	if idx := strings.IndexByte(name, '('); idx >= 0 {
+		name = name[:idx]
+	}
+	if setting, ok := experimentalIndexSettings[strings.ToLower(name)]; ok {
+		return m.allowExperimental(clickhouse.Settings{setting: 1})
+	}
+	return nil
+}
+
 func (m Migrator) getIndexGranularityOption(opts []schema.IndexOption) int {
 	for _, indexOpt := range opts {
 		if settingStr, ok := indexOpt.Field.TagSettings["INDEX"]; ok {
@@ -1,13 +1,19 @@
 package clickhouse_test
 
 import (
+	"errors"
+	"math/big"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/hardwk/gorm-driver-clickhouse"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"gorm.io/gorm/utils/tests"
 )
 
 type User struct {
@@ -109,18 +115,9 @@ func TestMigrator_HasIndex(t *testing.T) {
 }
 
 func TestMigrator_DontSupportEmptyDefaultValue(t *testing.T) {
-	options, err := clickhousego.ParseDSN(dbDSN)
-	if err != nil {
-		t.Fatalf("Can not parse dsn, got error %v", err)
-	}
-
-	DB, err := gorm.Open(clickhouse.New(clickhouse.Config{
-		Conn:                         clickhousego.OpenDB(options),
+	DB := newTestDB(t, clickhouse.Config{
 		DontSupportEmptyDefaultValue: true,
-	}))
-	if err != nil {
-		t.Fatalf("failed to connect database, got error %v", err)
-	}
+	})
 
 	type MyTable struct {
 		MyField string
@@ -160,29 +157,11 @@ func TestMigrator_OnClusterSupport(t *testing.T) {
 		CreatedAt time.Time
 	}
 
-	// Test ON CLUSTER extraction from gorm:table_options
-	sqlStrings := make([]string, 0)
-
-	// Create a new DB instance for this test
-	options, err := clickhousego.ParseDSN(dbDSN)
-	if err != nil {
-		t.Fatalf("Can not parse dsn, got error %v", err)
-	}
-
-	testDB, err := gorm.Open(clickhouse.New(clickhouse.Config{
-		Conn: clickhousego.OpenDB(options),
-	}))
+	// Test ON CLUSTER extraction from gorm:table_options with a mock recording the SQL
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
-		t.Fatalf("failed to connect database, got error %v", err)
-	}
-
-	// Replace raw callback to capture SQL
-	if err := testDB.Callback().Raw().Replace("gorm:raw", func(db *gorm.DB) {
-		sqlToExecute := db.Statement.SQL.String()
-		sqlStrings = append(sqlStrings, sqlToExecute)
-		// Don't actually execute for this test
-	}); err != nil {
-		t.Fatalf("no error should happen when registering a callback, but got %v", err)
+		t.Fatalf("failed to open mock, got error %v", err)
 	}
 
 	// Test with ON CLUSTER in table_options
@@ -192,6 +171,7 @@ func TestMigrator_OnClusterSupport(t *testing.T) {
 	}
 
 	// Check if ON CLUSTER was placed correctly in the SQL
+	sqlStrings := mock.SQL()
 	if len(sqlStrings) == 0 {
 		t.Fatalf("expected SQL to be captured")
 	}
@@ -208,3 +188,968 @@ func TestMigrator_OnClusterSupport(t *testing.T) {
 		t.Fatalf("ON CLUSTER not placed correctly. Got SQL: %s", createSQL)
 	}
 }
+
+func TestMigrator_MigrationLock(t *testing.T) {
+	lock := &clickhouse.MigrationLock{Table: "test_migration_lock", Timeout: time.Second, PollInterval: 10 * time.Millisecond}
+	lockDB := newTestDB(t, clickhouse.Config{MigrationLock: lock})
+
+	type LockedTable struct {
+		ID   uint64
+		Name string
+	}
+
+	lockDB.Migrator().DropTable(&LockedTable{}, "test_migration_lock")
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() { errs <- lockDB.AutoMigrate(&LockedTable{}) }()
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("no error should happen when auto migrate concurrently, but got %v", err)
+		}
+	}
+
+	var count int64
+	if err := lockDB.Table("test_migration_lock").Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("migration lock should be released, got %v rows, error %v", count, err)
+	}
+
+	if err := lockDB.Exec("INSERT INTO test_migration_lock (name, owner, acquired_at) SELECT 'auto_migrate', 'other', now64(6)").Error; err != nil {
+		t.Fatalf("failed to hold migration lock, got error %v", err)
+	}
+
+	lock.Timeout = 50 * time.Millisecond
+	if err := lockDB.AutoMigrate(&LockedTable{}); !errors.Is(err, clickhouse.ErrMigrationLockTimeout) {
+		t.Errorf("should time out when the migration lock is held, got %v", err)
+	}
+}
+
+func TestMigrator_DumpSchema(t *testing.T) {
+	if err := DB.Exec("CREATE VIEW IF NOT EXISTS dump_users_view AS SELECT id, name FROM users").Error; err != nil {
+		t.Fatalf("failed to create view, got error %v", err)
+	}
+
+	var buf strings.Builder
+	if err := DB.Migrator().(clickhouse.Migrator).DumpSchema(&buf); err != nil {
+		t.Fatalf("failed to dump schema, got error %v", err)
+	}
+
+	dump := buf.String()
+	tableIdx := strings.Index(dump, "CREATE TABLE gorm.users\n")
+	viewIdx := strings.Index(dump, "CREATE VIEW gorm.dump_users_view")
+	if tableIdx < 0 || viewIdx < 0 || viewIdx < tableIdx {
+		t.Errorf("dump should contain the users table before the view, got %v", dump)
+	}
+}
+
+func TestMigrator_Diff(t *testing.T) {
+	diffs, err := DB.Migrator().(clickhouse.Migrator).Diff(&User{})
+	if err != nil {
+		t.Fatalf("failed to diff schema, got error %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("users table should match the model, got %+v", diffs)
+	}
+
+	type DiffTable struct {
+		ID    uint64
+		Name  string
+		Score float64
+	}
+	DB.Migrator().DropTable(&DiffTable{})
+	if err := DB.Exec("CREATE TABLE diff_tables (id UInt64, name Int32, extra String) ENGINE=ReplacingMergeTree ORDER BY id").Error; err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	type Missing struct {
+		ID uint64
+	}
+	diffs, err = DB.Migrator().(clickhouse.Migrator).Diff(&DiffTable{}, &Missing{})
+	if err != nil {
+		t.Fatalf("failed to diff schema, got error %v", err)
+	}
+
+	if len(diffs) != 2 {
+		t.Fatalf("expects 2 table diffs, got %+v", diffs)
+	}
+
+	diff := diffs[0]
+	tests.AssertEqual(t, diff.MissingColumns, []string{"score"})
+	tests.AssertEqual(t, diff.ExtraColumns, []string{"extra"})
+	tests.AssertEqual(t, diff.ColumnTypes, []clickhouse.ColumnTypeDiff{{Column: "name", Expected: "String", Actual: "Int32"}})
+	tests.AssertEqual(t, diff.Engine, &clickhouse.Drift{Expected: "MergeTree", Actual: "ReplacingMergeTree"})
+	tests.AssertEqual(t, diff.OrderBy, &clickhouse.Drift{Expected: "tuple()", Actual: "id"})
+
+	if !diffs[1].Missing || diffs[1].Table != "missings" {
+		t.Errorf("missings table should be reported missing, got %+v", diffs[1])
+	}
+}
+
+func TestMigrator_Introspection(t *testing.T) {
+	type IntrospectTable struct {
+		ID        uint64
+		Name      string `gorm:"index:idx_name,type:bloom_filter"`
+		Age       int64  `gorm:"type:Nullable(Int64)"`
+		CreatedAt time.Time
+	}
+
+	DB.Migrator().DropTable(&IntrospectTable{})
+	if err := DB.AutoMigrate(&IntrospectTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	columnTypes, err := DB.Migrator().ColumnTypes(&IntrospectTable{})
+	if err != nil {
+		t.Fatalf("failed to get column types, got error %v", err)
+	}
+	for _, columnType := range columnTypes {
+		if nullable, ok := columnType.Nullable(); !ok || nullable != (columnType.Name() == "age") {
+			t.Errorf("column %v nullable should be %v, got %v", columnType.Name(), columnType.Name() == "age", nullable)
+		}
+		if columnType.ScanType() == nil {
+			t.Errorf("column %v should have a scan type", columnType.Name())
+		}
+	}
+
+	indexes, err := DB.Migrator().GetIndexes(&IntrospectTable{})
+	if err != nil {
+		t.Fatalf("failed to get indexes, got error %v", err)
+	}
+	if len(indexes) != 1 || indexes[0].Name() != "idx_name" || len(indexes[0].Columns()) != 1 || indexes[0].Columns()[0] != "name" {
+		t.Errorf("expects index idx_name on name, got %+v", indexes)
+	}
+
+	tableType, err := DB.Migrator().TableType(&IntrospectTable{})
+	if err != nil {
+		t.Fatalf("failed to get table type, got error %v", err)
+	}
+	if tableType.Name() != "introspect_tables" || tableType.Type() != "BASE TABLE" {
+		t.Errorf("expects base table introspect_tables, got %v %v", tableType.Name(), tableType.Type())
+	}
+}
+
+func TestMigrator_ColumnTypeScanTypes(t *testing.T) {
+	type ScanTypeTable struct {
+		ID        uint64
+		Code      string     `gorm:"type:LowCardinality(Nullable(String))"`
+		Tag       string     `gorm:"type:LowCardinality(String)"`
+		Amount    *big.Int   `gorm:"type:Int128"`
+		CreatedAt time.Time  `gorm:"type:DateTime64(3)"`
+		DeletedAt *time.Time `gorm:"type:Nullable(DateTime64(3))"`
+	}
+
+	DB.Migrator().DropTable(&ScanTypeTable{})
+	if err := DB.AutoMigrate(&ScanTypeTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	columnTypes, err := DB.Migrator().ColumnTypes(&ScanTypeTable{})
+	if err != nil {
+		t.Fatalf("failed to get column types, got error %v", err)
+	}
+
+	expects := map[string]struct {
+		DatabaseTypeName string
+		Nullable         bool
+		ScanType         reflect.Type
+	}{
+		"id":         {"UInt64", false, reflect.TypeOf(uint64(0))},
+		"code":       {"Nullable(String)", true, reflect.TypeOf(new(string))},
+		"tag":        {"String", false, reflect.TypeOf("")},
+		"amount":     {"Int128", false, reflect.TypeOf(big.NewInt(0))},
+		"created_at": {"DateTime64(3)", false, reflect.TypeOf(time.Time{})},
+		"deleted_at": {"Nullable(DateTime64(3))", true, reflect.TypeOf(new(time.Time))},
+	}
+	for _, columnType := range columnTypes {
+		expect := expects[columnType.Name()]
+		if columnType.DatabaseTypeName() != expect.DatabaseTypeName {
+			t.Errorf("column %v type should be %v, got %v", columnType.Name(), expect.DatabaseTypeName, columnType.DatabaseTypeName())
+		}
+		if nullable, ok := columnType.Nullable(); !ok || nullable != expect.Nullable {
+			t.Errorf("column %v nullable should be %v, got %v", columnType.Name(), expect.Nullable, nullable)
+		}
+		if columnType.ScanType() != expect.ScanType {
+			t.Errorf("column %v scan type should be %v, got %v", columnType.Name(), expect.ScanType, columnType.ScanType())
+		}
+	}
+
+	migrateDB := newTestDB(t, clickhouse.Config{
+		DontSupportEmptyDefaultValue: true,
+	})
+
+	var statements []string
+	migrateDB.Callback().Raw().Before("gorm:raw").Register("test:collect_alters", func(db *gorm.DB) {
+		if sql := db.Statement.SQL.String(); strings.Contains(sql, "MODIFY COLUMN") {
+			statements = append(statements, sql)
+		}
+	})
+
+	if err := migrateDB.AutoMigrate(&ScanTypeTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+	if len(statements) != 0 {
+		t.Errorf("unchanged columns should not be altered, got %v", statements)
+	}
+}
+
+func TestMigrator_DecimalSize(t *testing.T) {
+	type DecimalSizeTable struct {
+		ID        uint64
+		Price     float64    `gorm:"type:Decimal(10, 2)"`
+		Rate      *float64   `gorm:"type:Nullable(Decimal64(4))"`
+		CreatedAt time.Time  `gorm:"type:DateTime64(6, 'UTC')"`
+		DeletedAt *time.Time `gorm:"type:Nullable(DateTime64(3))"`
+	}
+
+	DB.Migrator().DropTable(&DecimalSizeTable{})
+	if err := DB.AutoMigrate(&DecimalSizeTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	columnTypes, err := DB.Migrator().ColumnTypes(&DecimalSizeTable{})
+	if err != nil {
+		t.Fatalf("failed to get column types, got error %v", err)
+	}
+
+	expects := map[string][2]int64{"price": {10, 2}, "rate": {18, 4}, "created_at": {6, 0}, "deleted_at": {3, 0}}
+	for _, columnType := range columnTypes {
+		precision, scale, ok := columnType.DecimalSize()
+		if expect, has := expects[columnType.Name()]; !has {
+			if ok {
+				t.Errorf("column %v should not have decimal size, got %v, %v", columnType.Name(), precision, scale)
+			}
+		} else if !ok || precision != expect[0] || scale != expect[1] {
+			t.Errorf("column %v decimal size should be %v, got %v, %v", columnType.Name(), expect, precision, scale)
+		}
+	}
+}
+
+func TestMigrator_DataTypeMapper(t *testing.T) {
+	mapperDB := newTestDB(t, clickhouse.Config{
+		DataTypeMapper: func(field *schema.Field) string {
+			if field.DataType == schema.Time {
+				return "DateTime64(6)"
+			}
+			return ""
+		},
+	})
+
+	type MappedTable struct {
+		ID        uint64
+		CreatedAt time.Time
+	}
+
+	mapperDB.Migrator().DropTable(&MappedTable{})
+	if err := mapperDB.AutoMigrate(&MappedTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	columnTypes, err := mapperDB.Migrator().ColumnTypes(&MappedTable{})
+	if err != nil {
+		t.Fatalf("failed to get column types, got error %v", err)
+	}
+	for _, columnType := range columnTypes {
+		expects := map[string]string{"id": "UInt64", "created_at": "DateTime64(6)"}[columnType.Name()]
+		if columnType.DatabaseTypeName() != expects {
+			t.Errorf("column %v should be %v, got %v", columnType.Name(), expects, columnType.DatabaseTypeName())
+		}
+	}
+}
+
+func TestMigrator_UseBoolType(t *testing.T) {
+	boolDB := newTestDB(t, clickhouse.Config{UseBoolType: true})
+
+	type BoolTable struct {
+		ID     uint64
+		Active bool
+	}
+
+	boolDB.Migrator().DropTable(&BoolTable{})
+	if err := boolDB.AutoMigrate(&BoolTable{}, &User{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	boolType := "Bool"
+	if boolDB.Dialector.(*clickhouse.Dialector).DontSupportBoolType {
+		boolType = "UInt8"
+	}
+
+	for table, expects := range map[string]string{"bool_tables": boolType, "users": "UInt8"} {
+		var dataType string
+		if err := boolDB.Raw("SELECT type FROM system.columns WHERE database = currentDatabase() AND table = ? AND name = 'active'", table).Row().Scan(&dataType); err != nil {
+			t.Fatalf("failed to get column type, got error %v", err)
+		}
+		if dataType != expects {
+			t.Errorf("active column of %v should be %v, got %v", table, expects, dataType)
+		}
+	}
+}
+
+func TestMigrator_DefaultEngine(t *testing.T) {
+	engineDB := newTestDB(t, clickhouse.Config{
+		DefaultEngine:  "ReplacingMergeTree()",
+		DefaultOrderBy: "id",
+	})
+
+	type EngineTable struct {
+		ID   uint64
+		Name string
+	}
+
+	engineDB.Migrator().DropTable(&EngineTable{})
+	if err := engineDB.AutoMigrate(&EngineTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var engine, sortingKey string
+	if err := engineDB.Raw("SELECT engine, sorting_key FROM system.tables WHERE database = currentDatabase() AND name = 'engine_tables'").Row().Scan(&engine, &sortingKey); err != nil {
+		t.Fatalf("failed to get table engine, got error %v", err)
+	}
+	if engine != "ReplacingMergeTree" || sortingKey != "id" {
+		t.Errorf("expects ReplacingMergeTree ordered by id, got %v ordered by %v", engine, sortingKey)
+	}
+}
+
+type DDLTable struct {
+	ID        uint64
+	Version   uint64
+	CreatedAt time.Time
+}
+
+func (DDLTable) ClickhouseEngine() string      { return "ReplacingMergeTree(version)" }
+func (DDLTable) ClickhousePartitionBy() string { return "toYYYYMM(created_at)" }
+func (DDLTable) ClickhouseOrderBy() string     { return "(id, created_at)" }
+func (DDLTable) ClickhouseSettings() map[string]any {
+	return map[string]any{"index_granularity": 4096}
+}
+
+func TestMigrator_ModelTableOptions(t *testing.T) {
+	DB.Migrator().DropTable(&DDLTable{})
+	if err := DB.AutoMigrate(&DDLTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var engine, partitionKey, sortingKey, engineFull string
+	if err := DB.Raw("SELECT engine, partition_key, sorting_key, engine_full FROM system.tables WHERE database = currentDatabase() AND name = 'ddl_tables'").Row().Scan(&engine, &partitionKey, &sortingKey, &engineFull); err != nil {
+		t.Fatalf("failed to get table engine, got error %v", err)
+	}
+
+	tests.AssertEqual(t, engine, "ReplacingMergeTree")
+	tests.AssertEqual(t, partitionKey, "toYYYYMM(created_at)")
+	tests.AssertEqual(t, sortingKey, "id, created_at")
+	if !strings.Contains(engineFull, "index_granularity = 4096") {
+		t.Errorf("table settings should be applied, got %v", engineFull)
+	}
+
+	diffs, err := DB.Migrator().(clickhouse.Migrator).Diff(&DDLTable{})
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("table should match the model, got %+v, error %v", diffs, err)
+	}
+}
+
+func TestMigrator_TextIndex(t *testing.T) {
+	type LogEntry struct {
+		ID      uint64
+		Message string `gorm:"index:idx_message,type:text(tokenizer = 'ngrams'\\, ngram_size = 3),granularity:1"`
+	}
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{EnableExperimentalSettings: true})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var settings []clickhousego.Settings
+	if err := testDB.Callback().Raw().After("gorm:raw").Register("test:settings", func(db *gorm.DB) {
+		settings = append(settings, clickhouse.SettingsFromContext(db.Statement.Context))
+	}); err != nil {
+		t.Fatalf("no error should happen when registering a callback, but got %v", err)
+	}
+
+	if err := testDB.Migrator().CreateTable(&LogEntry{}); err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+	if err := testDB.Migrator().(clickhouse.Migrator).MaterializeIndex(&LogEntry{}, "idx_message"); err != nil {
+		t.Fatalf("failed to materialize index, got error %v", err)
+	}
+
+	statements := mock.SQL()
+	if len(statements) != 2 {
+		t.Fatalf("expects 2 statements, got %v", statements)
+	}
+	if !strings.Contains(statements[0], "INDEX idx_message (`message`) TYPE text(tokenizer = 'ngrams', ngram_size = 3) GRANULARITY 1") {
+		t.Errorf("text index should be created, got %v", statements[0])
+	}
+	if settings[0]["allow_experimental_full_text_index"] != 1 {
+		t.Errorf("experimental text index setting should be enabled, got %v", settings[0])
+	}
+	tests.AssertEqual(t, statements[1], "ALTER TABLE `log_entries` MATERIALIZE INDEX `idx_message`")
+}
+
+func TestMigrator_Statistics(t *testing.T) {
+	type MetricValue struct {
+		ID    uint64
+		Value float64 `gorm:"statistics:tdigest,uniq"`
+	}
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{EnableExperimentalSettings: true})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	if err := testDB.Callback().Raw().After("gorm:raw").Register("test:settings", func(db *gorm.DB) {
+		if clickhouse.SettingsFromContext(db.Statement.Context)["allow_experimental_statistics"] != 1 {
+			t.Errorf("experimental statistics setting should be enabled for %v", db.Statement.SQL.String())
+		}
+	}); err != nil {
+		t.Fatalf("no error should happen when registering a callback, but got %v", err)
+	}
+
+	migrator := testDB.Migrator().(clickhouse.Migrator)
+	if err := migrator.CreateTable(&MetricValue{}); err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+	if err := migrator.AddStatistics(&MetricValue{}, "ID", "minmax", "uniq"); err != nil {
+		t.Fatalf("failed to add statistics, got error %v", err)
+	}
+	if err := migrator.MaterializeStatistics(&MetricValue{}, "ID", "Value"); err != nil {
+		t.Fatalf("failed to materialize statistics, got error %v", err)
+	}
+	if err := migrator.DropStatistics(&MetricValue{}, "ID"); err != nil {
+		t.Fatalf("failed to drop statistics, got error %v", err)
+	}
+
+	statements := mock.SQL()
+	if len(statements) != 4 || !strings.Contains(statements[0], "`value` Float64 STATISTICS(tdigest,uniq)") {
+		t.Fatalf("column statistics should be declared, got %v", statements)
+	}
+	tests.AssertEqual(t, statements[1:], []string{
+		"ALTER TABLE `metric_values` ADD STATISTICS `id` TYPE minmax, uniq",
+		"ALTER TABLE `metric_values` MATERIALIZE STATISTICS `id`, `value`",
+		"ALTER TABLE `metric_values` DROP STATISTICS `id`",
+	})
+}
+
+type TieredTable struct {
+	ID        uint64
+	CreatedAt time.Time
+}
+
+func (TieredTable) ClickhouseOrderBy() string { return "id" }
+func (TieredTable) ClickhouseTTL() []string {
+	return []string{clickhouse.TTLToVolume("toDateTime(created_at) + INTERVAL 7 DAY", "cold"), clickhouse.TTLDelete("toDateTime(created_at) + INTERVAL 1 YEAR")}
+}
+func (TieredTable) ClickhouseStoragePolicy() string { return "hot_and_cold" }
+
+type ExpiringTable struct {
+	ID        uint64
+	CreatedAt time.Time
+}
+
+func (ExpiringTable) ClickhouseTTL() []string {
+	return []string{clickhouse.TTLDelete("toDateTime(created_at) + INTERVAL 1 YEAR")}
+}
+func (ExpiringTable) ClickhouseStoragePolicy() string { return "default" }
+
+func TestMigrator_TTLAndStoragePolicy(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	if err := testDB.Migrator().CreateTable(&TieredTable{}); err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	createSQL := mock.SQL()[0]
+	expects := "ENGINE=MergeTree() ORDER BY id TTL toDateTime(created_at) + INTERVAL 7 DAY TO VOLUME 'cold', toDateTime(created_at) + INTERVAL 1 YEAR DELETE SETTINGS storage_policy = 'hot_and_cold'"
+	if !strings.HasSuffix(createSQL, expects) {
+		t.Errorf("expects table options %v, got %v", expects, createSQL)
+	}
+
+	DB.Migrator().DropTable(&ExpiringTable{})
+	if err := DB.AutoMigrate(&ExpiringTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	diffs, err := DB.Migrator().(clickhouse.Migrator).Diff(&ExpiringTable{}, &TieredTable{})
+	if err != nil {
+		t.Fatalf("failed to diff schema, got error %v", err)
+	}
+	if len(diffs) != 1 || !diffs[0].Missing {
+		t.Errorf("only the tiered table should be reported missing, got %+v", diffs)
+	}
+}
+
+type RollupTable struct {
+	SiteID uint64
+	Day    time.Time `gorm:"type:Date"`
+	Hits   uint64
+	Errors uint64
+}
+
+func (RollupTable) ClickhouseOrderBy() string { return "(site_id, day)" }
+func (RollupTable) ClickhouseTTL() []string {
+	return []string{clickhouse.TTLGroupBy("day + INTERVAL 1 MONTH", []string{"site_id"}, map[string]string{"hits": "sum(hits)", "errors": "sum(errors)"})}
+}
+
+func TestMigrator_TTLGroupBy(t *testing.T) {
+	DB.Migrator().DropTable(&RollupTable{})
+	if err := DB.AutoMigrate(&RollupTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var createSQL string
+	if err := DB.Raw("SELECT create_table_query FROM system.tables WHERE database = currentDatabase() AND name = 'rollup_tables'").Row().Scan(&createSQL); err != nil {
+		t.Fatalf("failed to get table, got error %v", err)
+	}
+
+	if !strings.Contains(createSQL, "TTL day + toIntervalMonth(1) GROUP BY site_id SET errors = sum(errors), hits = sum(hits)") {
+		t.Errorf("rollup ttl should be declared, got %v", createSQL)
+	}
+}
+
+func TestMigrator_DetachTable(t *testing.T) {
+	type DetachedTable struct {
+		ID uint64
+	}
+
+	DB.Migrator().DropTable(&DetachedTable{})
+	if err := DB.AutoMigrate(&DetachedTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	migrator := DB.Migrator().(clickhouse.Migrator)
+	if err := migrator.DetachTable(&DetachedTable{}, false); err != nil {
+		t.Fatalf("failed to detach table, got error %v", err)
+	}
+	if DB.Migrator().HasTable(&DetachedTable{}) {
+		t.Errorf("detached table should not exist")
+	}
+
+	if err := migrator.AttachTable(&DetachedTable{}); err != nil {
+		t.Fatalf("failed to attach table, got error %v", err)
+	}
+	if !DB.Migrator().HasTable(&DetachedTable{}) {
+		t.Errorf("attached table should exist")
+	}
+}
+
+func TestMigrator_DefaultCluster(t *testing.T) {
+	type ClusterDefault struct {
+		ID   uint64
+		Name string `gorm:"index"`
+	}
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{DefaultCluster: "{cluster}"})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	migrator := testDB.Migrator()
+	if err := migrator.CreateTable(&ClusterDefault{}); err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+	if err := migrator.CreateIndex(&ClusterDefault{}, "Name"); err != nil {
+		t.Fatalf("failed to create index, got error %v", err)
+	}
+	if err := migrator.DropTable(&ClusterDefault{}); err != nil {
+		t.Fatalf("failed to drop table, got error %v", err)
+	}
+	if err := testDB.Set("gorm:table_options", "ON CLUSTER other ENGINE=MergeTree ORDER BY id").Migrator().DropTable(&ClusterDefault{}); err != nil {
+		t.Fatalf("failed to drop table, got error %v", err)
+	}
+
+	expects := []string{
+		"CREATE TABLE `cluster_defaults` ON CLUSTER '{cluster}' (",
+		"ALTER TABLE `cluster_defaults` ON CLUSTER '{cluster}' ADD INDEX `idx_cluster_defaults_name`",
+		"DROP TABLE IF EXISTS `cluster_defaults` ON CLUSTER '{cluster}'",
+		"DROP TABLE IF EXISTS `cluster_defaults` ON CLUSTER other",
+	}
+	statements := mock.SQL()
+	if len(statements) != len(expects) {
+		t.Fatalf("expects %v statements, got %v", len(expects), statements)
+	}
+	for idx, expect := range expects {
+		if !strings.HasPrefix(statements[idx], expect) {
+			t.Errorf("expects statement starting with %v, got %v", expect, statements[idx])
+		}
+	}
+}
+
+func TestMigrator_DistributedDDLSettings(t *testing.T) {
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{
+		DistributedDDLOutputMode:  "null_status_on_timeout",
+		DistributedDDLTaskTimeout: 90 * time.Second,
+	})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var settings clickhousego.Settings
+	if err := testDB.Callback().Raw().After("gorm:raw").Register("test:settings", func(db *gorm.DB) {
+		settings = clickhouse.SettingsFromContext(db.Statement.Context)
+	}); err != nil {
+		t.Fatalf("no error should happen when registering a callback, but got %v", err)
+	}
+
+	if err := testDB.Set("gorm:table_options", "ON CLUSTER 'test_cluster' ENGINE=MergeTree ORDER BY id").Migrator().DropTable("ddl_settings"); err != nil {
+		t.Fatalf("failed to drop table, got error %v", err)
+	}
+
+	if settings["distributed_ddl_output_mode"] != "null_status_on_timeout" || settings["distributed_ddl_task_timeout"] != int64(90) {
+		t.Errorf("expects distributed ddl settings applied, got %v", settings)
+	}
+}
+
+func TestMigrator_CreateOrReplace(t *testing.T) {
+	type Country struct {
+		Code string
+		Name string
+	}
+
+	DB.Migrator().DropTable(&Country{})
+	if err := DB.Exec("CREATE TABLE countries (code String, name String, population UInt64) ENGINE=MergeTree ORDER BY code").Error; err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	if err := DB.Exec("INSERT INTO countries VALUES ('nz', 'New Zealand', 5000000)").Error; err != nil {
+		t.Fatalf("failed to insert country, got error %v", err)
+	}
+
+	if err := DB.Set("clickhouse:create_or_replace", true).AutoMigrate(&Country{}); err != nil {
+		t.Fatalf("failed to replace table, got error %v", err)
+	}
+
+	columnTypes, err := DB.Migrator().ColumnTypes(&Country{})
+	if err != nil {
+		t.Fatalf("failed to get column types, got error %v", err)
+	}
+
+	if len(columnTypes) != 2 || DB.Migrator().HasColumn(&Country{}, "population") {
+		t.Errorf("expects table replaced with the columns of the model, got %v columns", len(columnTypes))
+	}
+
+	var count int64
+	if err := DB.Model(&Country{}).Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("expects replaced table to be empty, got %v, error %v", count, err)
+	}
+}
+
+func TestMigrator_AddColumnPosition(t *testing.T) {
+	type ColumnPosition struct {
+		ID       uint64
+		Age      int64
+		Name     string
+		Nickname string `gorm:"after:ID"`
+	}
+
+	DB.Migrator().DropTable(&ColumnPosition{})
+	if err := DB.Exec("CREATE TABLE column_positions (id UInt64, name String) ENGINE=MergeTree ORDER BY id").Error; err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	if err := DB.AutoMigrate(&ColumnPosition{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var columns []string
+	if err := DB.Raw("SELECT name FROM system.columns WHERE database = currentDatabase() AND table = 'column_positions' ORDER BY position").Scan(&columns).Error; err != nil {
+		t.Fatalf("failed to get columns, got error %v", err)
+	}
+
+	if expects := "id,nickname,age,name"; strings.Join(columns, ",") != expects {
+		t.Errorf("expects columns %v, got %v", expects, columns)
+	}
+}
+
+func TestMigrator_PruneColumns(t *testing.T) {
+	type PrunedEvent struct {
+		ID   uint64
+		Name string
+	}
+
+	DB.Migrator().DropTable(&PrunedEvent{})
+	if err := DB.Exec("CREATE TABLE pruned_events (id UInt64, name String, legacy String, referrer String) ENGINE=MergeTree ORDER BY id").Error; err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	columns, err := DB.Migrator().(clickhouse.Migrator).PruneColumns(true, &PrunedEvent{})
+	if err != nil {
+		t.Fatalf("failed to list pruned columns, got error %v", err)
+	}
+
+	if expects := "legacy,referrer"; strings.Join(columns["pruned_events"], ",") != expects {
+		t.Errorf("expects pruned columns %v, got %v", expects, columns)
+	}
+
+	if !DB.Migrator().HasColumn(&PrunedEvent{}, "legacy") {
+		t.Errorf("dry run should not drop columns")
+	}
+
+	if err := DB.Set("clickhouse:prune_columns", true).AutoMigrate(&PrunedEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	for _, column := range []string{"legacy", "referrer"} {
+		if DB.Migrator().HasColumn(&PrunedEvent{}, column) {
+			t.Errorf("column %v should be pruned", column)
+		}
+	}
+}
+
+func TestMigrator_PreviousName(t *testing.T) {
+	type RenamedEvent struct {
+		ID       uint64
+		Referrer string `gorm:"previousName:referer"`
+	}
+
+	DB.Migrator().DropTable(&RenamedEvent{})
+	if err := DB.Exec("CREATE TABLE renamed_events (id UInt64, referer String) ENGINE=MergeTree ORDER BY id").Error; err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	if err := DB.Exec("INSERT INTO renamed_events VALUES (1, 'https://gorm.io')").Error; err != nil {
+		t.Fatalf("failed to insert event, got error %v", err)
+	}
+
+	if err := DB.AutoMigrate(&RenamedEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	if DB.Migrator().HasColumn(&RenamedEvent{}, "referer") {
+		t.Errorf("previous column should be renamed")
+	}
+
+	var event RenamedEvent
+	if err := DB.First(&event, "id = ?", 1).Error; err != nil || event.Referrer != "https://gorm.io" {
+		t.Errorf("expects renamed column to keep its data, got %+v, error %v", event, err)
+	}
+
+	if err := DB.AutoMigrate(&RenamedEvent{}); err != nil {
+		t.Errorf("migrating a renamed column again should be a no-op, got error %v", err)
+	}
+}
+
+func TestMigrator_RemoveColumnProperties(t *testing.T) {
+	type ColumnProperty struct {
+		ID        uint64
+		Score     int64
+		CreatedAt time.Time
+	}
+
+	DB.Migrator().DropTable(&ColumnProperty{})
+	if err := DB.Exec(
+		"CREATE TABLE column_properties (id UInt64, score Int64 DEFAULT 1 COMMENT 'score' CODEC(ZSTD(1)) TTL toDateTime(created_at) + INTERVAL 1 DAY, created_at DateTime64(3)) ENGINE=MergeTree ORDER BY id",
+	).Error; err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	if err := DB.AutoMigrate(&ColumnProperty{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var column struct {
+		DefaultKind      string
+		Comment          string
+		CompressionCodec string
+	}
+	if err := DB.Raw("SELECT default_kind, comment, compression_codec FROM system.columns WHERE database = currentDatabase() AND table = 'column_properties' AND name = 'score'").Scan(&column).Error; err != nil {
+		t.Fatalf("failed to get column, got error %v", err)
+	}
+
+	if column.DefaultKind != "" || column.Comment != "" || column.CompressionCodec != "" {
+		t.Errorf("expects default, comment and codec removed, got %+v", column)
+	}
+
+	var createStmt string
+	if err := DB.Raw("SHOW CREATE TABLE column_properties").Row().Scan(&createStmt); err != nil {
+		t.Fatalf("failed to show create table, got error %v", err)
+	}
+
+	if strings.Contains(createStmt, "TTL") {
+		t.Errorf("expects column ttl removed, got %v", createStmt)
+	}
+}
+
+type SettingsTable struct {
+	ID uint64
+}
+
+func (SettingsTable) ClickhouseSettings() map[string]any {
+	return map[string]any{"index_granularity": 4096, "merge_with_ttl_timeout": 3600, "min_bytes_for_wide_part": 0}
+}
+
+func TestMigrator_ReconcileTableSettings(t *testing.T) {
+	DB.Migrator().DropTable(&SettingsTable{})
+	if err := DB.Exec("CREATE TABLE settings_tables (id UInt64) ENGINE=MergeTree ORDER BY id SETTINGS index_granularity = 8192, merge_with_ttl_timeout = 14400").Error; err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+
+	if err := DB.AutoMigrate(&SettingsTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var engineFull string
+	if err := DB.Raw("SELECT engine_full FROM system.tables WHERE database = currentDatabase() AND name = 'settings_tables'").Row().Scan(&engineFull); err != nil {
+		t.Fatalf("failed to get table engine, got error %v", err)
+	}
+
+	for _, expects := range []string{"index_granularity = 8192", "merge_with_ttl_timeout = 3600", "min_bytes_for_wide_part = 0"} {
+		if !strings.Contains(engineFull, expects) {
+			t.Errorf("expects %v in table settings, got %v", expects, engineFull)
+		}
+	}
+}
+
+type PrimaryKeyTable struct {
+	TenantID  uint64
+	CreatedAt time.Time
+	ID        uint64
+}
+
+func (PrimaryKeyTable) ClickhouseOrderBy() string    { return "(tenant_id, created_at, id)" }
+func (PrimaryKeyTable) ClickhousePrimaryKey() string { return "(tenant_id, created_at)" }
+
+func TestMigrator_PrimaryKey(t *testing.T) {
+	DB.Migrator().DropTable(&PrimaryKeyTable{})
+	if err := DB.AutoMigrate(&PrimaryKeyTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var primaryKey, sortingKey string
+	if err := DB.Raw("SELECT primary_key, sorting_key FROM system.tables WHERE database = currentDatabase() AND name = 'primary_key_tables'").Row().Scan(&primaryKey, &sortingKey); err != nil {
+		t.Fatalf("failed to get table keys, got error %v", err)
+	}
+
+	tests.AssertEqual(t, primaryKey, "tenant_id, created_at")
+	tests.AssertEqual(t, sortingKey, "tenant_id, created_at, id")
+
+	diffs, err := DB.Migrator().(clickhouse.Migrator).Diff(&PrimaryKeyTable{})
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("table should match the model, got %+v, error %v", diffs, err)
+	}
+}
+
+func TestMigrator_RenameTable(t *testing.T) {
+	type RenamedTable struct {
+		ID uint64
+	}
+
+	DB.Migrator().DropTable(&RenamedTable{}, "renamed_tables_old", "renamed_tables_atomic")
+	if err := DB.AutoMigrate(&RenamedTable{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	if err := DB.Migrator().RenameTable(&RenamedTable{}, "renamed_tables_old"); err != nil {
+		t.Fatalf("failed to rename table, got error %v", err)
+	}
+
+	if DB.Migrator().HasTable(&RenamedTable{}) || !DB.Migrator().HasTable("renamed_tables_old") {
+		t.Errorf("table should be renamed")
+	}
+
+	if err := DB.Migrator().(clickhouse.Migrator).RenameTableAtomic("renamed_tables_old", "gorm.renamed_tables_atomic"); err != nil {
+		t.Fatalf("failed to rename table atomically, got error %v", err)
+	}
+
+	if !DB.Migrator().HasTable("renamed_tables_atomic") {
+		t.Errorf("table should be renamed atomically")
+	}
+
+	if err := DB.Migrator().(clickhouse.Migrator).RenameTableAtomic("renamed_tables_atomic", "not_exists_database.renamed_tables"); !errors.Is(err, clickhouse.ErrRenameNotAtomic) {
+		t.Errorf("renaming to an unknown database should return ErrRenameNotAtomic, got %v", err)
+	}
+}
+
+func TestMigrator_MaterializeColumn(t *testing.T) {
+	type MaterializedEvent struct {
+		ID        uint64
+		CreatedAt time.Time
+		Year      uint16 `gorm:"->;type:UInt16;materialized:toYear(created_at)"`
+	}
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	if err := mockDB.Set("clickhouse:materialize_columns", true).Migrator().AddColumn(&MaterializedEvent{}, "Year"); err != nil {
+		t.Fatalf("failed to add column, got error %v", err)
+	}
+
+	expected := []string{
+		"ALTER TABLE `materialized_events` ADD COLUMN `year` UInt16 MATERIALIZED toYear(created_at) AFTER `created_at`",
+		"ALTER TABLE `materialized_events` MATERIALIZE COLUMN `year`",
+	}
+	if sqls := mock.SQL(); !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("expects sql %v, got %v", expected, sqls)
+	}
+
+	mock.Reset()
+	if err := mockDB.Migrator().AddColumn(&MaterializedEvent{}, "Year"); err != nil {
+		t.Fatalf("failed to add column, got error %v", err)
+	}
+	if sqls := mock.SQL(); len(sqls) != 1 {
+		t.Errorf("column should be materialized only with clickhouse:materialize_columns, got %v", sqls)
+	}
+}
+
+func TestMigrator_RemoveColumnPropertiesOnce(t *testing.T) {
+	type ColumnProperty struct {
+		ID    uint64
+		Score int64
+		Name  string
+	}
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	mock.Returns("FROM system.tables", []string{"count"}, []interface{}{int64(1)})
+	mock.Returns("FROM system.columns", []string{"name", "type", "default_expression", "comment", "is_in_primary_key", "default_kind", "compression_codec", "character_octet_length"},
+		[]interface{}{"id", "UInt64", "", "", true, "", "", nil},
+		[]interface{}{"score", "Int64", "1", "score", false, "DEFAULT", "CODEC(ZSTD(1))", nil},
+		[]interface{}{"name", "String", "", "", false, "", "", nil},
+	)
+	mock.Returns("SHOW CREATE TABLE", []string{"statement"},
+		[]interface{}{"CREATE TABLE column_properties\n(\n    `id` UInt64,\n    `score` Int64 DEFAULT 1 TTL now(),\n    `name` String\n)"})
+
+	if err := mockDB.AutoMigrate(&ColumnProperty{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	var columnQueries, createQueries int
+	var removes []string
+	for _, sql := range mock.SQL() {
+		switch {
+		case strings.Contains(sql, "FROM system.columns"):
+			columnQueries++
+		case strings.HasPrefix(sql, "SHOW CREATE TABLE"):
+			createQueries++
+		case strings.Contains(sql, " REMOVE "):
+			removes = append(removes, sql)
+		}
+	}
+
+	if columnQueries != 1 || createQueries != 1 {
+		t.Errorf("expects system.columns and SHOW CREATE TABLE queried once, got %v", mock.SQL())
+	}
+	expected := []string{
+		"ALTER TABLE `column_properties` MODIFY COLUMN `score` REMOVE DEFAULT",
+		"ALTER TABLE `column_properties` MODIFY COLUMN `score` REMOVE COMMENT",
+		"ALTER TABLE `column_properties` MODIFY COLUMN `score` REMOVE CODEC",
+		"ALTER TABLE `column_properties` MODIFY COLUMN `score` REMOVE TTL",
+	}
+	if !reflect.DeepEqual(removes, expected) {
+		t.Errorf("expects %v, got %v", expected, removes)
+	}
+}
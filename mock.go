@@ -0,0 +1,239 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"regexp"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// MockStatement a statement received by a Mock
+type MockStatement struct {
+	SQL  string
+	Args []interface{}
+}
+
+type mockResult struct {
+	pattern *regexp.Regexp
+	columns []string
+	rows    [][]interface{}
+	err     error
+}
+
+// Mock records the statements of a dialector opened by NewMock and returns canned results instead of
+// sending them to a server, queries without a matching result return no rows, e.g.
+//
+//	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+//	db, _ := gorm.Open(dialector, &gorm.Config{})
+//	mock.Returns(`FROM .users.`, []string{"id", "name"}, []interface{}{1, "jinzhu"})
+//
+//	db.Where("name = ?", "jinzhu").Find(&users)
+//	mock.SQL() // [SELECT * FROM `users` WHERE name = 'jinzhu']
+type Mock struct {
+	mu         sync.Mutex
+	statements []MockStatement
+	results    []mockResult
+}
+
+// NewMock returns a dialector using config with a connection served by the returned Mock, the server
+// version is not detected unless a result for SELECT version() is registered before opening it
+func NewMock(config Config) (gorm.Dialector, *Mock) {
+	mock := &Mock{}
+	config.Conn = sql.OpenDB(mockConnector{mock: mock})
+	config.DSN = ""
+	config.Options = nil
+	config.SkipInitializeWithVersion = true
+	return New(config), mock
+}
+
+// Returns registers rows returned by queries matching the regular expression pattern, the latest
+// registered matching result is used
+func (m *Mock) Returns(pattern string, columns []string, rows ...[]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, mockResult{pattern: regexp.MustCompile(pattern), columns: columns, rows: rows})
+}
+
+// Fails registers err returned by statements matching the regular expression pattern
+func (m *Mock) Fails(pattern string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, mockResult{pattern: regexp.MustCompile(pattern), err: err})
+}
+
+// Statements returns the received statements in order, prepared inserts are recorded once for each row
+func (m *Mock) Statements() []MockStatement {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockStatement(nil), m.statements...)
+}
+
+// SQL returns the received statements with their args explained
+func (m *Mock) SQL() []string {
+	statements := m.Statements()
+	sqls := make([]string, len(statements))
+	for idx, statement := range statements {
+		sqls[idx] = Dialector{}.Explain(statement.SQL, statement.Args...)
+	}
+	return sqls
+}
+
+// Reset forgets the received statements and registered results
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statements, m.results = nil, nil
+}
+
+// receive records the statement and returns the latest registered result matching it
+func (m *Mock) receive(query string, args []driver.NamedValue) *mockResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make([]interface{}, len(args))
+	for idx, arg := range args {
+		values[idx] = arg.Value
+	}
+	m.statements = append(m.statements, MockStatement{SQL: query, Args: values})
+
+	for idx := len(m.results) - 1; idx >= 0; idx-- {
+		if m.results[idx].pattern.MatchString(query) {
+			return &m.results[idx]
+		}
+	}
+	return nil
+}
+
+type mockConnector struct {
+	mock *Mock
+}
+
+func (c mockConnector) Connect(context.Context) (driver.Conn, error) {
+	return mockConn(c), nil
+}
+
+func (c mockConnector) Driver() driver.Driver {
+	return mockDriver(c)
+}
+
+type mockDriver struct {
+	mock *Mock
+}
+
+func (d mockDriver) Open(string) (driver.Conn, error) {
+	return mockConn(d), nil
+}
+
+type mockConn struct {
+	mock *Mock
+}
+
+func (c mockConn) Prepare(query string) (driver.Stmt, error) {
+	return mockStmt{mock: c.mock, query: query}, nil
+}
+
+func (c mockConn) Close() error {
+	return nil
+}
+
+func (c mockConn) Begin() (driver.Tx, error) {
+	return mockTx{}, nil
+}
+
+func (c mockConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if result := c.mock.receive(query, args); result != nil && result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c mockConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.mock.receive(query, args)
+	if result == nil {
+		return &mockRows{}, nil
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &mockRows{columns: result.columns, rows: result.rows}, nil
+}
+
+// CheckNamedValue accepts args of any type like clickhouse-go
+func (c mockConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+type mockStmt struct {
+	mock  *Mock
+	query string
+}
+
+func (s mockStmt) Close() error {
+	return nil
+}
+
+func (s mockStmt) NumInput() int {
+	return -1
+}
+
+func (s mockStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return mockConn{mock: s.mock}.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s mockStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return mockConn{mock: s.mock}.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+// CheckNamedValue accepts args of any type like clickhouse-go
+func (s mockStmt) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for idx, arg := range args {
+		values[idx] = driver.NamedValue{Ordinal: idx + 1, Value: arg}
+	}
+	return values
+}
+
+type mockTx struct{}
+
+func (mockTx) Commit() error {
+	return nil
+}
+
+func (mockTx) Rollback() error {
+	return nil
+}
+
+type mockRows struct {
+	columns []string
+	rows    [][]interface{}
+	next    int
+}
+
+func (r *mockRows) Columns() []string {
+	return r.columns
+}
+
+func (r *mockRows) Close() error {
+	return nil
+}
+
+func (r *mockRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	for idx := range dest {
+		if idx < len(r.rows[r.next]) {
+			dest[idx] = r.rows[r.next][idx]
+		}
+	}
+	r.next++
+	return nil
+}
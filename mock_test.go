@@ -0,0 +1,54 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestMock(t *testing.T) {
+	type MockUser struct {
+		ID   uint64
+		Name string
+	}
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	mock.Returns("FROM `mock_users`", []string{"id", "name"}, []interface{}{uint64(1), "jinzhu"})
+	var users []MockUser
+	if err := mockDB.Where("name <> ?", "").Find(&users).Error; err != nil {
+		t.Fatalf("failed to find, got error %v", err)
+	}
+	if !reflect.DeepEqual(users, []MockUser{{ID: 1, Name: "jinzhu"}}) {
+		t.Errorf("expects canned users, got %+v", users)
+	}
+
+	if err := mockDB.Delete(&MockUser{ID: 1}).Error; err != nil {
+		t.Fatalf("failed to delete, got error %v", err)
+	}
+
+	expected := []string{
+		"SELECT * FROM `mock_users` WHERE name <> ''",
+		"ALTER TABLE `mock_users` DELETE WHERE `id` = 1",
+	}
+	if sqls := mock.SQL(); !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("expects sql %v, got %v", expected, sqls)
+	}
+
+	mockErr := errors.New("mock error")
+	mock.Reset()
+	mock.Fails("^ALTER TABLE", mockErr)
+	if err := mockDB.Delete(&MockUser{ID: 1}).Error; !errors.Is(err, mockErr) {
+		t.Errorf("expects mock error, got %v", err)
+	}
+	if statements := mock.Statements(); len(statements) != 1 {
+		t.Errorf("expects 1 statement after reset, got %v", statements)
+	}
+}
@@ -0,0 +1,572 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// nestedSettings settings creating Nested columns as a single Array(Tuple) column instead of an Array
+// column per sub column
+var nestedSettings = clickhouse.Settings{"flatten_nested": 0}
+
+// nestedSchemas schemas of the struct types of nested and tuple fields
+var nestedSchemas sync.Map
+
+func init() {
+	schema.RegisterSerializer("nested", NestedSerializer{})
+	schema.RegisterSerializer("tuple", TupleSerializer{})
+}
+
+// NestedSerializer maps a slice of structs `gorm:"serializer:nested"` to a Nested column of the struct fields,
+// or with `gorm:"serializer:nested;flatten"` to an Array column per struct field prefixed by the column name,
+// sub columns are named by their column tag or gorm's default naming strategy, e.g.
+//
+//	type Event struct {
+//		ID    uint64
+//		Items []Item `gorm:"serializer:nested"`         // items Nested(name String, price Float64)
+//		Tags  []Tag  `gorm:"serializer:nested;flatten"` // tags.key Array(String), tags.value Array(String)
+//		Geo   Geo    `gorm:"serializer:tuple"`          // geo Tuple(country String, city String)
+//	}
+type NestedSerializer struct{}
+
+// TupleSerializer maps a struct `gorm:"serializer:tuple"` to a named Tuple column of the struct fields
+type TupleSerializer struct{}
+
+// Scan implements schema.SerializerInterface interface
+func (NestedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+	if dbValue != nil {
+		fields, err := nestedFields(field)
+		if err != nil {
+			return err
+		}
+
+		rows := reflect.ValueOf(dbValue)
+		if rows.Kind() != reflect.Slice {
+			return fmt.Errorf("%w: can not scan %T into %s", gorm.ErrInvalidData, dbValue, field.FieldType)
+		}
+
+		slice := reflect.MakeSlice(field.IndirectFieldType, rows.Len(), rows.Len())
+		for i := 0; i < rows.Len(); i++ {
+			if err := scanNested(ctx, fields, slice.Index(i), rows.Index(i)); err != nil {
+				return err
+			}
+		}
+		if err := assignValue(fieldValue.Elem(), slice); err != nil {
+			return err
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface interface, the structs are converted to maps of the sub
+// column values
+func (NestedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	fields, err := nestedFields(field)
+	if err != nil {
+		return nil, err
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(fieldValue))
+	if !value.IsValid() {
+		return []map[string]interface{}{}, nil
+	}
+
+	rows := make([]map[string]interface{}, value.Len())
+	for i := range rows {
+		if rows[i], err = nestedValue(ctx, fields, value.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// Scan implements schema.SerializerInterface interface
+func (TupleSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+	if dbValue != nil {
+		fields, err := nestedFields(field)
+		if err != nil {
+			return err
+		}
+		if err := scanNested(ctx, fields, fieldValue.Elem(), reflect.ValueOf(dbValue)); err != nil {
+			return err
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface interface, the struct is converted to a map of the sub
+// column values
+func (TupleSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	fields, err := nestedFields(field)
+	if err != nil {
+		return nil, err
+	}
+	return nestedValue(ctx, fields, reflect.ValueOf(fieldValue))
+}
+
+// nestedFields returns the fields of the struct type of a nested or tuple field
+func nestedFields(field *schema.Field) ([]*schema.Field, error) {
+	structType := field.IndirectFieldType
+	if _, ok := field.Serializer.(NestedSerializer); ok {
+		if structType.Kind() != reflect.Slice && structType.Kind() != reflect.Array {
+			return nil, fmt.Errorf("%w: nested field %s should be a slice of structs, got %s", gorm.ErrInvalidField, field.Name, field.FieldType)
+		}
+		structType = structType.Elem()
+	}
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: field %s should be a struct, got %s", gorm.ErrInvalidField, field.Name, field.FieldType)
+	}
+
+	s, err := schema.Parse(reflect.New(structType).Interface(), &nestedSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]*schema.Field, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		if f.DBName != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// isNested reports whether field is a nested or tuple field
+func isNested(field *schema.Field) bool {
+	switch field.Serializer.(type) {
+	case NestedSerializer, TupleSerializer:
+		return true
+	}
+	return false
+}
+
+// isFlattened reports whether field is a nested field stored as an Array column per sub column
+func isFlattened(field *schema.Field) bool {
+	_, nested := field.Serializer.(NestedSerializer)
+	_, flatten := field.TagSettings["FLATTEN"]
+	return nested && flatten
+}
+
+// hasNested reports whether fields are created as Nested columns, which requires nestedSettings
+func hasNested(fields ...*schema.Field) bool {
+	for _, field := range fields {
+		if _, ok := field.Serializer.(NestedSerializer); ok && !isFlattened(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedType returns the Nested or Tuple type of the struct fields of field
+func (dialector Dialector) nestedType(field *schema.Field) string {
+	fields, err := nestedFields(field)
+	if err != nil {
+		return string(field.DataType)
+	}
+
+	columns := make([]string, len(fields))
+	for idx, f := range fields {
+		columns[idx] = f.DBName + " " + dialector.DataTypeOf(f)
+	}
+
+	if _, ok := field.Serializer.(TupleSerializer); ok {
+		return "Tuple(" + strings.Join(columns, ", ") + ")"
+	}
+	return "Nested(" + strings.Join(columns, ", ") + ")"
+}
+
+// flattenedColumn an Array column of a flattened nested field
+type flattenedColumn struct {
+	Name     string
+	DataType string
+	Field    *schema.Field
+}
+
+// flattenedColumns returns the Array columns of a flattened nested field, e.g. `items.name` Array(String)
+func (dialector Dialector) flattenedColumns(field *schema.Field) ([]flattenedColumn, error) {
+	fields, err := nestedFields(field)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]flattenedColumn, len(fields))
+	for idx, f := range fields {
+		columns[idx] = flattenedColumn{
+			Name:     field.DBName + "." + f.DBName,
+			DataType: "Array(" + dialector.DataTypeOf(f) + ")",
+			Field:    f,
+		}
+	}
+	return columns, nil
+}
+
+// nestedValue returns the sub column values of the struct value
+func nestedValue(ctx context.Context, fields []*schema.Field, value reflect.Value) (map[string]interface{}, error) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			value = reflect.Value{}
+			break
+		}
+		value = value.Elem()
+	}
+
+	row := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		if !value.IsValid() {
+			value = reflect.New(f.Schema.ModelType).Elem()
+		}
+
+		v, _ := f.ValueOf(ctx, value)
+		if f.Serializer != nil {
+			var err error
+			if v, err = v.(driver.Valuer).Value(); err != nil {
+				return nil, err
+			}
+		}
+		row[f.DBName] = v
+	}
+	return row, nil
+}
+
+// scanNested scans the sub column values of src, a map by sub column name or a slice in field order, into
+// the struct dst
+func scanNested(ctx context.Context, fields []*schema.Field, dst, src reflect.Value) error {
+	for src.Kind() == reflect.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
+	if dst.Kind() == reflect.Ptr {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		dst = dst.Elem()
+	}
+
+	for idx, f := range fields {
+		var value reflect.Value
+		switch src.Kind() {
+		case reflect.Map:
+			value = src.MapIndex(reflect.ValueOf(f.DBName))
+		case reflect.Slice, reflect.Array:
+			if idx < src.Len() {
+				value = src.Index(idx)
+			}
+		default:
+			return fmt.Errorf("%w: can not scan %s into %s", gorm.ErrInvalidData, src.Type(), dst.Type())
+		}
+		if !value.IsValid() {
+			continue
+		}
+
+		if f.Serializer != nil {
+			if err := f.Serializer.Scan(ctx, f, dst, value.Interface()); err != nil {
+				return err
+			}
+		} else if err := assignValue(f.ReflectValueOf(ctx, dst), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nestedValues converts the values of nested and tuple fields of inserted rows to the maps appended by
+// clickhouse-go, and splits flattened nested fields into their Array columns
+func (dialector Dialector) nestedValues(stmt *gorm.Statement, values clause.Values) (clause.Values, error) {
+	if stmt.Schema == nil {
+		return values, nil
+	}
+
+	fields := make([]*schema.Field, len(values.Columns))
+	found := false
+	for idx, column := range values.Columns {
+		if field := stmt.Schema.LookUpField(column.Name); field != nil && isNested(field) {
+			fields[idx], found = field, true
+		}
+	}
+	if !found {
+		return values, nil
+	}
+
+	result := clause.Values{Values: make([][]interface{}, len(values.Values))}
+	flattened := make([][]flattenedColumn, len(fields))
+	for idx, column := range values.Columns {
+		if field := fields[idx]; field != nil && isFlattened(field) {
+			columns, err := dialector.flattenedColumns(field)
+			if err != nil {
+				return values, err
+			}
+			for _, c := range columns {
+				result.Columns = append(result.Columns, clause.Column{Name: "`" + c.Name + "`", Raw: true})
+			}
+			flattened[idx] = columns
+			continue
+		}
+		result.Columns = append(result.Columns, column)
+	}
+
+	for rowIdx, row := range values.Values {
+		resultRow := make([]interface{}, 0, len(result.Columns))
+		for idx, value := range row {
+			if fields[idx] == nil {
+				resultRow = append(resultRow, value)
+				continue
+			}
+
+			if valuer, ok := value.(driver.Valuer); ok {
+				var err error
+				if value, err = valuer.Value(); err != nil {
+					return values, err
+				}
+			}
+
+			if flattened[idx] == nil {
+				resultRow = append(resultRow, value)
+				continue
+			}
+
+			rows, _ := value.([]map[string]interface{})
+			for _, c := range flattened[idx] {
+				elemType := c.Field.FieldType
+				if c.Field.Serializer != nil {
+					elemType = reflect.TypeOf((*interface{})(nil)).Elem()
+				}
+				array := reflect.MakeSlice(reflect.SliceOf(elemType), len(rows), len(rows))
+				for i, r := range rows {
+					if err := assignValue(array.Index(i), reflect.ValueOf(r[c.Field.DBName])); err != nil {
+						return values, err
+					}
+				}
+				resultRow = append(resultRow, array.Interface())
+			}
+		}
+		result.Values[rowIdx] = resultRow
+	}
+	return result, nil
+}
+
+// flattenedRows scans the Array columns of flattened nested fields, e.g. `items.name` and `items.price`, as
+// a single column of the field
+type flattenedRows struct {
+	gorm.Rows
+	columns  []string
+	targets  []int          // index of the returned column of each column of the rows
+	subNames map[int]string // sub column names of the Array columns of the rows
+}
+
+// newFlattenedRows wraps rows merging the Array columns of the flattened nested fields of the statement,
+// rows are returned as is when there are none
+func newFlattenedRows(stmt *gorm.Statement, rows gorm.Rows) gorm.Rows {
+	if stmt.Schema == nil {
+		return rows
+	}
+	switch stmt.Dest.(type) {
+	case map[string]interface{}, *map[string]interface{}, *[]map[string]interface{}:
+		return rows
+	}
+
+	rawColumns, err := rows.Columns()
+	if err != nil {
+		return rows
+	}
+
+	wrapped := flattenedRows{Rows: rows, targets: make([]int, len(rawColumns)), subNames: map[int]string{}}
+	merged := map[string]int{}
+	for idx, column := range rawColumns {
+		if prefix, sub, ok := strings.Cut(column, "."); ok {
+			if field := stmt.Schema.LookUpField(prefix); field != nil && isFlattened(field) {
+				target, ok := merged[prefix]
+				if !ok {
+					target = len(wrapped.columns)
+					merged[prefix] = target
+					wrapped.columns = append(wrapped.columns, field.DBName)
+				}
+				wrapped.targets[idx] = target
+				wrapped.subNames[idx] = sub
+				continue
+			}
+		}
+		wrapped.targets[idx] = len(wrapped.columns)
+		wrapped.columns = append(wrapped.columns, column)
+	}
+
+	if len(wrapped.subNames) == 0 {
+		return rows
+	}
+	return wrapped
+}
+
+func (rows flattenedRows) Columns() ([]string, error) {
+	return rows.columns, nil
+}
+
+// Scan zips the Array columns of every flattened nested field into Nested rows scanned into its destination
+func (rows flattenedRows) Scan(dest ...interface{}) error {
+	rawDest := make([]interface{}, len(rows.targets))
+	for idx, target := range rows.targets {
+		if _, ok := rows.subNames[idx]; ok {
+			rawDest[idx] = new(interface{})
+		} else {
+			rawDest[idx] = dest[target]
+		}
+	}
+	if err := rows.Rows.Scan(rawDest...); err != nil {
+		return err
+	}
+
+	nested := map[int][]map[string]interface{}{}
+	for idx, target := range rows.targets {
+		sub, ok := rows.subNames[idx]
+		if !ok {
+			continue
+		}
+
+		array := reflect.Indirect(reflect.ValueOf(*rawDest[idx].(*interface{})))
+		if array.Kind() != reflect.Slice && array.Kind() != reflect.Array {
+			continue
+		}
+		values := nested[target]
+		for len(values) < array.Len() {
+			values = append(values, map[string]interface{}{})
+		}
+		for i := 0; i < array.Len(); i++ {
+			values[i][sub] = array.Index(i).Interface()
+		}
+		nested[target] = values
+	}
+
+	for target, values := range nested {
+		if values == nil {
+			values = []map[string]interface{}{}
+		}
+		switch d := dest[target].(type) {
+		case sql.Scanner:
+			if err := d.Scan(values); err != nil {
+				return err
+			}
+		case *interface{}:
+			*d = values
+		}
+	}
+	return nil
+}
+
+// addFlattenedColumns adds the missing Array columns of a flattened nested field at position
+func (m Migrator) addFlattenedColumns(stmt *gorm.Statement, field *schema.Field, clusterOpts, position string, vars []interface{}) error {
+	columns, err := m.Dialector.flattenedColumns(field)
+	if err != nil {
+		return err
+	}
+
+	var (
+		alters = make([]string, len(columns))
+		args   = []interface{}{clause.Table{Name: stmt.Table}}
+	)
+	for idx, column := range columns {
+		alters[idx] = "ADD COLUMN IF NOT EXISTS ? ?"
+		args = append(args, clause.Expr{SQL: "`" + column.Name + "`"}, clause.Expr{SQL: column.DataType})
+		if idx == 0 {
+			alters[idx] += position
+			args = append(args, vars...)
+		} else {
+			alters[idx] += " AFTER ?"
+			args = append(args, clause.Expr{SQL: "`" + columns[idx-1].Name + "`"})
+		}
+	}
+	return m.DB.Exec(fmt.Sprintf("ALTER TABLE ?%s %s", clusterOpts, strings.Join(alters, ", ")), args...).Error
+}
+
+// alterFlattenedColumns modifies the Array columns of a flattened nested field
+func (m Migrator) alterFlattenedColumns(stmt *gorm.Statement, field *schema.Field, clusterOpts string) error {
+	columns, err := m.Dialector.flattenedColumns(field)
+	if err != nil {
+		return err
+	}
+
+	var (
+		alters = make([]string, len(columns))
+		args   = []interface{}{clause.Table{Name: stmt.Table}}
+	)
+	for idx, column := range columns {
+		alters[idx] = "MODIFY COLUMN ? ?"
+		args = append(args, clause.Expr{SQL: "`" + column.Name + "`"}, clause.Expr{SQL: column.DataType})
+	}
+	return m.DB.Exec(fmt.Sprintf("ALTER TABLE ?%s %s", clusterOpts, strings.Join(alters, ", ")), args...).Error
+}
+
+// flattenedColumnTypes returns a column type of every flattened nested field of the statement whose Array
+// columns all exist in columnTypes, typed as the Nested type of the element types of the columns
+func (m Migrator) flattenedColumnTypes(stmt *gorm.Statement, columnTypes []gorm.ColumnType) []gorm.ColumnType {
+	types := make(map[string]string, len(columnTypes))
+	for _, columnType := range columnTypes {
+		types[columnType.Name()] = columnType.DatabaseTypeName()
+	}
+
+	var flattened []gorm.ColumnType
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || !isFlattened(field) {
+			continue
+		}
+		fields, err := nestedFields(field)
+		if err != nil {
+			continue
+		}
+
+		subColumns := make([]string, 0, len(fields))
+		for _, f := range fields {
+			columnType, ok := types[field.DBName+"."+f.DBName]
+			if !ok || !strings.HasPrefix(columnType, "Array(") {
+				break
+			}
+			subColumns = append(subColumns, f.DBName+" "+strings.TrimSuffix(strings.TrimPrefix(columnType, "Array("), ")"))
+		}
+		if len(subColumns) != len(fields) {
+			continue
+		}
+
+		dataType := sql.NullString{String: "Nested(" + strings.Join(subColumns, ", ") + ")", Valid: true}
+		flattened = append(flattened, migrator.ColumnType{
+			NameValue:       sql.NullString{String: field.DBName, Valid: true},
+			DataTypeValue:   dataType,
+			ColumnTypeValue: dataType,
+			NullableValue:   sql.NullBool{Valid: true},
+		})
+	}
+	return flattened
+}
+
+// modelColumns returns the columns of the fields of the statement in order, a flattened nested field by its
+// Array columns, so they aren't taken for columns without a field
+func (m Migrator) modelColumns(stmt *gorm.Statement) ([]flattenedColumn, error) {
+	var columns []flattenedColumn
+	for _, dbName := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[dbName]
+		if !isFlattened(field) {
+			columns = append(columns, flattenedColumn{Name: dbName, DataType: m.Migrator.DataTypeOf(field), Field: field})
+			continue
+		}
+
+		flattened, err := m.Dialector.flattenedColumns(field)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, flattened...)
+	}
+	return columns, nil
+}
@@ -0,0 +1,127 @@
+package clickhouse_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+type NestedItem struct {
+	Name  string
+	Price float64
+}
+
+type NestedGeo struct {
+	Country string
+	City    string `gorm:"column:town"`
+}
+
+type NestedEvent struct {
+	ID    uint64
+	Items []NestedItem `gorm:"serializer:nested"`
+	Tags  []NestedItem `gorm:"serializer:nested;flatten"`
+	Geo   NestedGeo    `gorm:"serializer:tuple"`
+}
+
+func TestNested(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	if err := mockDB.Migrator().CreateTable(&NestedEvent{}); err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+	statements := mock.Statements()
+	expected := "CREATE TABLE `nested_events` (`id` UInt64,`items` Nested(name String, price Float64)," +
+		"`tags.name` Array(String),`tags.price` Array(Float64),`geo` Tuple(country String, town String)  ) ENGINE=MergeTree() ORDER BY tuple()"
+	if len(statements) != 1 || statements[0].SQL != expected {
+		t.Fatalf("expects %v, got %v", expected, mock.SQL())
+	}
+
+	mock.Reset()
+	event := NestedEvent{
+		ID:    1,
+		Items: []NestedItem{{Name: "book", Price: 9.5}, {Name: "pen", Price: 1}},
+		Tags:  []NestedItem{{Name: "color", Price: 2}},
+		Geo:   NestedGeo{Country: "NL", City: "Amsterdam"},
+	}
+	if err := mockDB.Create(&event).Error; err != nil {
+		t.Fatalf("failed to create, got error %v", err)
+	}
+	statements = mock.Statements()
+	if len(statements) != 1 || !strings.HasPrefix(statements[0].SQL, "INSERT INTO `nested_events` (`items`,`tags.name`,`tags.price`,`geo`,`id`) VALUES") {
+		t.Fatalf("expects insert of flattened columns, got %+v", statements)
+	}
+	args := []interface{}{
+		[]map[string]interface{}{{"name": "book", "price": 9.5}, {"name": "pen", "price": 1.0}},
+		[]string{"color"},
+		[]float64{2},
+		map[string]interface{}{"country": "NL", "town": "Amsterdam"},
+		uint64(1),
+	}
+	for idx, arg := range statements[0].Args {
+		if !reflect.DeepEqual(arg, args[idx]) {
+			t.Errorf("expects arg %d %#v, got %#v", idx, args[idx], arg)
+		}
+	}
+
+	mock.Returns("FROM `nested_events`", []string{"id", "items", "tags.name", "tags.price", "geo"}, []interface{}{
+		uint64(1),
+		[]map[string]interface{}{{"name": "book", "price": 9.5}, {"name": "pen", "price": 1.0}},
+		[]string{"color"},
+		[]float64{2},
+		map[string]interface{}{"country": "NL", "town": "Amsterdam"},
+	})
+	var result NestedEvent
+	if err := mockDB.First(&result).Error; err != nil {
+		t.Fatalf("failed to find, got error %v", err)
+	}
+	if !reflect.DeepEqual(result, event) {
+		t.Errorf("expects %+v, got %+v", event, result)
+	}
+}
+
+func TestNestedPruneAndDiff(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	type FlattenedEvent struct {
+		ID   uint64
+		Tags []NestedItem `gorm:"serializer:nested;flatten"`
+	}
+
+	mock.Returns(`SELECT count\(\*\) FROM system.tables`, []string{"count"}, []interface{}{int64(1)})
+	mock.Returns(`SELECT engine, sorting_key, storage_policy`, []string{"engine", "sorting_key", "storage_policy"},
+		[]interface{}{"MergeTree", "", "default"})
+	mock.Returns(`SELECT name FROM system.columns`, []string{"name"},
+		[]interface{}{"id"}, []interface{}{"tags.name"}, []interface{}{"tags.price"}, []interface{}{"legacy"})
+	mock.Returns(`SELECT name, type FROM system.columns`, []string{"name", "type"},
+		[]interface{}{"id", "UInt64"}, []interface{}{"tags.name", "Array(String)"}, []interface{}{"tags.price", "Array(Float64)"},
+		[]interface{}{"legacy", "String"})
+
+	migrator := mockDB.Migrator().(clickhouse.Migrator)
+	columns, err := migrator.PruneColumns(true, &FlattenedEvent{})
+	if err != nil {
+		t.Fatalf("failed to list pruned columns, got error %v", err)
+	}
+	if expects := "legacy"; strings.Join(columns["flattened_events"], ",") != expects {
+		t.Errorf("expects pruned columns %v, got %v", expects, columns)
+	}
+
+	diffs, err := migrator.Diff(&FlattenedEvent{})
+	if err != nil {
+		t.Fatalf("failed to diff, got error %v", err)
+	}
+	expected := []clickhouse.TableDiff{{Table: "flattened_events", ExtraColumns: []string{"legacy"}}}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expects diffs %+v, got %+v", expected, diffs)
+	}
+}
@@ -0,0 +1,183 @@
+package clickhouse_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+	"gorm.io/gorm"
+)
+
+func TestOpenWithOptions(t *testing.T) {
+	options, err := clickhousego.ParseDSN(dbDSN)
+	if err != nil {
+		t.Fatalf("Can not parse dsn, got error %v", err)
+	}
+	options.ConnOpenStrategy = clickhousego.ConnOpenRoundRobin
+	options.BlockBufferSize = 4
+	options.ConnMaxLifetime = time.Minute
+	options.Settings = clickhousego.Settings{"max_threads": 3}
+
+	optionsDB := newTestDB(t, clickhouse.Config{Options: options})
+
+	var maxThreads string
+	if err := optionsDB.Raw("SELECT toString(getSetting('max_threads'))").Scan(&maxThreads).Error; err != nil || maxThreads != "3" {
+		t.Errorf("expects settings of options applied, got %v, error %v", maxThreads, err)
+	}
+
+	if !optionsDB.Migrator().HasTable(&User{}) {
+		t.Errorf("users table should exist")
+	}
+}
+
+func TestOpenWithPoolConfig(t *testing.T) {
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Hour})
+	poolDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	sqlDB, err := poolDB.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql db, got error %v", err)
+	}
+	if stats := sqlDB.Stats(); stats.MaxOpenConnections != 7 {
+		t.Errorf("expects max open connections 7, got %v", stats.MaxOpenConnections)
+	}
+}
+
+func TestOpenWithDialContext(t *testing.T) {
+	var dials int32
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+
+	dialDB, err := gorm.Open(clickhouse.New(clickhouse.Config{DSN: dbDSN, DialContext: dialer}))
+	if err != nil {
+		t.Fatalf("failed to connect database, got error %v", err)
+	}
+
+	var count int64
+	if err := dialDB.Model(&User{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count users, got error %v", err)
+	}
+	if atomic.LoadInt32(&dials) == 0 {
+		t.Errorf("connections should be dialed with the custom dialer")
+	}
+}
+
+func TestOpenWithConnOptions(t *testing.T) {
+	connDB, err := gorm.Open(clickhouse.New(clickhouse.Config{
+		DSN:              dbDSN,
+		KeepAlive:        time.Minute,
+		ConnOpenStrategy: clickhousego.ConnOpenRoundRobin,
+		BlockBufferSize:  4,
+		RecycleConnCodes: []int32{clickhouseerr.SyntaxError},
+	}))
+	if err != nil {
+		t.Fatalf("failed to connect database, got error %v", err)
+	}
+
+	sqlDB, err := connDB.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql db, got error %v", err)
+	}
+
+	if err := connDB.Exec("SELEC 1").Error; !clickhouseerr.IsSyntaxError(err) {
+		t.Fatalf("expects syntax error, got %v", err)
+	}
+	if stats := sqlDB.Stats(); stats.OpenConnections != 0 {
+		t.Errorf("connection failed with a recycled code should be closed, got %v open connections", stats.OpenConnections)
+	}
+
+	var count int64
+	if err := connDB.Model(&User{}).Count(&count).Error; err != nil {
+		t.Errorf("failed to count users, got error %v", err)
+	}
+}
+
+type wrappedDriver struct {
+	driver.Driver
+}
+
+func init() {
+	stock, _ := sql.Open("clickhouse", "")
+	sql.Register("clickhouse-wrapped", wrappedDriver{Driver: stock.Driver()})
+}
+
+func TestOpenWithCustomDriver(t *testing.T) {
+	sqlDB, err := sql.Open("clickhouse-wrapped", dbDSN)
+	if err != nil {
+		t.Fatalf("failed to open sql db, got error %v", err)
+	}
+
+	wrappedDB, err := gorm.Open(clickhouse.New(clickhouse.Config{Conn: sqlDB}))
+	if err != nil {
+		t.Fatalf("failed to connect database, got error %v", err)
+	}
+
+	columnTypes, err := wrappedDB.Migrator().ColumnTypes(&User{})
+	if err != nil || len(columnTypes) == 0 {
+		t.Fatalf("failed to get column types, got %v, error %v", len(columnTypes), err)
+	}
+
+	for _, columnType := range columnTypes {
+		if scanType := columnType.ScanType(); scanType == nil || scanType.Kind() == reflect.Interface {
+			t.Errorf("scan type of %v should be detected, got %v", columnType.Name(), scanType)
+		}
+	}
+
+	driverDB, err := gorm.Open(clickhouse.New(clickhouse.Config{DriverName: "clickhouse-wrapped", DSN: dbDSN}))
+	if err != nil {
+		t.Fatalf("failed to connect database with driver name, got error %v", err)
+	}
+
+	var count int64
+	if err := driverDB.Model(&User{}).Count(&count).Error; err != nil {
+		t.Errorf("failed to count users, got error %v", err)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	dsn := clickhouse.DSN{
+		Hosts:       []string{"127.0.0.1:9942"},
+		Database:    "gorm",
+		Username:    "gorm",
+		Password:    "gorm",
+		Settings:    clickhousego.Settings{"max_execution_time": 60},
+		Compression: clickhousego.CompressionLZ4,
+	}
+
+	dsnDB, err := gorm.Open(clickhouse.OpenConfig(dsn))
+	if err != nil {
+		t.Fatalf("failed to connect database with dsn %v, got error %v", dsn, err)
+	}
+
+	var count int64
+	if err := dsnDB.Model(&User{}).Count(&count).Error; err != nil {
+		t.Errorf("failed to count users, got error %v", err)
+	}
+
+	dsn = clickhouse.DSN{Hosts: []string{"host1:9000", "host2:9000"}, Database: "db", Username: "user", Password: "p@ss:w/rd?#&", Secure: true}
+	options, err := clickhousego.ParseDSN(dsn.String())
+	if err != nil {
+		t.Fatalf("failed to parse dsn %v, got error %v", dsn, err)
+	}
+
+	if options.Auth.Password != dsn.Password || options.Auth.Username != "user" || options.Auth.Database != "db" {
+		t.Errorf("expects auth parsed from %v, got %+v", dsn, options.Auth)
+	}
+
+	if len(options.Addr) != 2 || options.TLS == nil || options.Compression != nil {
+		t.Errorf("expects two secure addresses without compression, got %v, tls %v, compression %v", options.Addr, options.TLS, options.Compression)
+	}
+}
@@ -0,0 +1,165 @@
+package clickhouse
+
+import (
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PartitionID identifies a partition by its id in system.parts instead of the value of the partition key
+type PartitionID string
+
+// partitionExpr returns the partition expression of partition, a value of the partition key,
+// e.g. 202401 for toYYYYMM(created_at), or a PartitionID
+func partitionExpr(partition interface{}) clause.Expr {
+	if id, ok := partition.(PartitionID); ok {
+		return clause.Expr{SQL: "ID ?", Vars: []interface{}{string(id)}}
+	}
+	return clause.Expr{SQL: "?", Vars: []interface{}{partition}}
+}
+
+// tableNameOf returns the table name of a model or a table name
+func tableNameOf(db *gorm.DB, value interface{}) (string, error) {
+	if name, ok := value.(string); ok {
+		return name, nil
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return "", err
+	}
+	return stmt.Table, nil
+}
+
+// alterPartition runs ALTER TABLE ... <action> PARTITION <partition> <suffix> on the table of value
+func (m Migrator) alterPartition(value interface{}, action string, partition interface{}, suffix string, vars ...interface{}) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		clusterOpts := m.extractClusterOption()
+		return m.DB.Exec(
+			fmt.Sprintf("ALTER TABLE ?%s %s PARTITION ?%s", clusterOpts, action, suffix),
+			append([]interface{}{clause.Table{Name: stmt.Table}, partitionExpr(partition)}, vars...)...,
+		).Error
+	})
+}
+
+// MovePartitionToTable moves partition of the table of value to the table of dest, both tables must have
+// the same structure, partition key and storage policy, e.g.
+//
+//	db.Migrator().(clickhouse.Migrator).MovePartitionToTable(&Event{}, 202401, &ArchivedEvent{})
+func (m Migrator) MovePartitionToTable(value interface{}, partition interface{}, dest interface{}) error {
+	table, err := tableNameOf(m.DB, dest)
+	if err != nil {
+		return err
+	}
+	return m.alterPartition(value, "MOVE", partition, " TO TABLE ?", clause.Table{Name: table})
+}
+
+// MovePartitionToDisk moves partition of the table of value to disk of its storage policy
+func (m Migrator) MovePartitionToDisk(value interface{}, partition interface{}, disk string) error {
+	return m.alterPartition(value, "MOVE", partition, " TO DISK ?", disk)
+}
+
+// MovePartitionToVolume moves partition of the table of value to volume of its storage policy
+func (m Migrator) MovePartitionToVolume(value interface{}, partition interface{}, volume string) error {
+	return m.alterPartition(value, "MOVE", partition, " TO VOLUME ?", volume)
+}
+
+// optimize runs OPTIMIZE TABLE for partition of the table of value, or the whole table if partition is nil
+func (m Migrator) optimize(value interface{}, partition interface{}, final bool) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		sql := "OPTIMIZE TABLE ?" + m.extractClusterOption()
+		vars := []interface{}{clause.Table{Name: stmt.Table}}
+		if partition != nil {
+			sql += " PARTITION ?"
+			vars = append(vars, partitionExpr(partition))
+		}
+		if final {
+			sql += " FINAL"
+		}
+		return m.DB.Exec(sql, vars...).Error
+	})
+}
+
+// OptimizeTable schedules a merge of the parts of the table of value, merges all parts until a single part
+// is left in each partition if final is set, e.g. to deduplicate the rows of ReplacingMergeTree tables
+func (m Migrator) OptimizeTable(value interface{}, final bool) error {
+	return m.optimize(value, nil, final)
+}
+
+// OptimizePartition merges only the parts of partition of the table of value, e.g. to deduplicate the rows
+// loaded into a partition without merging the whole table
+//
+//	db.Migrator().(clickhouse.Migrator).OptimizePartition(&Event{}, 202401, true)
+func (m Migrator) OptimizePartition(value interface{}, partition interface{}, final bool) error {
+	return m.optimize(value, partition, final)
+}
+
+// FrozenPart a part frozen or unfrozen by FreezePartition or UnfreezePartition
+type FrozenPart struct {
+	CommandType    string
+	PartitionID    string
+	PartName       string
+	BackupName     string
+	BackupPath     string
+	PartBackupPath string
+}
+
+// freezePartition runs FREEZE or UNFREEZE for partition of the table of value, or the whole table if partition is nil
+func (m Migrator) freezePartition(value interface{}, action string, partition interface{}, name string) (parts []FrozenPart, err error) {
+	err = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		sql := "ALTER TABLE ?%s %s WITH NAME ?"
+		vars := []interface{}{clause.Table{Name: stmt.Table}, name}
+		if partition != nil {
+			sql = "ALTER TABLE ?%s %s PARTITION ? WITH NAME ?"
+			vars = []interface{}{clause.Table{Name: stmt.Table}, partitionExpr(partition), name}
+		}
+
+		return WithSettings(m.DB, clickhouse.Settings{"alter_partition_verbose_result": 1}).
+			Raw(fmt.Sprintf(sql, m.extractClusterOption(), action), vars...).Scan(&parts).Error
+	})
+	return
+}
+
+// FreezePartition creates a local backup of partition of the table of value named name under the shadow directory
+// of the server, freezes the whole table if partition is nil, returns the frozen parts and their backup paths
+func (m Migrator) FreezePartition(value interface{}, partition interface{}, name string) ([]FrozenPart, error) {
+	return m.freezePartition(value, "FREEZE", partition, name)
+}
+
+// UnfreezePartition removes the backup named name of partition of the table of value, or of the whole table if partition is nil
+func (m Migrator) UnfreezePartition(value interface{}, partition interface{}, name string) ([]FrozenPart, error) {
+	return m.freezePartition(value, "UNFREEZE", partition, name)
+}
+
+const inPartitionName = "IN PARTITION"
+
+// InPartitionClause limits ALTER TABLE UPDATE and DELETE mutations to a partition, see InPartition
+type InPartitionClause struct {
+	Partition interface{}
+}
+
+// Name implements clause.Interface interface
+func (InPartitionClause) Name() string {
+	return inPartitionName
+}
+
+// Build implements clause.Expression interface, the IN PARTITION keywords are written as the clause name
+func (p InPartitionClause) Build(builder clause.Builder) {
+	partitionExpr(p.Partition).Build(builder)
+}
+
+// MergeClause implements clause.Interface interface
+func (p InPartitionClause) MergeClause(c *clause.Clause) {
+	c.Expression = p
+}
+
+// InPartition scope to rewrite only the parts of partition with Update and Delete mutations, partition is a
+// value of the partition key or a PartitionID, e.g.
+//
+//	db.Scopes(clickhouse.InPartition(202401)).Where("user_id = ?", userID).Delete(&Event{})
+func InPartition(partition interface{}) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(InPartitionClause{Partition: partition})
+	}
+}
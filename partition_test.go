@@ -0,0 +1,107 @@
+package clickhouse_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+type PartitionedEvent struct {
+	ID        uint64
+	CreatedAt time.Time
+}
+
+func (PartitionedEvent) ClickhousePartitionBy() string { return "toYYYYMM(created_at)" }
+func (PartitionedEvent) ClickhouseOrderBy() string     { return "id" }
+
+type ArchivedEvent struct {
+	ID        uint64
+	CreatedAt time.Time
+}
+
+func (ArchivedEvent) ClickhousePartitionBy() string { return "toYYYYMM(created_at)" }
+func (ArchivedEvent) ClickhouseOrderBy() string     { return "id" }
+
+func TestMovePartitionToTable(t *testing.T) {
+	DB.Migrator().DropTable(&PartitionedEvent{}, &ArchivedEvent{})
+	if err := DB.AutoMigrate(&PartitionedEvent{}, &ArchivedEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	events := []PartitionedEvent{
+		{ID: 1, CreatedAt: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, CreatedAt: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	if err := DB.Create(&events).Error; err != nil {
+		t.Fatalf("failed to create events, got error %v", err)
+	}
+
+	if err := DB.Migrator().(clickhouse.Migrator).MovePartitionToTable(&PartitionedEvent{}, 202401, &ArchivedEvent{}); err != nil {
+		t.Fatalf("failed to move partition, got error %v", err)
+	}
+
+	var hot, archived int64
+	DB.Model(&PartitionedEvent{}).Count(&hot)
+	DB.Model(&ArchivedEvent{}).Count(&archived)
+	if hot != 1 || archived != 1 {
+		t.Errorf("expects 1 hot and 1 archived event, got %v and %v", hot, archived)
+	}
+
+	parts, err := DB.Migrator().(clickhouse.Migrator).FreezePartition(&PartitionedEvent{}, 202402, "test_freeze")
+	if err != nil {
+		t.Fatalf("failed to freeze partition, got error %v", err)
+	}
+	if len(parts) != 1 || parts[0].PartitionID != "202402" || parts[0].BackupName != "test_freeze" {
+		t.Errorf("expects frozen part of partition 202402, got %+v", parts)
+	}
+
+	if _, err := DB.Migrator().(clickhouse.Migrator).UnfreezePartition(&PartitionedEvent{}, nil, "test_freeze"); err != nil {
+		t.Errorf("failed to unfreeze table, got error %v", err)
+	}
+}
+
+func TestInPartition(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	mockDB.Scopes(clickhouse.InPartition(202401)).Where("id = ?", 1).Delete(&PartitionedEvent{})
+	mockDB.Model(&PartitionedEvent{}).Scopes(clickhouse.InPartition(clickhouse.PartitionID("202402"))).Where("id = ?", 2).Update("created_at", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	expected := []string{
+		"ALTER TABLE `partitioned_events` DELETE IN PARTITION 202401 WHERE id = 1",
+		"ALTER TABLE `partitioned_events` UPDATE `created_at`='2024-02-01 00:00:00' IN PARTITION ID '202402' WHERE id = 2",
+	}
+	if sqls := mock.SQL(); !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("expects sql %v, got %v", expected, sqls)
+	}
+}
+
+func TestOptimizePartition(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	migrator := mockDB.Migrator().(clickhouse.Migrator)
+	if err := migrator.OptimizePartition(&PartitionedEvent{}, 202401, true); err != nil {
+		t.Fatalf("failed to optimize partition, got error %v", err)
+	}
+	if err := migrator.OptimizeTable(&PartitionedEvent{}, false); err != nil {
+		t.Fatalf("failed to optimize table, got error %v", err)
+	}
+
+	expected := []string{
+		"OPTIMIZE TABLE `partitioned_events` PARTITION 202401 FINAL",
+		"OPTIMIZE TABLE `partitioned_events`",
+	}
+	if sqls := mock.SQL(); !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("expects sql %v, got %v", expected, sqls)
+	}
+}
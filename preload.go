@@ -0,0 +1,140 @@
+package clickhouse
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+const preloadName = "gorm:clickhouse:preload"
+
+// preloadOptions options of the queries of preloaded associations
+type preloadOptions struct {
+	ChunkSize int
+	GlobalIn  bool
+}
+
+func (dialector *Dialector) registerPreloadCallbacks(db *gorm.DB) {
+	if dialector.PreloadChunkSize <= 0 && !dialector.PreloadGlobalIn {
+		return
+	}
+
+	options := preloadOptions{ChunkSize: dialector.PreloadChunkSize, GlobalIn: dialector.PreloadGlobalIn}
+	db.Callback().Query().Replace("gorm:preload", func(db *gorm.DB) {
+		// the settings are copied to the statements of the preloaded associations
+		db.Statement.Settings.Store(preloadName, options)
+		defer db.Statement.Settings.Delete(preloadName)
+		callbacks.Preload(db)
+	})
+}
+
+// globalIn an IN condition written as GLOBAL IN
+type globalIn clause.IN
+
+// Build implements clause.Expression interface
+func (in globalIn) Build(builder clause.Builder) {
+	if len(in.Values) == 0 {
+		clause.IN(in).Build(builder)
+		return
+	}
+
+	builder.WriteQuoted(in.Column)
+	builder.WriteString(" GLOBAL IN (")
+	builder.AddVar(builder, in.Values...)
+	builder.WriteByte(')')
+}
+
+// NegationBuild implements clause.NegationExpressionBuilder interface
+func (in globalIn) NegationBuild(builder clause.Builder) {
+	if len(in.Values) == 0 {
+		clause.IN(in).NegationBuild(builder)
+		return
+	}
+
+	builder.WriteQuoted(in.Column)
+	builder.WriteString(" GLOBAL NOT IN (")
+	builder.AddVar(builder, in.Values...)
+	builder.WriteByte(')')
+}
+
+// queryPreloadInChunks runs the query of a preloaded association once for each chunk of the values of its IN
+// condition, which is written as GLOBAL IN with PreloadGlobalIn or the Global scope, returns false when the
+// query isn't chunked
+func queryPreloadInChunks(db *gorm.DB) bool {
+	v, ok := db.Statement.Settings.Load(preloadName)
+	if !ok {
+		return false
+	}
+	options := v.(preloadOptions)
+
+	whereClause, ok := db.Statement.Clauses["WHERE"]
+	if !ok {
+		return false
+	}
+	where, ok := whereClause.Expression.(clause.Where)
+	if !ok {
+		return false
+	}
+
+	inIdx := -1
+	for idx, expr := range where.Exprs {
+		if _, ok := expr.(clause.IN); ok {
+			inIdx = idx
+			break
+		}
+	}
+	if inIdx < 0 {
+		return false
+	}
+
+	var (
+		in     = where.Exprs[inIdx].(clause.IN)
+		global = options.GlobalIn || isGlobal(db.Statement)
+		exprs  = append([]clause.Expression(nil), where.Exprs...)
+	)
+	setIn := func(values []interface{}) {
+		if global {
+			exprs[inIdx] = globalIn{Column: in.Column, Values: values}
+		} else {
+			exprs[inIdx] = clause.IN{Column: in.Column, Values: values}
+		}
+		whereClause.Expression = clause.Where{Exprs: exprs}
+		db.Statement.Clauses["WHERE"] = whereClause
+	}
+
+	if options.ChunkSize <= 0 || len(in.Values) <= options.ChunkSize || db.Statement.ReflectValue.Kind() != reflect.Slice {
+		setIn(in.Values)
+		return false
+	}
+
+	var (
+		results      = reflect.MakeSlice(db.Statement.ReflectValue.Type(), 0, db.Statement.ReflectValue.Len())
+		rowsAffected int64
+	)
+	for start := 0; start < len(in.Values); start += options.ChunkSize {
+		end := start + options.ChunkSize
+		if end > len(in.Values) {
+			end = len(in.Values)
+		}
+
+		setIn(in.Values[start:end])
+		db.Statement.SQL.Reset()
+		db.Statement.Vars = nil
+		queryRows(db)
+		if db.Error != nil || db.DryRun {
+			return true
+		}
+
+		results = reflect.AppendSlice(results, db.Statement.ReflectValue)
+		rowsAffected += db.RowsAffected
+	}
+
+	db.Statement.ReflectValue.Set(results)
+	db.RowsAffected = rowsAffected
+	if db.Statement.Result != nil {
+		db.Statement.Result.RowsAffected = rowsAffected
+	}
+	return true
+}
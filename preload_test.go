@@ -0,0 +1,49 @@
+package clickhouse_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+type PreloadOrder struct {
+	ID            uint64
+	PreloadUserID uint64
+}
+
+type PreloadUser struct {
+	ID     uint64
+	Orders []PreloadOrder
+}
+
+func TestPreloadInChunks(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{PreloadChunkSize: 2, PreloadGlobalIn: true})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	mock.Returns("FROM `preload_users`", []string{"id"}, []interface{}{uint64(1)}, []interface{}{uint64(2)}, []interface{}{uint64(3)})
+	mock.Returns(`GLOBAL IN \(\?,\?\)$`, []string{"id", "preload_user_id"}, []interface{}{uint64(10), uint64(1)}, []interface{}{uint64(11), uint64(1)})
+	mock.Returns(`GLOBAL IN \(\?\)$`, []string{"id", "preload_user_id"}, []interface{}{uint64(12), uint64(3)})
+
+	var users []PreloadUser
+	if err := mockDB.Preload("Orders").Find(&users).Error; err != nil {
+		t.Fatalf("failed to preload, got error %v", err)
+	}
+
+	expected := []string{
+		"SELECT * FROM `preload_users`",
+		"SELECT * FROM `preload_orders` WHERE `preload_orders`.`preload_user_id` GLOBAL IN (1,2)",
+		"SELECT * FROM `preload_orders` WHERE `preload_orders`.`preload_user_id` GLOBAL IN (3)",
+	}
+	if sqls := mock.SQL(); !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("expects %v, got %v", expected, sqls)
+	}
+
+	if len(users) != 3 || len(users[0].Orders) != 2 || len(users[1].Orders) != 0 || len(users[2].Orders) != 1 || users[2].Orders[0].ID != 12 {
+		t.Errorf("expects orders preloaded from both chunks, got %+v", users)
+	}
+}
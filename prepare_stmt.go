@@ -0,0 +1,39 @@
+package clickhouse
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// registerPreparedStmtCallbacks disables PrepareStmt, clickhouse-go prepares every statement as an INSERT
+// batch, which can neither run queries nor be reused after it has been sent, so cached prepared statements
+// are bypassed and the underlying connection pool is used directly
+func (dialector *Dialector) registerPreparedStmtCallbacks(db *gorm.DB) {
+	if db.Config.PrepareStmt {
+		db.Logger.Warn(context.Background(), "clickhouse: PrepareStmt is not supported by the clickhouse driver, disabled")
+		db.Config.PrepareStmt = false
+	}
+
+	db.Callback().Create().Before("gorm:begin_transaction").Register("clickhouse:unwrap_prepared_stmt", unwrapPreparedStmt)
+	db.Callback().Update().Before("gorm:begin_transaction").Register("clickhouse:unwrap_prepared_stmt", unwrapPreparedStmt)
+	db.Callback().Delete().Before("gorm:begin_transaction").Register("clickhouse:unwrap_prepared_stmt", unwrapPreparedStmt)
+	db.Callback().Query().Before("gorm:query").Register("clickhouse:unwrap_prepared_stmt", unwrapPreparedStmt)
+	db.Callback().Row().Before("gorm:row").Register("clickhouse:unwrap_prepared_stmt", unwrapPreparedStmt)
+	db.Callback().Raw().Before("gorm:raw").Register("clickhouse:unwrap_prepared_stmt", unwrapPreparedStmt)
+}
+
+func unwrapPreparedStmt(db *gorm.DB) {
+	db.Statement.ConnPool = unwrapConnPool(db.Statement.ConnPool)
+}
+
+// unwrapConnPool returns the connection pool or transaction of the prepared statements of pool
+func unwrapConnPool(pool gorm.ConnPool) gorm.ConnPool {
+	switch pool := pool.(type) {
+	case *gorm.PreparedStmtDB:
+		return pool.ConnPool
+	case *gorm.PreparedStmtTX:
+		return pool.Tx
+	}
+	return pool
+}
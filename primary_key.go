@@ -0,0 +1,119 @@
+package clickhouse
+
+import (
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// compositePrimaryKey returns the tuple of the primary key columns of models with multiple primaryKey
+// fields, e.g. (`tenant_id`, `code`), returns "" otherwise
+func compositePrimaryKey(stmt *gorm.Statement) string {
+	if stmt.Schema == nil || len(stmt.Schema.PrimaryFields) < 2 {
+		return ""
+	}
+
+	columns := make([]string, len(stmt.Schema.PrimaryFields))
+	for idx, field := range stmt.Schema.PrimaryFields {
+		columns[idx] = stmt.Quote(field.DBName)
+	}
+	return "(" + strings.Join(columns, ", ") + ")"
+}
+
+// primaryKeyColumns returns the primary key columns of models with multiple primaryKey fields
+func primaryKeyColumns(stmt *gorm.Statement) []clause.Column {
+	if stmt.Schema == nil || len(stmt.Schema.PrimaryFields) < 2 {
+		return nil
+	}
+
+	columns := make([]clause.Column, len(stmt.Schema.PrimaryFields))
+	for idx, field := range stmt.Schema.PrimaryFields {
+		columns[idx] = clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	}
+	return columns
+}
+
+func isPrimaryKeyColumn(column interface{}) bool {
+	c, ok := column.(clause.Column)
+	return ok && c.Name == clause.PrimaryKey
+}
+
+// expandCompositePrimaryKey rewrites the primary key of models with multiple primaryKey fields to all of
+// their columns, gorm only uses the first one, so First/Last order by every primary key column and
+// conditions on primary key values, e.g. db.Delete(&Model{}, [][]interface{}{{1, "a"}}), compare tuples
+func expandCompositePrimaryKey(stmt *gorm.Statement) {
+	columns := primaryKeyColumns(stmt)
+	if len(columns) == 0 {
+		return
+	}
+
+	if c, ok := stmt.Clauses["ORDER BY"]; ok {
+		if orderBy, ok := c.Expression.(clause.OrderBy); ok {
+			orderByColumns := make([]clause.OrderByColumn, 0, len(orderBy.Columns))
+			for _, column := range orderBy.Columns {
+				if !isPrimaryKeyColumn(column.Column) {
+					orderByColumns = append(orderByColumns, column)
+					continue
+				}
+				for _, primaryKey := range columns {
+					orderByColumns = append(orderByColumns, clause.OrderByColumn{Column: primaryKey, Desc: column.Desc})
+				}
+			}
+			orderBy.Columns = orderByColumns
+			c.Expression = orderBy
+			stmt.Clauses["ORDER BY"] = c
+		}
+	}
+
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			expandPrimaryKeyExprs(where.Exprs, columns)
+		}
+	}
+}
+
+func expandPrimaryKeyExprs(exprs []clause.Expression, columns []clause.Column) {
+	for idx, expr := range exprs {
+		switch v := expr.(type) {
+		case clause.AndConditions:
+			expandPrimaryKeyExprs(v.Exprs, columns)
+		case clause.NotConditions:
+			expandPrimaryKeyExprs(v.Exprs, columns)
+		case clause.OrConditions:
+			expandPrimaryKeyExprs(v.Exprs, columns)
+		case clause.IN:
+			if !isPrimaryKeyColumn(v.Column) || len(v.Values) == 0 {
+				continue
+			}
+
+			values := make([]interface{}, len(v.Values))
+			for i, value := range v.Values {
+				reflectValue := reflect.Indirect(reflect.ValueOf(value))
+				if (reflectValue.Kind() != reflect.Slice && reflectValue.Kind() != reflect.Array) || reflectValue.Len() != len(columns) {
+					values = nil
+					break
+				}
+
+				tuple := make([]interface{}, reflectValue.Len())
+				for j := range tuple {
+					tuple[j] = reflectValue.Index(j).Interface()
+				}
+				values[i] = tuple
+			}
+
+			if values != nil {
+				exprs[idx] = clause.IN{Column: columns, Values: values}
+			}
+		}
+	}
+}
+
+func registerPrimaryKeyCallbacks(db *gorm.DB) {
+	db.Callback().Query().Before("gorm:query").Register("clickhouse:primary_key", func(db *gorm.DB) {
+		if db.Error == nil {
+			expandCompositePrimaryKey(db.Statement)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package clickhouse
+
+import "gorm.io/gorm"
+
+// PruneColumns drops the columns of the tables of models without a field in the model, returns the columns
+// by table, nothing is dropped with dryRun, e.g. list them before pruning
+//
+//	columns, err := db.Migrator().(clickhouse.Migrator).PruneColumns(true, &Event{})
+//
+// AutoMigrate prunes the columns of migrated models if clickhouse:prune_columns is set
+func (m Migrator) PruneColumns(dryRun bool, models ...interface{}) (map[string][]string, error) {
+	pruned := map[string][]string{}
+	for _, model := range models {
+		var columns []string
+		if err := m.RunWithValue(model, func(stmt *gorm.Statement) error {
+			var names []string
+			if err := m.DB.Raw(
+				"SELECT name FROM system.columns WHERE database = ? AND table = ? ORDER BY position",
+				m.CurrentDatabase(), stmt.Table,
+			).Scan(&names).Error; err != nil {
+				return err
+			}
+
+			modelColumns, err := m.modelColumns(stmt)
+			if err != nil {
+				return err
+			}
+			fieldColumns := make(map[string]bool, len(modelColumns))
+			for _, column := range modelColumns {
+				fieldColumns[column.Name] = true
+			}
+
+			for _, name := range names {
+				if !fieldColumns[name] {
+					columns = append(columns, name)
+				}
+			}
+
+			if len(columns) > 0 {
+				pruned[stmt.Table] = columns
+			}
+			return nil
+		}); err != nil {
+			return pruned, err
+		}
+
+		if dryRun {
+			continue
+		}
+
+		for _, column := range columns {
+			if err := m.DropColumn(model, column); err != nil {
+				return pruned, err
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// pruneColumns reports whether AutoMigrate prunes columns without a field in the model
+func (m Migrator) pruneColumns() bool {
+	prune, ok := m.DB.Get("clickhouse:prune_columns")
+	return ok && prune == true
+}
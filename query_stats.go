@@ -0,0 +1,141 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type queryStatsCtxKey struct{}
+
+// QueryStats execution statistics of a statement reported by the server over the native protocol, updated
+// while the statement runs, read them with Snapshot
+type QueryStats struct {
+	mu       sync.Mutex
+	queryID  string
+	snapshot QueryStatsSnapshot
+}
+
+// QueryStatsSnapshot a copy of the QueryStats of a statement
+type QueryStatsSnapshot struct {
+	QueryID      string
+	ReadRows     uint64
+	ReadBytes    uint64
+	WrittenRows  uint64
+	WrittenBytes uint64
+	PeakMemory   int64
+}
+
+// Snapshot returns a copy of the statistics collected so far
+func (s *QueryStats) Snapshot() QueryStatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := s.snapshot
+	snapshot.QueryID = s.queryID
+	return snapshot
+}
+
+func (s *QueryStats) String() string {
+	snapshot := s.Snapshot()
+	return fmt.Sprintf("query_id=%s read_rows=%d read_bytes=%d written_rows=%d written_bytes=%d peak_memory=%d",
+		snapshot.QueryID, snapshot.ReadRows, snapshot.ReadBytes, snapshot.WrittenRows, snapshot.WrittenBytes, snapshot.PeakMemory)
+}
+
+func (s *QueryStats) onProgress(p *clickhouse.Progress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.ReadRows += p.Rows
+	s.snapshot.ReadBytes += p.Bytes
+	s.snapshot.WrittenRows += p.WroteRows
+	s.snapshot.WrittenBytes += p.WroteBytes
+}
+
+func (s *QueryStats) onProfileEvents(events []clickhouse.ProfileEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range events {
+		if (event.Name == "MemoryTrackerPeakUsage" || event.Name == "MemoryTrackerUsage") && event.Value > s.snapshot.PeakMemory {
+			s.snapshot.PeakMemory = event.Value
+		}
+	}
+}
+
+// QueryStatsFromContext returns the statistics collected for the statement executed with ctx
+func QueryStatsFromContext(ctx context.Context) (*QueryStats, bool) {
+	stats, ok := ctx.Value(queryStatsCtxKey{}).(*QueryStats)
+	return stats, ok
+}
+
+// queryIDSequence numbers the query_ids generated when the random source fails
+var queryIDSequence atomic.Uint64
+
+// newQueryID generates a random query_id in uuid format, from the time and a sequence if the random source
+// fails so query_ids stay unique
+func newQueryID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixNano()))
+		binary.BigEndian.PutUint64(b[8:], queryIDSequence.Add(1))
+	}
+	s := hex.EncodeToString(b[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// withQueryStats assigns a query_id to every execution of the statement unless set with WithQueryID and
+// subscribes to its progress and profile events, the stats of a reused statement or context are replaced
+func withQueryStats(db *gorm.DB) {
+	stats := &QueryStats{queryID: newQueryID()}
+	if queryID, ok := db.Statement.Context.Value(queryIDCtxKey{}).(string); ok {
+		stats.queryID = queryID
+	}
+	ctx := clickhouse.Context(db.Statement.Context,
+		clickhouse.WithQueryID(stats.queryID),
+		clickhouse.WithProgress(stats.onProgress),
+		clickhouse.WithProfileEvents(stats.onProfileEvents),
+	)
+	db.Statement.Context = context.WithValue(ctx, queryStatsCtxKey{}, stats)
+}
+
+func (dialector *Dialector) registerQueryStatsCallbacks(db *gorm.DB) {
+	if !dialector.LogQueryStats && dialector.TracerProvider == nil {
+		return
+	}
+
+	if dialector.LogQueryStats {
+		db.Logger = queryStatsLogger{Interface: db.Logger}
+	}
+	db.Callback().Create().Before("gorm:begin_transaction").Register("clickhouse:query_stats", withQueryStats)
+	db.Callback().Update().Before("gorm:begin_transaction").Register("clickhouse:query_stats", withQueryStats)
+	db.Callback().Delete().Before("gorm:begin_transaction").Register("clickhouse:query_stats", withQueryStats)
+	db.Callback().Query().Before("gorm:query").Register("clickhouse:query_stats", withQueryStats)
+	db.Callback().Row().Before("gorm:row").Register("clickhouse:query_stats", withQueryStats)
+	db.Callback().Raw().Before("gorm:raw").Register("clickhouse:query_stats", withQueryStats)
+}
+
+// queryStatsLogger appends the collected QueryStats to every traced SQL
+type queryStatsLogger struct {
+	logger.Interface
+}
+
+func (l queryStatsLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return queryStatsLogger{Interface: l.Interface.LogMode(level)}
+}
+
+func (l queryStatsLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.Interface.Trace(ctx, begin, func() (string, int64) {
+		sql, rowsAffected := fc()
+		if stats, ok := QueryStatsFromContext(ctx); ok {
+			sql += " /* " + stats.String() + " */"
+		}
+		return sql, rowsAffected
+	}, err)
+}
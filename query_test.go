@@ -0,0 +1,340 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestGroupModifier(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Select("name, count(*)").Group("name").Having("count(*) > ?", 1).Clauses(clickhouse.WithRollup, clickhouse.WithTotals).Find(&[]User{})
+	})
+	if !regexp.MustCompile("GROUP BY `name` WITH ROLLUP WITH TOTALS HAVING count\\(\\*\\) > 1").MatchString(sql) {
+		t.Errorf("group by modifiers should be placed before having, got %v", sql)
+	}
+
+	type result struct {
+		Active bool
+		Total  int64
+	}
+
+	var (
+		results []result
+		totals  result
+	)
+	if err := clickhouse.FindWithTotals(DB.Model(&User{}).Select("active, count(*) AS total").Group("active"), &results, &totals); err != nil {
+		t.Fatalf("failed to find with totals, got error %v", err)
+	}
+
+	var sum int64
+	for _, r := range results {
+		sum += r.Total
+	}
+	if totals.Total != sum {
+		t.Errorf("totals should be %v, got %v", sum, totals.Total)
+	}
+}
+
+func TestWindowFunc(t *testing.T) {
+	window := clickhouse.Over([]string{"name"}, []clause.OrderByColumn{{Column: clause.Column{Name: "id"}}}, clickhouse.FrameRunning)
+
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Select("id, ?, ?", clickhouse.Sum("salary", window).As("running_total"), clickhouse.RowNumber(window)).Find(&[]User{})
+	})
+	if !regexp.MustCompile("sum\\(`salary`\\) OVER \\(PARTITION BY `name` ORDER BY `id` ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW\\) AS `running_total`").MatchString(sql) {
+		t.Errorf("window function should be rendered, got %v", sql)
+	}
+
+	type result struct {
+		ID           uint64
+		RunningTotal float64
+	}
+	var results []result
+	if err := DB.Model(&User{}).Select("id, ?", clickhouse.Sum("salary", window).As("running_total")).Find(&results).Error; err != nil {
+		t.Fatalf("failed to query window function, got error %v", err)
+	}
+}
+
+func TestAggregateFunc(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Select("name, ?, ?, ?", clickhouse.Uniq("id").As("users"), clickhouse.Quantile(0.95, "age"), clickhouse.TopK(3, "salary")).
+			Group("name").Having("? > ?", clickhouse.UniqExact("id"), 1).Find(&[]User{})
+	})
+	if !regexp.MustCompile("SELECT name, uniq\\(`id`\\) AS `users`, quantile\\(0.95\\)\\(`age`\\), topK\\(3\\)\\(`salary`\\) FROM `users` GROUP BY `name` HAVING uniqExact\\(`id`\\) > 1").MatchString(sql) {
+		t.Errorf("aggregate functions should be rendered, got %v", sql)
+	}
+
+	type result struct {
+		Name   string
+		Users  uint64
+		Oldest uint64
+	}
+	var results []result
+	if err := DB.Model(&User{}).Select("name, ?, ?", clickhouse.Uniq("id").As("users"), clickhouse.ArgMax("id", "age").As("oldest")).Group("name").Find(&results).Error; err != nil {
+		t.Fatalf("failed to query aggregate functions, got error %v", err)
+	}
+}
+
+func TestCountDistinct(t *testing.T) {
+	var expected int64
+	if err := DB.Model(&User{}).Distinct("name").Count(&expected).Error; err != nil {
+		t.Fatalf("failed to count distinct names, got error %v", err)
+	}
+
+	for _, accuracy := range []clickhouse.DistinctAccuracy{clickhouse.Exact, clickhouse.Approx, clickhouse.ApproxCombined} {
+		count, err := clickhouse.CountDistinct(DB.Model(&User{}).Order("name"), "name", accuracy)
+		if err != nil {
+			t.Fatalf("failed to count distinct names with accuracy %v, got error %v", accuracy, err)
+		}
+		if count != expected {
+			t.Errorf("expects %v distinct names with accuracy %v, got %v", expected, accuracy, count)
+		}
+	}
+}
+
+func TestDistinctOn(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Clauses(clickhouse.DistinctOn("name")).Select("id", "name").Order("name").Find(&[]User{})
+	})
+	if !regexp.MustCompile("SELECT DISTINCT ON \\(`name`\\) `id`,`name` FROM `users`").MatchString(sql) {
+		t.Errorf("distinct on should be rendered, got %v", sql)
+	}
+
+	var users []User
+	if err := DB.Clauses(clickhouse.DistinctOn("name")).Order("name").Find(&users).Error; err != nil {
+		t.Fatalf("failed to query distinct on, got error %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, user := range users {
+		if names[user.Name] {
+			t.Errorf("name %v should be distinct", user.Name)
+		}
+		names[user.Name] = true
+	}
+}
+
+func TestSetOperation(t *testing.T) {
+	q1 := DB.Model(&User{}).Select("id").Where("id = ?", 1)
+	q2 := DB.Model(&User{}).Select("id").Where("id = ?", 2)
+
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table("(?) AS u", clickhouse.Union(q1, q2, clickhouse.All)).Find(&[]User{})
+	})
+	if !regexp.MustCompile("FROM \\(\\(SELECT `id` FROM `users` WHERE id = 1\\) UNION ALL \\(SELECT `id` FROM `users` WHERE id = 2\\)\\) AS u").MatchString(sql) {
+		t.Errorf("union should be rendered, got %v", sql)
+	}
+
+	var ids []uint64
+	if err := DB.Raw("?", clickhouse.Except(clickhouse.Union(q1, q2, clickhouse.Distinct), q2, clickhouse.Distinct)).Scan(&ids).Error; err != nil {
+		t.Fatalf("failed to query set operation, got error %v", err)
+	}
+
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("set operation should return id 1, got %v", ids)
+	}
+}
+
+func TestGlobal(t *testing.T) {
+	subQuery := DB.Model(&User{}).Select("id").Where("name = ?", "create")
+
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(clickhouse.Global).Model(&User{}).Joins("LEFT JOIN users AS u2 ON u2.id = users.id").Where("users.id IN (?)", subQuery).Find(&[]User{})
+	})
+	if !regexp.MustCompile("GLOBAL LEFT JOIN users AS u2 .* WHERE users.id GLOBAL IN \\(SELECT").MatchString(sql) {
+		t.Errorf("joins and subqueries should be global, got %v", sql)
+	}
+
+	var users []User
+	if err := DB.Scopes(clickhouse.Global).Where("id IN (?)", subQuery).Find(&users).Error; err != nil {
+		t.Fatalf("failed to query with global in, got error %v", err)
+	}
+}
+
+func TestGlobalExpressions(t *testing.T) {
+	type GlobalEvent struct {
+		ID     uint64
+		UserID uint64
+		Name   string
+	}
+
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	subQuery := mockDB.Model(&GlobalEvent{}).Select("user_id").Where("name = ?", "click")
+	sql := mockDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(clickhouse.Global).Model(&GlobalEvent{}).
+			Joins("LEFT JOIN global_events AS e2 ON e2.id = global_events.id AND e2.name <> 'LEFT JOIN x'").
+			Clauses(clickhouse.Join{Kind: "ANY LEFT", Table: "global_events", Alias: "e3", On: "e3.id = global_events.id"}).
+			Where("`join` = ? AND name <> 'IN (SELECT 1)'", 1).
+			Where("user_id IN (?)", subQuery).
+			Not(clause.IN{Column: "id", Values: []interface{}{subQuery}}).
+			Find(&[]GlobalEvent{})
+	})
+
+	expected := "SELECT `global_events`.`id`,`global_events`.`user_id`,`global_events`.`name` FROM `global_events` " +
+		"GLOBAL ANY LEFT JOIN `global_events` AS `e3` ON e3.id = global_events.id " +
+		"GLOBAL LEFT JOIN global_events AS e2 ON e2.id = global_events.id AND e2.name <> 'LEFT JOIN x' " +
+		"WHERE (`join` = 1 AND name <> 'IN (SELECT 1)') " +
+		"AND user_id GLOBAL IN (SELECT `user_id` FROM `global_events` WHERE name = 'click') " +
+		"AND `id` GLOBAL NOT IN (SELECT `user_id` FROM `global_events` WHERE name = 'click')"
+	if sql != expected {
+		t.Errorf("expects %v, got %v", expected, sql)
+	}
+}
+
+func TestJoinKind(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Clauses(clickhouse.Join{Kind: "ANY LEFT", Table: "users", Alias: "u2", On: "u2.id = users.id"}).Find(&[]User{})
+	})
+	if !regexp.MustCompile("FROM `users` ANY LEFT JOIN `users` AS `u2` ON u2.id = users.id").MatchString(sql) {
+		t.Errorf("join kind should be rendered, got %v", sql)
+	}
+
+	var users []User
+	if err := DB.Clauses(clickhouse.Join{Kind: "LEFT SEMI", Subquery: DB.Model(&User{}).Select("id"), Alias: "u2", Using: []string{"id"}}).Find(&users).Error; err != nil {
+		t.Fatalf("failed to query with semi join, got error %v", err)
+	}
+
+	if err := DB.Clauses(clickhouse.Join{Kind: "LEFT SIDEWAYS", Table: "users"}).Find(&users).Error; err == nil {
+		t.Errorf("invalid join kind should return error")
+	}
+}
+
+func TestASOFJoin(t *testing.T) {
+	type UserSnapshot struct {
+		ID        uint64
+		Name      string
+		CreatedAt time.Time
+	}
+
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Clauses(clickhouse.ASOFJoin(&UserSnapshot{}, []string{"id"}, "created_at")).Find(&[]User{})
+	})
+	if !regexp.MustCompile("ASOF LEFT JOIN `user_snapshots` ON `users`.`id` = `user_snapshots`.`id` AND `users`.`created_at` >= `user_snapshots`.`created_at`").MatchString(sql) {
+		t.Errorf("asof join should be rendered, got %v", sql)
+	}
+}
+
+func TestTableFunction(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table(clickhouse.S3("https://bucket.s3.amazonaws.com/events/*.parquet", clickhouse.FormatParquet, "")).Where("id > ?", 1).Find(&[]User{})
+	})
+	if expects := "SELECT * FROM s3('https://bucket.s3.amazonaws.com/events/*.parquet', 'Parquet') WHERE id > 1"; sql != expects {
+		t.Errorf("expects %v, got %v", expects, sql)
+	}
+
+	sql = DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		credentials := clickhouse.S3Credentials{AccessKeyID: "key", SecretAccessKey: "it's"}
+		return tx.Table(clickhouse.S3WithCredentials("https://bucket/a.csv", credentials, "", "id UInt64, name String")).Find(&[]User{})
+	})
+	if expects := `SELECT * FROM s3('https://bucket/a.csv', 'key', 'it\'s', 'auto', 'id UInt64, name String')`; sql != expects {
+		t.Errorf("expects %v, got %v", expects, sql)
+	}
+
+	sql = DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table(clickhouse.NamedCollection("s3", "lake", map[string]string{"format": "CSV", "filename": "a.csv"})).Find(&[]User{})
+	})
+	if expects := "SELECT * FROM s3(lake, filename = 'a.csv', format = 'CSV')"; sql != expects {
+		t.Errorf("expects %v, got %v", expects, sql)
+	}
+
+	sql = DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table(clickhouse.File("data/users.csv", clickhouse.FormatCSVWithNames, "")).Find(&[]User{})
+	})
+	if expects := "SELECT * FROM file('data/users.csv', 'CSVWithNames')"; sql != expects {
+		t.Errorf("expects %v, got %v", expects, sql)
+	}
+}
+
+func TestRemoteTableFunction(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table(clickhouse.Remote("127.0.0.1:9000", "gorm", "users")).Where("id = ?", 1).Find(&[]User{})
+	})
+	if expects := "SELECT * FROM remote('127.0.0.1:9000', 'gorm', 'users') WHERE id = 1"; sql != expects {
+		t.Errorf("expects %v, got %v", expects, sql)
+	}
+
+	sql = DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Table(clickhouse.Cluster("my'cluster", "gorm", "users")).Find(&[]User{})
+	})
+	if expects := `SELECT * FROM cluster('my\'cluster', 'gorm', 'users')`; sql != expects {
+		t.Errorf("expects %v, got %v", expects, sql)
+	}
+
+	var count int64
+	if err := DB.Table(clickhouse.RemoteWithCredentials("127.0.0.1:9000", "gorm", "users", "gorm", "gorm")).Count(&count).Error; err != nil {
+		t.Fatalf("failed to query remote table, got error %v", err)
+	}
+}
+
+func TestTimeBucket(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&User{}).Select("?, count() AS total", clickhouse.TimeBucket("created_at", "30 minute").As("bucket")).
+			Group("bucket").Order(clause.OrderBy{Expression: clickhouse.TimeBucket("created_at", "1 hour")}).Find(&[]User{})
+	})
+	if !regexp.MustCompile("SELECT toStartOfInterval\\(`created_at`, INTERVAL 30 MINUTE\\) AS `bucket`, count\\(\\) AS total FROM `users` GROUP BY `bucket` ORDER BY toStartOfHour\\(`created_at`\\)").MatchString(sql) {
+		t.Errorf("time bucket should be rendered, got %v", sql)
+	}
+
+	if bucket := clickhouse.TimeBucket("created_at", "15 minutes").String(); bucket != "toStartOfFifteenMinutes(`created_at`)" {
+		t.Errorf("15 minutes should be bucketed with toStartOfFifteenMinutes, got %v", bucket)
+	}
+
+	type result struct {
+		Bucket time.Time
+		Total  uint64
+	}
+	var results []result
+	if err := DB.Model(&User{}).Select("?, count() AS total", clickhouse.TimeBucket("created_at", "1 day").As("bucket")).Group("bucket").Order("bucket").Find(&results).Error; err != nil {
+		t.Fatalf("failed to query time buckets, got error %v", err)
+	}
+
+	if err := DB.Model(&User{}).Select("?", clickhouse.TimeBucket("created_at", "15 fortnights")).Find(&results).Error; !errors.Is(err, gorm.ErrInvalidData) {
+		t.Errorf("invalid interval should fail with ErrInvalidData, got %v", err)
+	}
+}
+
+func TestQueryStatsQueryID(t *testing.T) {
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{LogQueryStats: true})
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var queryIDs []string
+	if err := db.Callback().Query().After("gorm:query").Register("test:query_id", func(db *gorm.DB) {
+		if stats, ok := clickhouse.QueryStatsFromContext(db.Statement.Context); ok {
+			queryIDs = append(queryIDs, stats.Snapshot().QueryID)
+		}
+	}); err != nil {
+		t.Fatalf("no error should happen when registering a callback, but got %v", err)
+	}
+
+	tx := db.Model(&User{}).Where("id = ?", 1)
+	tx.Find(&[]User{})
+	tx.Find(&[]User{})
+	db.WithContext(tx.Statement.Context).Find(&[]User{})
+	fixed := clickhouse.WithQueryID(db, "fixed-query-id")
+	fixed.Find(&[]User{})
+	fixed.Find(&[]User{})
+
+	if len(queryIDs) != 5 {
+		t.Fatalf("expects 5 query_ids, got %v", queryIDs)
+	}
+	if queryIDs[0] == "" || queryIDs[0] == queryIDs[1] || queryIDs[1] == queryIDs[2] || queryIDs[0] == queryIDs[2] {
+		t.Errorf("every execution should have its own query_id, got %v", queryIDs)
+	}
+	if queryIDs[3] != "fixed-query-id" || queryIDs[4] != "fixed-query-id" {
+		t.Errorf("query_id of WithQueryID should be kept, got %v", queryIDs)
+	}
+}
@@ -0,0 +1,23 @@
+package clickhouse
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+type quotaKeyCtxKey struct{}
+
+// WithQuotaKey runs the statements of db with quota key, so usage of quotas keyed by client_key is accounted
+// per key, e.g. per tenant of multi-tenant applications
+func WithQuotaKey(db *gorm.DB, key string) *gorm.DB {
+	ctx := clickhouse.Context(db.Statement.Context, clickhouse.WithQuotaKey(key))
+	return db.WithContext(context.WithValue(ctx, quotaKeyCtxKey{}, key))
+}
+
+// QuotaKeyFromContext returns the quota key applied with WithQuotaKey
+func QuotaKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(quotaKeyCtxKey{}).(string)
+	return key, ok
+}
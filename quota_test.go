@@ -0,0 +1,30 @@
+package clickhouse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestWithQuotaKey(t *testing.T) {
+	queryID := "gorm-test-quota-" + time.Now().Format("150405.000000")
+
+	var count int64
+	if err := clickhouse.WithQueryID(clickhouse.WithQuotaKey(DB, "tenant-1"), queryID).Model(&User{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count with quota key, got error %v", err)
+	}
+
+	if err := DB.Exec("SYSTEM FLUSH LOGS").Error; err != nil {
+		t.Fatalf("failed to flush logs, got error %v", err)
+	}
+
+	var quotaKey string
+	if err := DB.Raw("SELECT quota_key FROM system.query_log WHERE query_id = ? LIMIT 1", queryID).Scan(&quotaKey).Error; err != nil {
+		t.Fatalf("failed to query query log, got error %v", err)
+	}
+
+	if quotaKey != "tenant-1" {
+		t.Errorf("expects quota key tenant-1, got %v", quotaKey)
+	}
+}
@@ -0,0 +1,43 @@
+package clickhouse
+
+import (
+	"gorm.io/gorm"
+)
+
+const readOnlyName = "gorm:clickhouse:read_only"
+
+// ReadOnly returns a session which only allows reading data, the server enforces it with the
+// readonly setting and the dialector rejects Create/Update/Delete and DDL with ErrReadOnly locally
+func ReadOnly(db *gorm.DB) *gorm.DB {
+	return WithSettings(db, map[string]interface{}{"readonly": 2}).Set(readOnlyName, true)
+}
+
+func isReadOnly(db *gorm.DB) bool {
+	v, ok := db.Get(readOnlyName)
+	return ok && v == true
+}
+
+func registerReadOnlyCallbacks(db *gorm.DB) {
+	rejectWrite := func(db *gorm.DB) {
+		if isReadOnly(db) {
+			db.AddError(ErrReadOnly)
+		}
+	}
+
+	db.Callback().Create().Before("gorm:begin_transaction").Register("clickhouse:read_only", rejectWrite)
+	db.Callback().Update().Before("gorm:begin_transaction").Register("clickhouse:read_only", rejectWrite)
+	db.Callback().Delete().Before("gorm:begin_transaction").Register("clickhouse:read_only", rejectWrite)
+	db.Callback().Raw().Before("gorm:raw").Register("clickhouse:read_only", func(db *gorm.DB) {
+		if isReadOnly(db) && !isReadStatement(db.Statement.SQL.String()) {
+			db.AddError(ErrReadOnly)
+		}
+	})
+}
+
+func isReadStatement(query string) bool {
+	switch statementKind(query) {
+	case "SELECT", "WITH", "SHOW", "DESCRIBE", "DESC", "EXISTS", "EXPLAIN":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package clickhouse_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestReadOnly(t *testing.T) {
+	tx := clickhouse.ReadOnly(DB)
+
+	var count int64
+	if err := tx.Model(&User{}).Count(&count).Error; err != nil {
+		t.Fatalf("read-only session should query, got error %v", err)
+	}
+
+	if err := tx.Create(&User{ID: 71, Name: "read_only"}).Error; !errors.Is(err, clickhouse.ErrReadOnly) {
+		t.Errorf("read-only session should reject create, got error %v", err)
+	}
+
+	if err := tx.Model(&User{ID: 1}).Update("name", "read_only").Error; !errors.Is(err, clickhouse.ErrReadOnly) {
+		t.Errorf("read-only session should reject update, got error %v", err)
+	}
+
+	if err := tx.Delete(&User{ID: 1}).Error; !errors.Is(err, clickhouse.ErrReadOnly) {
+		t.Errorf("read-only session should reject delete, got error %v", err)
+	}
+
+	if err := tx.Migrator().AddColumn(&User{}, "Name"); !errors.Is(err, clickhouse.ErrReadOnly) {
+		t.Errorf("read-only session should reject migrations, got error %v", err)
+	}
+
+	if err := tx.Exec("SELECT 1").Error; err != nil {
+		t.Errorf("read-only session should allow raw select, got error %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package clickhouse
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SyncReplica waits until the replica of the table of value has fetched all entries of the replication queue,
+// e.g. to read data inserted through other replicas after a migration
+func (m Migrator) SyncReplica(value interface{}) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec(fmt.Sprintf("SYSTEM SYNC REPLICA%s ?", m.extractClusterOption()), clause.Table{Name: stmt.Table}).Error
+	})
+}
+
+// RestartReplica reinitializes the Keeper session of the replica of the table of value
+func (m Migrator) RestartReplica(value interface{}) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec(fmt.Sprintf("SYSTEM RESTART REPLICA%s ?", m.extractClusterOption()), clause.Table{Name: stmt.Table}).Error
+	})
+}
+
+// DropReplica removes the metadata of the dead replica from the Keeper path of the table of value,
+// the metadata is shared by all replicas, so it runs on the current server only
+func (m Migrator) DropReplica(value interface{}, replica string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec("SYSTEM DROP REPLICA ? FROM TABLE ?", replica, clause.Table{Name: stmt.Table}).Error
+	})
+}
@@ -0,0 +1,34 @@
+package clickhouse_test
+
+import (
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestReplicaMaintenance(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	migrator := testDB.Set("gorm:table_options", "ON CLUSTER 'test_cluster' ENGINE=ReplicatedMergeTree ORDER BY id").Migrator().(clickhouse.Migrator)
+	if err := migrator.SyncReplica(&User{}); err != nil {
+		t.Fatalf("failed to sync replica, got error %v", err)
+	}
+	if err := migrator.RestartReplica(&User{}); err != nil {
+		t.Fatalf("failed to restart replica, got error %v", err)
+	}
+	if err := migrator.DropReplica(&User{}, "replica-2"); err != nil {
+		t.Fatalf("failed to drop replica, got error %v", err)
+	}
+
+	tests.AssertEqual(t, mock.SQL(), []string{
+		"SYSTEM SYNC REPLICA ON CLUSTER 'test_cluster' `users`",
+		"SYSTEM RESTART REPLICA ON CLUSTER 'test_cluster' `users`",
+		"SYSTEM DROP REPLICA 'replica-2' FROM TABLE `users`",
+	})
+}
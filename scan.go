@@ -0,0 +1,130 @@
+package clickhouse
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ScanColumns reads the query result column by column into the slice fields of dest,
+// dest must be a pointer to a struct whose fields are slices, e.g.
+//
+//	var cols struct {
+//		ID   []uint64
+//		Name []string `gorm:"column:name"`
+//	}
+//	clickhouse.ScanColumns(db.Model(&User{}).Where("age > ?", 18), &cols)
+//
+// fields are matched to result columns with the `column` tag or the naming strategy,
+// and are selected automatically when the query has no explicit Select
+func ScanColumns(db *gorm.DB, dest interface{}) error {
+	reflectValue := reflect.ValueOf(dest)
+	if reflectValue.Kind() != reflect.Ptr || reflectValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: ScanColumns dest should be a pointer to struct, got %T", gorm.ErrInvalidData, dest)
+	}
+	reflectValue = reflectValue.Elem()
+
+	var (
+		reflectType = reflectValue.Type()
+		fields      = map[string]reflect.Value{}
+		columns     = make([]string, 0, reflectType.NumField())
+	)
+	for i := 0; i < reflectType.NumField(); i++ {
+		fieldStruct := reflectType.Field(i)
+		if !fieldStruct.IsExported() || fieldStruct.Type.Kind() != reflect.Slice {
+			continue
+		}
+
+		name := schema.ParseTagSetting(fieldStruct.Tag.Get("gorm"), ";")["COLUMN"]
+		if name == "" {
+			name = db.NamingStrategy.ColumnName("", fieldStruct.Name)
+		}
+		fields[name] = reflectValue.Field(i)
+		columns = append(columns, name)
+	}
+
+	tx := db
+	if _, ok := db.Statement.Clauses["SELECT"]; !ok && len(db.Statement.Selects) == 0 {
+		tx = db.Select(columns)
+	}
+
+	rows, err := tx.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	resultColumns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(resultColumns))
+	for idx, name := range resultColumns {
+		if field, ok := fields[name]; ok {
+			field.Set(reflect.MakeSlice(field.Type(), 0, 0))
+			values[idx] = reflect.New(field.Type().Elem()).Interface()
+		} else {
+			values[idx] = new(interface{})
+		}
+	}
+
+	for rows.Next() {
+		if err := (arrayRows{Rows: rows}).Scan(values...); err != nil {
+			return err
+		}
+
+		for idx, name := range resultColumns {
+			if field, ok := fields[name]; ok {
+				field.Set(reflect.Append(field, reflect.ValueOf(values[idx]).Elem()))
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+// DefaultStreamBatchSize matches the default max_block_size of ClickHouse
+const DefaultStreamBatchSize = 65409
+
+// Stream runs the query and passes results to fn in batches of batchSize rows,
+// rows are decoded lazily as blocks arrive, so memory stays bounded regardless of the
+// result size and no OFFSET pagination is required. Returning an error from fn stops
+// the stream and closes the query
+func Stream[T any](db *gorm.DB, batchSize int, fn func(batch []T) error) error {
+	if batchSize <= 0 {
+		batchSize = DefaultStreamBatchSize
+	}
+
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]T, 0, batchSize)
+	for rows.Next() {
+		var value T
+		if err := db.ScanRows(rows, &value); err != nil {
+			return err
+		}
+
+		if batch = append(batch, value); len(batch) >= batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]T, 0, batchSize)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package clickhouse_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestScanColumns(t *testing.T) {
+	users := []User{
+		{ID: 41, Name: "scan_columns_1", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: true, Salary: 6},
+		{ID: 42, Name: "scan_columns_2", FirstName: "zhang", LastName: "jinzhu", Age: 19, Active: false, Salary: 6.12},
+	}
+
+	if err := DB.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users, got error %v", err)
+	}
+
+	var cols struct {
+		ID   []uint64
+		Name []string
+		Ages []int64 `gorm:"column:age"`
+	}
+	if err := clickhouse.ScanColumns(DB.Model(&User{}).Where("id IN ?", []uint64{41, 42}).Order("id"), &cols); err != nil {
+		t.Fatalf("failed to scan columns, got error %v", err)
+	}
+
+	if !slices.Equal(cols.ID, []uint64{41, 42}) {
+		t.Errorf("ids should be scanned, got %v", cols.ID)
+	}
+
+	if !slices.Equal(cols.Name, []string{"scan_columns_1", "scan_columns_2"}) {
+		t.Errorf("names should be scanned, got %v", cols.Name)
+	}
+
+	if !slices.Equal(cols.Ages, []int64{18, 19}) {
+		t.Errorf("ages should be scanned, got %v", cols.Ages)
+	}
+}
+
+func TestStream(t *testing.T) {
+	users := []User{
+		{ID: 51, Name: "stream_1", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: true, Salary: 6},
+		{ID: 52, Name: "stream_2", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: false, Salary: 6.12},
+		{ID: 53, Name: "stream_3", FirstName: "zhang", LastName: "jinzhu", Age: 18, Active: true, Salary: 6.1234},
+	}
+
+	if err := DB.Create(&users).Error; err != nil {
+		t.Fatalf("failed to create users, got error %v", err)
+	}
+
+	var (
+		batches int
+		results []User
+	)
+	err := clickhouse.Stream(DB.Model(&User{}).Where("id IN ?", []uint64{51, 52, 53}).Order("id"), 2, func(batch []User) error {
+		batches++
+		results = append(results, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to stream users, got error %v", err)
+	}
+
+	if batches != 2 || len(results) != 3 {
+		t.Fatalf("should stream 3 users in 2 batches, got %v users in %v batches", len(results), batches)
+	}
+
+	for idx, u := range users {
+		tests.AssertEqual(t, results[idx], u)
+	}
+}
+
+func TestScanArray(t *testing.T) {
+	type ArrayRow struct {
+		ID   uint64
+		Ints []int     `gorm:"type:Array(UInt32)"`
+		Tags []string  `gorm:"type:Array(Nullable(String))"`
+		Grid [][]int   `gorm:"type:Array(Array(Int64))"`
+		Opts []*string `gorm:"type:Array(Nullable(String))"`
+	}
+
+	DB.Migrator().DropTable(&ArrayRow{})
+	if err := DB.AutoMigrate(&ArrayRow{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	if err := DB.Exec("INSERT INTO array_rows VALUES (1, [1, 2], ['a', NULL], [[1], [2, 3]], [NULL, 'b'])").Error; err != nil {
+		t.Fatalf("failed to insert, got error %v", err)
+	}
+
+	var rows []ArrayRow
+	if err := DB.Find(&rows).Error; err != nil {
+		t.Fatalf("failed to find, got error %v", err)
+	}
+	if len(rows) != 1 || !slices.Equal(rows[0].Ints, []int{1, 2}) || !slices.Equal(rows[0].Tags, []string{"a", ""}) ||
+		len(rows[0].Grid) != 2 || !slices.Equal(rows[0].Grid[1], []int{2, 3}) ||
+		len(rows[0].Opts) != 2 || rows[0].Opts[0] != nil || *rows[0].Opts[1] != "b" {
+		t.Errorf("expects scanned arrays, got %+v", rows)
+	}
+
+	var grids [][][]int
+	if err := DB.Model(&ArrayRow{}).Pluck("grid", &grids).Error; err != nil || len(grids) != 1 || len(grids[0]) != 2 {
+		t.Errorf("expects plucked arrays, got %v, error %v", grids, err)
+	}
+
+	sqlRows, err := DB.Model(&ArrayRow{}).Select("ints").Rows()
+	if err != nil {
+		t.Fatalf("failed to query rows, got error %v", err)
+	}
+	defer sqlRows.Close()
+
+	var ints []int64
+	for sqlRows.Next() {
+		if err := sqlRows.Scan(clickhouse.ScanArray(&ints)); err != nil {
+			t.Fatalf("failed to scan array, got error %v", err)
+		}
+	}
+	if !slices.Equal(ints, []int64{1, 2}) {
+		t.Errorf("expects scanned ints, got %v", ints)
+	}
+}
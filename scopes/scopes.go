@@ -0,0 +1,63 @@
+// Package scopes provides scopes applying ClickHouse query features, chained with db.Scopes, e.g.
+//
+//	db.Scopes(
+//		scopes.Final(),
+//		scopes.Prewhere("event_date = ?", today),
+//		scopes.Settings(clickhousego.Settings{"max_threads": 4}),
+//		scopes.QueryID("report-daily"),
+//	).Where("user_id = ?", userID).Find(&events)
+package scopes
+
+import (
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+// Final reads the rows of the table merged with FINAL, e.g. the latest versions of ReplacingMergeTree rows
+func Final() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clickhouse.FinalClause{})
+	}
+}
+
+// NoFinal reads the rows of models implementing clickhouse.DeduplicationInterface as stored, see clickhouse.NoFinal
+func NoFinal() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return clickhouse.NoFinal(db)
+	}
+}
+
+// Settings sends settings with the statements of the scoped db, merged with the settings applied before
+func Settings(settings clickhousego.Settings) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return clickhouse.WithSettings(db, settings)
+	}
+}
+
+// Sample reads a sample of the rows of a table with a SAMPLE BY key, size up to 1 is the ratio of rows,
+// larger sizes the approximate number of rows, the optional offset is the ratio of rows skipped before
+func Sample(size float64, offset ...float64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		sample := clickhouse.SampleClause{Size: size}
+		if len(offset) > 0 {
+			sample.Offset = offset[0]
+		}
+		return db.Clauses(sample)
+	}
+}
+
+// Prewhere filters rows with the conditions before the other columns are read, conditions take the same
+// forms as db.Where
+func Prewhere(query interface{}, args ...interface{}) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clickhouse.PrewhereClause{Exprs: db.Statement.BuildCondition(query, args...)})
+	}
+}
+
+// QueryID runs the statements of the scoped db with queryID, see clickhouse.WithQueryID
+func QueryID(queryID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return clickhouse.WithQueryID(db, queryID)
+	}
+}
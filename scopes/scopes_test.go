@@ -0,0 +1,94 @@
+package scopes_test
+
+import (
+	"reflect"
+	"testing"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"github.com/hardwk/gorm-driver-clickhouse/scopes"
+	"gorm.io/gorm"
+)
+
+type Event struct {
+	ID     uint64
+	UserID uint64
+	Name   string
+}
+
+func TestScopes(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var settings clickhousego.Settings
+	db.Callback().Query().Before("gorm:query").Register("test:settings", func(db *gorm.DB) {
+		settings = clickhouse.SettingsFromContext(db.Statement.Context)
+	})
+
+	var events []Event
+	if err := db.Scopes(
+		scopes.Final(),
+		scopes.Sample(0.1, 0.5),
+		scopes.Prewhere("name = ?", "click"),
+		scopes.Prewhere(&Event{UserID: 1}),
+		scopes.Settings(clickhousego.Settings{"max_threads": 4}),
+		scopes.QueryID("scopes"),
+	).Joins("JOIN users ON users.id = events.user_id").Where("id > ?", 10).Find(&events).Error; err != nil {
+		t.Fatalf("failed to find, got error %v", err)
+	}
+
+	expected := []string{
+		"SELECT `events`.`id`,`events`.`user_id`,`events`.`name` FROM `events` FINAL SAMPLE 0.1 OFFSET 0.5 " +
+			"JOIN users ON users.id = events.user_id PREWHERE name = 'click' AND `events`.`user_id` = 1 WHERE id > 10",
+	}
+	if sqls := mock.SQL(); !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("expects sql %v, got %v", expected, sqls)
+	}
+
+	if !reflect.DeepEqual(settings, clickhousego.Settings{"max_threads": 4}) {
+		t.Errorf("expects max_threads setting, got %v", settings)
+	}
+
+	mock.Reset()
+	var count int64
+	if err := db.Model(&Event{}).Scopes(scopes.Final()).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count, got error %v", err)
+	}
+	if sqls := mock.SQL(); len(sqls) != 1 || sqls[0] != "SELECT count(*) FROM `events` FINAL" {
+		t.Errorf("expects count with FINAL, got %v", sqls)
+	}
+}
+
+type Version struct {
+	ID      uint64
+	Version uint64
+}
+
+func (Version) ClickhouseDeduplication() clickhouse.Deduplication {
+	return clickhouse.Deduplication{Final: true}
+}
+
+func TestNoFinal(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var versions []Version
+	db.Find(&versions)
+	db.Scopes(scopes.NoFinal()).Find(&versions)
+	db.Find(&versions)
+
+	expected := []string{
+		"SELECT * FROM `versions` FINAL",
+		"SELECT * FROM `versions`",
+		"SELECT * FROM `versions` FINAL",
+	}
+	if sqls := mock.SQL(); !reflect.DeepEqual(sqls, expected) {
+		t.Errorf("expects sql %v, got %v", expected, sqls)
+	}
+}
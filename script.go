@@ -0,0 +1,124 @@
+package clickhouse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	onClusterRegexp = regexp.MustCompile(`(?i)\bON\s+CLUSTER\b`)
+	ddlObjectRegexp = regexp.MustCompile("(?is)^\\s*(?:CREATE(?:\\s+OR\\s+REPLACE)?|ALTER|DROP|TRUNCATE|ATTACH|DETACH|OPTIMIZE)\\s+" +
+		"(?:TEMPORARY\\s+)?(?:TABLE|DATABASE|DICTIONARY|VIEW|MATERIALIZED\\s+VIEW|LIVE\\s+VIEW|FUNCTION)\\s+" +
+		"(?:IF\\s+(?:NOT\\s+)?EXISTS\\s+)?(?:`[^`]+`|\"[^\"]+\"|[\\w{}]+)(?:\\.(?:`[^`]+`|\"[^\"]+\"|[\\w{}]+))?")
+	renameRegexp = regexp.MustCompile(`(?is)^\s*(?:RENAME|EXCHANGE)\s`)
+)
+
+// ExecScript executes the statements of a multi-statement SQL script in order on a single connection,
+// statements are separated by semicolons outside of strings, quoted identifiers and comments, e.g.
+//
+//	script, _ := os.ReadFile("migrations/001_events.sql")
+//	err := clickhouse.ExecScript(db, string(script))
+func ExecScript(db *gorm.DB, script string) error {
+	return execScript(db, "", script)
+}
+
+// ExecScriptOnCluster executes the statements of script like ExecScript, adding ON CLUSTER cluster to the
+// DDL statements without an ON CLUSTER clause
+func ExecScriptOnCluster(db *gorm.DB, cluster string, script string) error {
+	return execScript(db, cluster, script)
+}
+
+func execScript(db *gorm.DB, cluster string, script string) error {
+	statements := splitStatements(script)
+	return db.Connection(func(tx *gorm.DB) error {
+		for idx, statement := range statements {
+			if cluster != "" {
+				statement = withOnCluster(statement, cluster)
+			}
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("statement %d of script: %w", idx+1, err)
+			}
+		}
+		return nil
+	})
+}
+
+// withOnCluster adds ON CLUSTER cluster to DDL statement if it has none
+func withOnCluster(statement string, cluster string) string {
+	if onClusterRegexp.MatchString(statement) {
+		return statement
+	}
+
+	onCluster := " ON CLUSTER " + quoteString(cluster)
+	if loc := ddlObjectRegexp.FindStringIndex(statement); loc != nil {
+		return statement[:loc[1]] + onCluster + statement[loc[1]:]
+	}
+	if renameRegexp.MatchString(statement) {
+		return statement + onCluster
+	}
+	return statement
+}
+
+// splitStatements splits script into statements on semicolons outside of strings, quoted identifiers and
+// comments, leading comments are removed and statements containing only comments are skipped
+func splitStatements(script string) (statements []string) {
+	var (
+		start, idx int
+		hasCode    bool
+	)
+
+	appendStatement := func(end int) {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(script[start:min(end, len(script))]))
+		}
+		hasCode = false
+	}
+
+	// startCode marks the statement starting at the first code after comments
+	startCode := func() {
+		if !hasCode {
+			start, hasCode = idx, true
+		}
+	}
+
+	for idx < len(script) {
+		switch c := script[idx]; {
+		case c == '\'' || c == '"' || c == '`':
+			startCode()
+			idx++
+			for idx < len(script) && script[idx] != c {
+				if script[idx] == '\\' {
+					idx++
+				}
+				idx++
+			}
+			idx++
+		case c == '-' && strings.HasPrefix(script[idx:], "--"), c == '#':
+			if end := strings.IndexByte(script[idx:], '\n'); end >= 0 {
+				idx += end + 1
+			} else {
+				idx = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[idx:], "/*"):
+			if end := strings.Index(script[idx+2:], "*/"); end >= 0 {
+				idx += end + 4
+			} else {
+				idx = len(script)
+			}
+		case c == ';':
+			appendStatement(idx)
+			idx++
+		default:
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				startCode()
+			}
+			idx++
+		}
+	}
+
+	appendStatement(len(script))
+	return statements
+}
@@ -0,0 +1,64 @@
+package clickhouse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestExecScript(t *testing.T) {
+	script := `
+-- events of the script test; dropped first
+DROP TABLE IF EXISTS script_events;
+
+/* the ; in strings and comments does not split statements */
+CREATE TABLE script_events (id UInt64, name String DEFAULT 'a;b') ENGINE = MergeTree ORDER BY id;
+INSERT INTO script_events (id, name) VALUES (1, 'it''s;'), (2, 'semi\';colon');
+INSERT INTO script_events (id) VALUES (3);
+`
+	if err := clickhouse.ExecScript(DB, script); err != nil {
+		t.Fatalf("failed to exec script, got error %v", err)
+	}
+
+	var names []string
+	if err := DB.Table("script_events").Order("id").Pluck("name", &names).Error; err != nil {
+		t.Fatalf("failed to query, got error %v", err)
+	}
+	if strings.Join(names, "|") != "it's;|semi';colon|a;b" {
+		t.Errorf("expects names of the script, got %v", names)
+	}
+
+	if err := clickhouse.ExecScript(DB, "SELECT 1; SELECT * FROM not_exists_table"); err == nil || !strings.Contains(err.Error(), "statement 2") {
+		t.Errorf("expects error of statement 2, got %v", err)
+	}
+}
+
+func TestExecScriptOnCluster(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	testDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	script := "CREATE TABLE IF NOT EXISTS db.events (id UInt64) ENGINE = MergeTree ORDER BY id;\n" +
+		"ALTER TABLE events ADD COLUMN name String;\n" +
+		"RENAME TABLE events TO events_old;\n" +
+		"DROP TABLE events_old ON CLUSTER other;\n" +
+		"INSERT INTO events VALUES (1)"
+	if err := clickhouse.ExecScriptOnCluster(testDB, "{cluster}", script); err != nil {
+		t.Fatalf("failed to exec script, got error %v", err)
+	}
+
+	expects := []string{
+		"CREATE TABLE IF NOT EXISTS db.events ON CLUSTER '{cluster}' (id UInt64) ENGINE = MergeTree ORDER BY id",
+		"ALTER TABLE events ON CLUSTER '{cluster}' ADD COLUMN name String",
+		"RENAME TABLE events TO events_old ON CLUSTER '{cluster}'",
+		"DROP TABLE events_old ON CLUSTER other",
+		"INSERT INTO events VALUES (1)",
+	}
+	if statements := mock.SQL(); strings.Join(statements, "\n") != strings.Join(expects, "\n") {
+		t.Errorf("expects statements %v, got %v", expects, statements)
+	}
+}
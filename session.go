@@ -0,0 +1,92 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+// WithSession runs the statements of db in the server side session sessionID when using the HTTP protocol,
+// so SET statements and temporary tables persist across them, e.g.
+//
+//	session := clickhouse.WithSession(db, "", 5*time.Minute)
+//	session.Exec("CREATE TEMPORARY TABLE ids (id UInt64)")
+//	session.Where("id IN (SELECT id FROM ids)").Find(&users)
+//
+// an empty sessionID generates a new one, timeout is the session_timeout, 0 uses the server default.
+// The server rejects concurrent statements of one session, the native protocol rejects the session_id
+// setting, so statements return ErrUnsupportedDriver unless the DSN or Options use the HTTP protocol, use
+// db.Connection instead as the native protocol keeps settings by connection
+func WithSession(db *gorm.DB, sessionID string, timeout time.Duration) *gorm.DB {
+	dialector, err := dialectorOf(db)
+	if err == nil && dialector.options.Protocol != clickhouse.HTTP {
+		err = fmt.Errorf("%w: sessions require the HTTP protocol", gorm.ErrUnsupportedDriver)
+	}
+	if err != nil {
+		tx := db.Session(&gorm.Session{})
+		tx.AddError(err)
+		return tx
+	}
+
+	if sessionID == "" {
+		sessionID = newQueryID()
+	}
+
+	settings := clickhouse.Settings{"session_id": sessionID}
+	if timeout > 0 {
+		settings["session_timeout"] = int64(math.Ceil(timeout.Seconds()))
+	}
+	return WithSettings(db, settings)
+}
+
+// SessionIDFromContext returns the session id applied with WithSession
+func SessionIDFromContext(ctx context.Context) (string, bool) {
+	sessionID, ok := SettingsFromContext(ctx)["session_id"].(string)
+	return sessionID, ok
+}
+
+// WithRoles runs fc on a dedicated connection acting with roles set by SET ROLE, no roles sets ROLE NONE, e.g.
+//
+//	clickhouse.WithRoles(db, []string{"analyst"}, func(tx *gorm.DB) error {
+//		return tx.Find(&reports).Error
+//	})
+//
+// the default roles of the user are restored before the connection returns to the pool,
+// a session is used when using the HTTP protocol
+func WithRoles(db *gorm.DB, roles []string, fc func(tx *gorm.DB) error) error {
+	dialector, err := dialectorOf(db)
+	if err != nil {
+		return err
+	}
+
+	if dialector.options.Protocol == clickhouse.HTTP {
+		db = WithSession(db, "", 0)
+	}
+
+	return db.Connection(func(tx *gorm.DB) (err error) {
+		setRole := "SET ROLE NONE"
+		if len(roles) > 0 {
+			quoted := make([]string, len(roles))
+			for idx, role := range roles {
+				quoted[idx] = tx.Statement.Quote(role)
+			}
+			setRole = "SET ROLE " + strings.Join(quoted, ", ")
+		}
+
+		if err = tx.Exec(setRole).Error; err != nil {
+			return err
+		}
+
+		defer func() {
+			if resetErr := tx.Exec("SET ROLE DEFAULT").Error; err == nil {
+				err = resetErr
+			}
+		}()
+		return fc(tx)
+	})
+}
@@ -0,0 +1,55 @@
+package clickhouse
+
+import (
+	"gorm.io/gorm/clause"
+)
+
+// SetMode mode of a set operation
+type SetMode string
+
+const (
+	Distinct SetMode = "DISTINCT"
+	All      SetMode = "ALL"
+)
+
+// SetOperation combines queries with UNION, INTERSECT or EXCEPT, every query is wrapped
+// in parentheses and its arguments are merged into the outer statement, e.g.
+//
+//	db.Raw("?", clickhouse.Union(db.Model(&A{}).Select("id"), db.Model(&B{}).Select("id"), clickhouse.All)).Scan(&ids)
+//	db.Table("(?) AS u", clickhouse.Except(q1, q2, clickhouse.Distinct)).Count(&count)
+type SetOperation struct {
+	Operator string
+	Mode     SetMode
+	Left     interface{} // *gorm.DB or SetOperation
+	Right    interface{} // *gorm.DB or SetOperation
+}
+
+// Union left UNION mode right
+func Union(left, right interface{}, mode SetMode) SetOperation {
+	return SetOperation{Operator: "UNION", Mode: mode, Left: left, Right: right}
+}
+
+// Intersect left INTERSECT mode right
+func Intersect(left, right interface{}, mode SetMode) SetOperation {
+	return SetOperation{Operator: "INTERSECT", Mode: mode, Left: left, Right: right}
+}
+
+// Except left EXCEPT mode right
+func Except(left, right interface{}, mode SetMode) SetOperation {
+	return SetOperation{Operator: "EXCEPT", Mode: mode, Left: left, Right: right}
+}
+
+// Build implements clause.Expression interface
+func (s SetOperation) Build(builder clause.Builder) {
+	builder.WriteByte('(')
+	builder.AddVar(builder, s.Left)
+	builder.WriteString(") ")
+	builder.WriteString(s.Operator)
+	if s.Mode != "" {
+		builder.WriteByte(' ')
+		builder.WriteString(string(s.Mode))
+	}
+	builder.WriteString(" (")
+	builder.AddVar(builder, s.Right)
+	builder.WriteByte(')')
+}
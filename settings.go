@@ -0,0 +1,128 @@
+package clickhouse
+
+import (
+	"context"
+	"maps"
+	"math"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+)
+
+type settingsCtxKey struct{}
+
+// WithSettings returns a session sending settings with every following statement,
+// settings are merged with the ones applied to db before
+func WithSettings(db *gorm.DB, settings clickhouse.Settings) *gorm.DB {
+	return db.WithContext(settingsContext(db.Statement.Context, settings))
+}
+
+// settingsContext returns a context sending settings merged with the ones applied to ctx before
+func settingsContext(ctx context.Context, settings clickhouse.Settings) context.Context {
+	merged := clickhouse.Settings{}
+	maps.Copy(merged, SettingsFromContext(ctx))
+	maps.Copy(merged, settings)
+
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(merged))
+	return context.WithValue(ctx, settingsCtxKey{}, merged)
+}
+
+// SettingsFromContext returns the settings applied with WithSettings
+func SettingsFromContext(ctx context.Context) clickhouse.Settings {
+	settings, _ := ctx.Value(settingsCtxKey{}).(clickhouse.Settings)
+	return settings
+}
+
+// Limits resource limits of statements, zero values are not applied
+type Limits struct {
+	MaxMemory        int64         // max_memory_usage in bytes
+	MaxExecutionTime time.Duration // max_execution_time, rounded to seconds
+	MaxRowsToRead    uint64        // max_rows_to_read
+	MaxBytesToRead   uint64        // max_bytes_to_read
+	MaxResultRows    uint64        // max_result_rows
+	MaxThreads       uint64        // max_threads
+}
+
+// WithLimits applies limits to the statements of the returned db, statements exceeding them fail instead of
+// exhausting the resources of shared clusters, e.g.
+//
+//	clickhouse.WithLimits(db, clickhouse.Limits{MaxMemory: 1 << 30, MaxRowsToRead: 1e9}).Find(&events)
+func WithLimits(db *gorm.DB, limits Limits) *gorm.DB {
+	settings := clickhouse.Settings{}
+	if limits.MaxMemory > 0 {
+		settings["max_memory_usage"] = limits.MaxMemory
+	}
+	if limits.MaxExecutionTime > 0 {
+		settings["max_execution_time"] = int64(math.Ceil(limits.MaxExecutionTime.Seconds()))
+	}
+	if limits.MaxRowsToRead > 0 {
+		settings["max_rows_to_read"] = limits.MaxRowsToRead
+	}
+	if limits.MaxBytesToRead > 0 {
+		settings["max_bytes_to_read"] = limits.MaxBytesToRead
+	}
+	if limits.MaxResultRows > 0 {
+		settings["max_result_rows"] = limits.MaxResultRows
+	}
+	if limits.MaxThreads > 0 {
+		settings["max_threads"] = limits.MaxThreads
+	}
+	return WithSettings(db, settings)
+}
+
+// QueryPriority scheduling of statements relative to the other queries of the server, zero values are not applied
+type QueryPriority struct {
+	Priority                            uint64        // lower values are higher priorities, 0 means no priority
+	MaxExecutionTime                    time.Duration // max_execution_time, rounded to seconds
+	TimeoutBeforeCheckingExecutionSpeed time.Duration // timeout_before_checking_execution_speed, rounded to seconds
+}
+
+var (
+	// InteractivePriority priority of latency sensitive statements, e.g. dashboard queries
+	InteractivePriority = QueryPriority{Priority: 1}
+	// BackgroundPriority priority of background jobs, paused while higher priority queries are running
+	BackgroundPriority = QueryPriority{Priority: 10}
+)
+
+// Scope applies the priority to the statement, e.g.
+//
+//	db.Scopes(clickhouse.BackgroundPriority.Scope).Find(&events)
+func (priority QueryPriority) Scope(db *gorm.DB) *gorm.DB {
+	settings := clickhouse.Settings{}
+	if priority.Priority > 0 {
+		settings["priority"] = priority.Priority
+	}
+	if priority.MaxExecutionTime > 0 {
+		settings["max_execution_time"] = int64(math.Ceil(priority.MaxExecutionTime.Seconds()))
+	}
+	if priority.TimeoutBeforeCheckingExecutionSpeed > 0 {
+		settings["timeout_before_checking_execution_speed"] = int64(math.Ceil(priority.TimeoutBeforeCheckingExecutionSpeed.Seconds()))
+	}
+	return WithSettings(db, settings)
+}
+
+// InsertQuorum returns a scope waiting until writes to replicated tables are acknowledged by quorum replicas,
+// quorum 0 is the majority of the replicas, parallel allows concurrent quorum inserts, which are not read
+// consistently by SequentialConsistency, e.g.
+//
+//	db.Scopes(clickhouse.InsertQuorum(2, false)).Create(&orders)
+//	db.Scopes(clickhouse.SequentialConsistency).Find(&orders)
+func InsertQuorum(quorum int, parallel bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		settings := clickhouse.Settings{"insert_quorum": "auto", "insert_quorum_parallel": 0}
+		if quorum > 0 {
+			settings["insert_quorum"] = quorum
+		}
+		if parallel {
+			settings["insert_quorum_parallel"] = 1
+		}
+		return WithSettings(db, settings)
+	}
+}
+
+// SequentialConsistency scope reading from replicas having all the writes acknowledged with InsertQuorum,
+// statements fail on replicas lagging behind instead of returning stale rows
+func SequentialConsistency(db *gorm.DB) *gorm.DB {
+	return WithSettings(db, clickhouse.Settings{"select_sequential_consistency": 1})
+}
@@ -0,0 +1,149 @@
+package clickhouse_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestWithLimits(t *testing.T) {
+	limited := clickhouse.WithLimits(DB, clickhouse.Limits{MaxThreads: 2, MaxExecutionTime: 1500 * time.Millisecond, MaxRowsToRead: 100})
+
+	var maxThreads, maxExecutionTime string
+	if err := limited.Raw("SELECT toString(getSetting('max_threads')), toString(getSetting('max_execution_time'))").Row().Scan(&maxThreads, &maxExecutionTime); err != nil {
+		t.Fatalf("failed to get settings, got error %v", err)
+	}
+	if maxThreads != "2" || maxExecutionTime != "2" {
+		t.Errorf("expects limits applied, got max_threads %v, max_execution_time %v", maxThreads, maxExecutionTime)
+	}
+
+	var count uint64
+	if err := limited.Raw("SELECT count() FROM numbers(1000)").Scan(&count).Error; err == nil {
+		t.Errorf("reading more rows than max_rows_to_read should fail")
+	}
+
+	if err := DB.Raw("SELECT count() FROM numbers(1000)").Scan(&count).Error; err != nil || count != 1000 {
+		t.Errorf("limits should not be applied to db, got %v, error %v", count, err)
+	}
+}
+
+func TestQueryPriority(t *testing.T) {
+	priority := clickhouse.QueryPriority{Priority: 5, TimeoutBeforeCheckingExecutionSpeed: 3 * time.Second}
+
+	var values []string
+	if err := DB.Scopes(priority.Scope).Raw("SELECT toString(getSetting('priority')) UNION ALL SELECT toString(getSetting('timeout_before_checking_execution_speed'))").Scan(&values).Error; err != nil {
+		t.Fatalf("failed to get settings, got error %v", err)
+	}
+
+	if len(values) != 2 || !slices.Contains(values, "5") || !slices.Contains(values, "3") {
+		t.Errorf("expects priority settings applied, got %v", values)
+	}
+
+	var count int64
+	if err := DB.Model(&User{}).Scopes(clickhouse.BackgroundPriority.Scope).Count(&count).Error; err != nil {
+		t.Errorf("failed to count with background priority, got error %v", err)
+	}
+}
+
+func TestInsertQuorum(t *testing.T) {
+	var quorum, parallel, consistency string
+	if err := DB.Scopes(clickhouse.InsertQuorum(0, true), clickhouse.SequentialConsistency).Raw(
+		"SELECT toString(getSetting('insert_quorum')), toString(getSetting('insert_quorum_parallel')), toString(getSetting('select_sequential_consistency'))",
+	).Row().Scan(&quorum, &parallel, &consistency); err != nil {
+		t.Fatalf("failed to get settings, got error %v", err)
+	}
+	if quorum != "auto" || parallel != "1" || consistency != "1" {
+		t.Errorf("expects quorum settings applied, got insert_quorum %v, insert_quorum_parallel %v, select_sequential_consistency %v", quorum, parallel, consistency)
+	}
+}
+
+func TestEnableExperimentalSettings(t *testing.T) {
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{EnableExperimentalSettings: true})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var settings []clickhousego.Settings
+	if err := mockDB.Callback().Raw().Replace("gorm:raw", func(db *gorm.DB) {
+		settings = append(settings, clickhouse.SettingsFromContext(db.Statement.Context))
+	}); err != nil {
+		t.Fatalf("no error should happen when registering a callback, but got %v", err)
+	}
+
+	mockDB.Exec("CREATE TABLE events (id UInt64, attrs JSON, INDEX idx_name name TYPE inverted(0) GRANULARITY 1) ENGINE = MergeTree ORDER BY id")
+	mockDB.Exec("CREATE WINDOW VIEW wv AS SELECT count() FROM events GROUP BY tumble(now(), INTERVAL '10' SECOND)")
+	clickhouse.WithSettings(mockDB, clickhousego.Settings{"allow_experimental_json_type": 0}).Exec("ALTER TABLE events ADD COLUMN meta JSON")
+	mockDB.Exec("SELECT toJSONString(map('a', 1))")
+	mockDB.Exec("ALTER TABLE events ADD COLUMN `JSON` String COMMENT 'JSON payload, Variant(String)'")
+	mockDB.Exec("CREATE TABLE objects (id UInt64, data Object('json')) ENGINE = MergeTree ORDER BY id")
+
+	expected := []clickhousego.Settings{
+		{"allow_experimental_json_type": 1, "allow_experimental_inverted_index": 1},
+		{"allow_experimental_window_view": 1},
+		{"allow_experimental_json_type": 0},
+		nil,
+		nil,
+		{"allow_experimental_object_type": 1},
+	}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Errorf("expects settings %v, got %v", expected, settings)
+	}
+}
+
+func TestExperimentalSettingsDisabled(t *testing.T) {
+	type ExperimentalMetric struct {
+		ID    uint64
+		Value float64 `gorm:"statistics:tdigest"`
+		Extra string  `gorm:"type:Variant(String, UInt64)"`
+		Name  string  `gorm:"index:idx_name,type:text(tokenizer = 'ngrams')"`
+	}
+
+	dialector, _ := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var settings []clickhousego.Settings
+	if err := mockDB.Callback().Raw().Replace("gorm:raw", func(db *gorm.DB) {
+		settings = append(settings, clickhouse.SettingsFromContext(db.Statement.Context))
+	}); err != nil {
+		t.Fatalf("no error should happen when registering a callback, but got %v", err)
+	}
+
+	if err := mockDB.Migrator().CreateTable(&ExperimentalMetric{}); err != nil {
+		t.Fatalf("failed to create table, got error %v", err)
+	}
+	if err := mockDB.Migrator().AddColumn(&ExperimentalMetric{}, "Extra"); err != nil {
+		t.Fatalf("failed to add column, got error %v", err)
+	}
+	if err := mockDB.Migrator().CreateIndex(&ExperimentalMetric{}, "idx_name"); err != nil {
+		t.Fatalf("failed to create index, got error %v", err)
+	}
+
+	migrator := mockDB.Migrator().(clickhouse.Migrator)
+	if err := migrator.AddStatistics(&ExperimentalMetric{}, "ID", "minmax"); err != nil {
+		t.Fatalf("failed to add statistics, got error %v", err)
+	}
+	if err := migrator.MaterializeStatistics(&ExperimentalMetric{}, "ID"); err != nil {
+		t.Fatalf("failed to materialize statistics, got error %v", err)
+	}
+	if err := migrator.DropStatistics(&ExperimentalMetric{}, "ID"); err != nil {
+		t.Fatalf("failed to drop statistics, got error %v", err)
+	}
+
+	if len(settings) != 6 {
+		t.Fatalf("expects 6 statements, got %v", settings)
+	}
+	for _, statementSettings := range settings {
+		if len(statementSettings) != 0 {
+			t.Errorf("experimental settings should only be sent with EnableExperimentalSettings, got %v", settings)
+		}
+	}
+}
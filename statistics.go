@@ -0,0 +1,69 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// statisticsSettings settings required to manage column statistics, sent with EnableExperimentalSettings
+var statisticsSettings = clickhouse.Settings{"allow_experimental_statistics": 1}
+
+// hasStatistics reports whether fields declare statistics with the statistics tag, e.g. `gorm:"statistics:tdigest,uniq"`
+func hasStatistics(fields ...*schema.Field) bool {
+	for _, field := range fields {
+		if stats, ok := field.TagSettings["STATISTICS"]; ok && stats != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// statisticsColumns returns the quoted column list of fields
+func statisticsColumns(stmt *gorm.Statement, fields []string) clause.Expr {
+	columns := make([]string, len(fields))
+	for idx, name := range fields {
+		if field := stmt.Schema.LookUpField(name); field != nil {
+			name = field.DBName
+		}
+		columns[idx] = stmt.Quote(name)
+	}
+	return clause.Expr{SQL: strings.Join(columns, ", ")}
+}
+
+// AddStatistics adds statistics of types to the column of field, e.g. tdigest, uniq, minmax, countmin
+func (m Migrator) AddStatistics(value interface{}, field string, types ...string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		clusterOpts := m.extractClusterOption()
+		return WithSettings(m.DB, m.allowExperimental(statisticsSettings)).Exec(
+			fmt.Sprintf("ALTER TABLE ?%s ADD STATISTICS ? TYPE %s", clusterOpts, strings.Join(types, ", ")),
+			clause.Table{Name: stmt.Table}, statisticsColumns(stmt, []string{field}),
+		).Error
+	})
+}
+
+// DropStatistics drops the statistics of the column of field
+func (m Migrator) DropStatistics(value interface{}, field string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		clusterOpts := m.extractClusterOption()
+		return WithSettings(m.DB, m.allowExperimental(statisticsSettings)).Exec(
+			fmt.Sprintf("ALTER TABLE ?%s DROP STATISTICS ?", clusterOpts),
+			clause.Table{Name: stmt.Table}, statisticsColumns(stmt, []string{field}),
+		).Error
+	})
+}
+
+// MaterializeStatistics builds the statistics of fields for data inserted before the statistics were added
+func (m Migrator) MaterializeStatistics(value interface{}, fields ...string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		clusterOpts := m.extractClusterOption()
+		return WithSettings(m.DB, m.allowExperimental(statisticsSettings)).Exec(
+			fmt.Sprintf("ALTER TABLE ?%s MATERIALIZE STATISTICS ?", clusterOpts),
+			clause.Table{Name: stmt.Table}, statisticsColumns(stmt, fields),
+		).Error
+	})
+}
@@ -0,0 +1,140 @@
+package clickhouse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// S3Credentials credentials used by FromS3 to access s3 buckets
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// quoteString quotes s as a string literal, the bytes that aren't printable or part of valid UTF-8 are written as \xHH
+func quoteString(s string) string {
+	var (
+		result strings.Builder
+		valid  = utf8.ValidString(s)
+	)
+	result.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '\\':
+			result.WriteByte('\\')
+			result.WriteByte(c)
+		case c < 0x20 || c == 0x7f || (c >= 0x80 && !valid):
+			fmt.Fprintf(&result, "\\x%02X", c)
+		default:
+			result.WriteByte(c)
+		}
+	}
+	result.WriteByte('\'')
+	return result.String()
+}
+
+// tableFunction builds a table function expression for db.Table, args are quoted as string literals
+func tableFunction(name string, args ...string) string {
+	quoted := make([]string, len(args))
+	for idx, arg := range args {
+		quoted[idx] = quoteString(arg)
+	}
+	return name + "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// formatArgs returns the format and structure arguments of file like table functions, format defaults to auto
+func formatArgs(format Format, structure string) []string {
+	if format == "" {
+		format = "auto"
+	}
+	if structure != "" {
+		return []string{string(format), structure}
+	}
+	return []string{string(format)}
+}
+
+// S3 returns the s3 table function without credentials for db.Table, format and structure are optional, e.g.
+//
+//	db.Table(clickhouse.S3("https://bucket.s3.amazonaws.com/events/*.parquet", clickhouse.FormatParquet, "")).Find(&events)
+func S3(url string, format Format, structure string) string {
+	return tableFunction("s3", append([]string{url}, formatArgs(format, structure)...)...)
+}
+
+// S3WithCredentials returns the s3 table function with credentials for db.Table
+func S3WithCredentials(url string, credentials S3Credentials, format Format, structure string) string {
+	args := []string{url, credentials.AccessKeyID, credentials.SecretAccessKey}
+	if credentials.SessionToken != "" {
+		args = append(args, credentials.SessionToken)
+	}
+	return tableFunction("s3", append(args, formatArgs(format, structure)...)...)
+}
+
+// FromS3 scope to query the s3 table function, credentials are pulled from Config.S3Credentials
+func FromS3(url string, format Format, structure string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if dialector, ok := db.Dialector.(*Dialector); ok && dialector.S3Credentials != nil {
+			return db.Table(S3WithCredentials(url, *dialector.S3Credentials, format, structure))
+		}
+		return db.Table(S3(url, format, structure))
+	}
+}
+
+// NamedCollection returns the table function fn configured with the server side named collection,
+// params override the keys of the collection, e.g.
+//
+//	db.Table(clickhouse.NamedCollection("s3", "lake", map[string]string{"filename": "events/*.parquet"}))
+func NamedCollection(fn string, collection string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{collection}
+	for _, key := range keys {
+		args = append(args, key+" = "+quoteString(params[key]))
+	}
+	return fn + "(" + strings.Join(args, ", ") + ")"
+}
+
+// URL returns the url table function for db.Table
+func URL(url string, format Format, structure string) string {
+	return tableFunction("url", append([]string{url}, formatArgs(format, structure)...)...)
+}
+
+// File returns the file table function for db.Table, path is relative to user_files_path of the server
+func File(path string, format Format, structure string) string {
+	return tableFunction("file", append([]string{path}, formatArgs(format, structure)...)...)
+}
+
+// Remote returns the remote table function for db.Table to query table of another server, e.g.
+//
+//	db.Table(clickhouse.Remote("replica-2:9000", "default", "events")).Count(&count)
+func Remote(addresses string, database string, table string) string {
+	return tableFunction("remote", addresses, database, table)
+}
+
+// RemoteWithCredentials returns the remote table function with credentials for db.Table
+func RemoteWithCredentials(addresses string, database string, table string, user string, password string) string {
+	return tableFunction("remote", addresses, database, table, user, password)
+}
+
+// RemoteSecure returns the remoteSecure table function for db.Table, which connects over TLS
+func RemoteSecure(addresses string, database string, table string, user string, password string) string {
+	return tableFunction("remoteSecure", addresses, database, table, user, password)
+}
+
+// Cluster returns the cluster table function for db.Table to query table on all shards of the cluster
+func Cluster(cluster string, database string, table string) string {
+	return tableFunction("cluster", cluster, database, table)
+}
+
+// ClusterAllReplicas returns the clusterAllReplicas table function for db.Table to query table on all replicas of the cluster
+func ClusterAllReplicas(cluster string, database string, table string) string {
+	return tableFunction("clusterAllReplicas", cluster, database, table)
+}
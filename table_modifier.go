@@ -0,0 +1,142 @@
+package clickhouse
+
+import (
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	finalName    = "FINAL"
+	sampleName   = "SAMPLE"
+	prewhereName = "PREWHERE"
+)
+
+// FinalClause reads the rows of the table merged with FINAL, e.g. the latest versions of ReplacingMergeTree rows
+//
+//	db.Clauses(clickhouse.FinalClause{}).Find(&users)
+type FinalClause struct{}
+
+// Name implements clause.Interface interface
+func (FinalClause) Name() string {
+	return finalName
+}
+
+// Build implements clause.Expression interface
+func (FinalClause) Build(builder clause.Builder) {
+	builder.WriteString("FINAL")
+}
+
+// MergeClause implements clause.Interface interface
+func (f FinalClause) MergeClause(c *clause.Clause) {
+	c.Expression = f
+}
+
+// SampleClause reads a sample of the rows of a table with a SAMPLE BY key, Size up to 1 is the ratio of
+// rows, larger sizes the approximate number of rows, e.g.
+//
+//	db.Clauses(clickhouse.SampleClause{Size: 0.1}).Select("count() * 10").Find(&count)
+type SampleClause struct {
+	Size   float64
+	Offset float64 // ratio of the rows skipped before the sample
+}
+
+// Name implements clause.Interface interface
+func (SampleClause) Name() string {
+	return sampleName
+}
+
+// Build implements clause.Expression interface
+func (s SampleClause) Build(builder clause.Builder) {
+	builder.WriteString("SAMPLE ")
+	builder.WriteString(strconv.FormatFloat(s.Size, 'f', -1, 64))
+	if s.Offset > 0 {
+		builder.WriteString(" OFFSET ")
+		builder.WriteString(strconv.FormatFloat(s.Offset, 'f', -1, 64))
+	}
+}
+
+// MergeClause implements clause.Interface interface
+func (s SampleClause) MergeClause(c *clause.Clause) {
+	c.Expression = s
+}
+
+// PrewhereClause conditions filtering rows before the other columns are read, conditions of multiple
+// clauses are joined with AND, e.g.
+//
+//	db.Clauses(clickhouse.PrewhereClause{Exprs: []clause.Expression{clause.Eq{Column: "event_type", Value: "click"}}})
+type PrewhereClause struct {
+	Exprs []clause.Expression
+}
+
+// Name implements clause.Interface interface
+func (PrewhereClause) Name() string {
+	return prewhereName
+}
+
+// Build implements clause.Expression interface
+func (p PrewhereClause) Build(builder clause.Builder) {
+	builder.WriteString("PREWHERE ")
+	clause.Where{Exprs: p.Exprs}.Build(builder)
+}
+
+// MergeClause implements clause.Interface interface
+func (p PrewhereClause) MergeClause(c *clause.Clause) {
+	if v, ok := c.Expression.(PrewhereClause); ok {
+		p.Exprs = append(append([]clause.Expression{}, v.Exprs...), p.Exprs...)
+	}
+	c.Expression = p
+}
+
+// buildFrom writes FINAL and SAMPLE after the tables and PREWHERE after the joins of the FROM clause when specified
+func buildFrom(c clause.Clause, builder clause.Builder) {
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		if _, ok := c.Expression.(clause.From); ok && hasTableModifiers(stmt) {
+			c.Builder = func(c clause.Clause, _ clause.Builder) {
+				buildFromWithModifiers(stmt, c.Expression.(clause.From))
+			}
+		}
+	}
+	buildWithGlobal(c, builder)
+}
+
+func hasTableModifiers(stmt *gorm.Statement) bool {
+	for _, name := range []string{finalName, sampleName, prewhereName} {
+		if c, ok := stmt.Clauses[name]; ok && c.Expression != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func buildFromWithModifiers(stmt *gorm.Statement, from clause.From) {
+	stmt.WriteString("FROM ")
+	if len(from.Tables) > 0 {
+		for idx, table := range from.Tables {
+			if idx > 0 {
+				stmt.WriteByte(',')
+			}
+			stmt.WriteQuoted(table)
+		}
+	} else {
+		stmt.WriteQuoted(clause.Table{Name: clause.CurrentTable})
+	}
+
+	for _, name := range []string{finalName, sampleName} {
+		if c, ok := stmt.Clauses[name]; ok && c.Expression != nil {
+			stmt.WriteByte(' ')
+			c.Expression.Build(stmt)
+		}
+	}
+
+	for _, join := range from.Joins {
+		stmt.WriteByte(' ')
+		join.Build(stmt)
+	}
+
+	if c, ok := stmt.Clauses[prewhereName]; ok && c.Expression != nil {
+		stmt.WriteByte(' ')
+		c.Expression.Build(stmt)
+	}
+}
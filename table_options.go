@@ -0,0 +1,237 @@
+package clickhouse
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EngineInterface models implementing it are created with the returned engine, e.g. ReplacingMergeTree(version)
+type EngineInterface interface {
+	ClickhouseEngine() string
+}
+
+// PartitionByInterface models implementing it are created with the returned partition key, e.g. toYYYYMM(created_at)
+type PartitionByInterface interface {
+	ClickhousePartitionBy() string
+}
+
+// OrderByInterface models implementing it are created with the returned sorting key, e.g. (tenant_id, created_at)
+type OrderByInterface interface {
+	ClickhouseOrderBy() string
+}
+
+// PrimaryKeyInterface models implementing it are created with the returned primary key, a prefix of the sorting key
+// keeping the primary index small for wide sorting keys, e.g. (tenant_id, created_at) ordered by (tenant_id, created_at, id)
+type PrimaryKeyInterface interface {
+	ClickhousePrimaryKey() string
+}
+
+// SettingsInterface models implementing it are created with the returned table settings
+type SettingsInterface interface {
+	ClickhouseSettings() map[string]any
+}
+
+// TTLInterface models implementing it are created with the returned TTL rules, see TTLToVolume, TTLToDisk, TTLDelete and TTLGroupBy
+type TTLInterface interface {
+	ClickhouseTTL() []string
+}
+
+// StoragePolicyInterface models implementing it are created with the returned storage policy
+type StoragePolicyInterface interface {
+	ClickhouseStoragePolicy() string
+}
+
+// TTLToVolume returns a TTL rule moving parts to volume when expr expires, e.g.
+//
+//	clickhouse.TTLToVolume("created_at + INTERVAL 7 DAY", "cold")
+func TTLToVolume(expr string, volume string) string {
+	return expr + " TO VOLUME " + quoteString(volume)
+}
+
+// TTLToDisk returns a TTL rule moving parts to disk when expr expires
+func TTLToDisk(expr string, disk string) string {
+	return expr + " TO DISK " + quoteString(disk)
+}
+
+// TTLDelete returns a TTL rule deleting rows when expr expires
+func TTLDelete(expr string) string {
+	return expr + " DELETE"
+}
+
+// TTLGroupBy returns a TTL rule rolling up expired rows by keys, a prefix of the sorting key, with set
+// aggregating the other columns, e.g.
+//
+//	clickhouse.TTLGroupBy("toDateTime(day) + INTERVAL 1 MONTH", []string{"site_id"}, map[string]string{"hits": "sum(hits)"})
+func TTLGroupBy(expr string, keys []string, set map[string]string) string {
+	rule := expr + " GROUP BY " + strings.Join(keys, ", ")
+
+	columns := make([]string, 0, len(set))
+	for column := range set {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for idx, column := range columns {
+		if idx == 0 {
+			rule += " SET "
+		} else {
+			rule += ", "
+		}
+		rule += column + " = " + set[column]
+	}
+	return rule
+}
+
+// modelTableOptions builds the table options of the model from the DDL interfaces it implements, models
+// with multiple primaryKey fields are sorted by them by default, returns "" if none of them applies
+func (m Migrator) modelTableOptions(stmt *gorm.Statement) string {
+	if stmt.Schema == nil {
+		return ""
+	}
+
+	model := reflect.New(stmt.Schema.ModelType).Interface()
+	engine, hasEngine := model.(EngineInterface)
+	partitionBy, hasPartitionBy := model.(PartitionByInterface)
+	orderBy, hasOrderBy := model.(OrderByInterface)
+	primaryKey, hasPrimaryKey := model.(PrimaryKeyInterface)
+	settings, hasSettings := model.(SettingsInterface)
+	ttl, hasTTL := model.(TTLInterface)
+	storagePolicy, hasStoragePolicy := model.(StoragePolicyInterface)
+	compositeKey := compositePrimaryKey(stmt)
+	if !hasEngine && !hasPartitionBy && !hasOrderBy && !hasPrimaryKey && !hasSettings && !hasTTL && !hasStoragePolicy && compositeKey == "" {
+		return ""
+	}
+
+	opts := "ENGINE=" + m.Dialector.DefaultEngine
+	if hasEngine {
+		opts = "ENGINE=" + engine.ClickhouseEngine()
+	}
+
+	if hasPartitionBy {
+		opts += " PARTITION BY " + partitionBy.ClickhousePartitionBy()
+	}
+
+	if hasOrderBy {
+		opts += " ORDER BY " + orderBy.ClickhouseOrderBy()
+	} else if compositeKey != "" {
+		opts += " ORDER BY " + compositeKey
+	} else {
+		opts += " ORDER BY " + m.Dialector.DefaultOrderBy
+	}
+
+	if hasPrimaryKey {
+		opts += " PRIMARY KEY " + primaryKey.ClickhousePrimaryKey()
+	}
+
+	if hasTTL {
+		if rules := ttl.ClickhouseTTL(); len(rules) > 0 {
+			opts += " TTL " + strings.Join(rules, ", ")
+		}
+	}
+
+	values := map[string]any{}
+	if hasSettings {
+		maps.Copy(values, settings.ClickhouseSettings())
+	}
+	if hasStoragePolicy {
+		values["storage_policy"] = storagePolicy.ClickhouseStoragePolicy()
+	}
+
+	if len(values) > 0 {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, len(keys))
+		for idx, key := range keys {
+			pairs[idx] = key + " = " + m.Dialector.Explain("?", values[key])
+		}
+		opts += " SETTINGS " + strings.Join(pairs, ", ")
+	}
+	return opts
+}
+
+// tableEngineOpts returns the table options of the statement without ON CLUSTER, gorm:table_options takes
+// precedence over the DDL interfaces of the model and DefaultTableEngineOpts
+func (m Migrator) tableEngineOpts(stmt *gorm.Statement) (clusterOpts, engineOpts string) {
+	if tableOption, ok := m.DB.Get("gorm:table_options"); ok {
+		return isolateClusterOption(fmt.Sprint(tableOption))
+	}
+	if opts := m.modelTableOptions(stmt); opts != "" {
+		return "", opts
+	}
+	return "", m.Dialector.DefaultTableEngineOpts
+}
+
+// immutableTableSettings table settings that can only be set when creating the table
+var immutableTableSettings = map[string]bool{"index_granularity": true}
+
+var tableSettingsRegexp = regexp.MustCompile(`(?s)\sSETTINGS\s+(.+)$`)
+
+// tableSettings parses the SETTINGS clause of the full engine of a table
+func tableSettings(engineFull string) map[string]string {
+	settings := map[string]string{}
+	if matches := tableSettingsRegexp.FindStringSubmatch(engineFull); len(matches) > 1 {
+		for _, pair := range splitTypeArgs(matches[1]) {
+			if key, value, ok := strings.Cut(pair, "="); ok {
+				settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return settings
+}
+
+// reconcileTableSettings modifies the settings of existing tables that differ from the settings of their
+// models implementing SettingsInterface, settings not returned by the model are left unchanged
+func (m Migrator) reconcileTableSettings(values ...interface{}) error {
+	for _, value := range values {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			if stmt.Schema == nil {
+				return nil
+			}
+
+			model, ok := reflect.New(stmt.Schema.ModelType).Interface().(SettingsInterface)
+			if !ok {
+				return nil
+			}
+
+			var engineFull string
+			if err := m.DB.Raw(
+				"SELECT engine_full FROM system.tables WHERE database = ? AND name = ?",
+				m.CurrentDatabase(), stmt.Table,
+			).Row().Scan(&engineFull); err != nil {
+				return err
+			}
+
+			actual := tableSettings(engineFull)
+			var pairs []string
+			for key, value := range model.ClickhouseSettings() {
+				if expected := m.Dialector.Explain("?", value); !immutableTableSettings[key] && actual[key] != expected {
+					pairs = append(pairs, key+" = "+expected)
+				}
+			}
+
+			if len(pairs) == 0 {
+				return nil
+			}
+
+			sort.Strings(pairs)
+			return m.DB.Exec(
+				fmt.Sprintf("ALTER TABLE ?%s MODIFY SETTING %s", m.extractClusterOption(), strings.Join(pairs, ", ")),
+				clause.Table{Name: stmt.Table},
+			).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
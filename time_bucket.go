@@ -0,0 +1,83 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// startOfFunctions functions rounding down to common intervals, cheaper than toStartOfInterval
+var startOfFunctions = map[IntervalExpr]string{
+	{Value: 1, Unit: IntervalMinute}:  "toStartOfMinute",
+	{Value: 5, Unit: IntervalMinute}:  "toStartOfFiveMinutes",
+	{Value: 10, Unit: IntervalMinute}: "toStartOfTenMinutes",
+	{Value: 15, Unit: IntervalMinute}: "toStartOfFifteenMinutes",
+	{Value: 1, Unit: IntervalHour}:    "toStartOfHour",
+	{Value: 1, Unit: IntervalDay}:     "toStartOfDay",
+	{Value: 1, Unit: IntervalMonth}:   "toStartOfMonth",
+	{Value: 1, Unit: IntervalQuarter}: "toStartOfQuarter",
+	{Value: 1, Unit: IntervalYear}:    "toStartOfYear",
+}
+
+// TimeBucketExpr the start of the interval a time column falls into
+type TimeBucketExpr struct {
+	Column   string
+	Interval IntervalExpr
+	Alias    string
+	err      error
+}
+
+// TimeBucket rounds the time column down to the start of interval, e.g. "15 minute" or "1 day", with
+// toStartOfInterval or the cheaper toStartOfHour, toStartOfDay... of common intervals, used as a var of
+// Select and Order, Group by the alias or String of the expression
+//
+//	db.Model(&Event{}).Select("?, count() AS events", clickhouse.TimeBucket("created_at", "15 minute").As("bucket")).
+//		Group("bucket").Order("bucket").Find(&series)
+func TimeBucket(column string, interval string) TimeBucketExpr {
+	bucket := TimeBucketExpr{Column: column}
+	if err := bucket.Interval.Scan(interval); err != nil {
+		bucket.err = err
+	} else if bucket.Interval.Value <= 0 {
+		bucket.err = fmt.Errorf("%w: invalid interval %q", gorm.ErrInvalidData, interval)
+	}
+	return bucket
+}
+
+// As sets the alias of the bucket in Select
+func (b TimeBucketExpr) As(alias string) TimeBucketExpr {
+	b.Alias = alias
+	return b
+}
+
+// String returns the expression without the alias, e.g. toStartOfInterval(`created_at`, INTERVAL 30 MINUTE)
+func (b TimeBucketExpr) String() string {
+	var sql strings.Builder
+	if fn, ok := startOfFunctions[b.Interval]; ok {
+		sql.WriteString(fn)
+		sql.WriteByte('(')
+		Dialector{}.QuoteTo(&sql, b.Column)
+	} else {
+		sql.WriteString("toStartOfInterval(")
+		Dialector{}.QuoteTo(&sql, b.Column)
+		sql.WriteString(", ")
+		sql.WriteString(b.Interval.String())
+	}
+	sql.WriteByte(')')
+	return sql.String()
+}
+
+// Build implements clause.Expression interface
+func (b TimeBucketExpr) Build(builder clause.Builder) {
+	if b.err != nil {
+		builder.AddError(b.err)
+		return
+	}
+
+	builder.WriteString(b.String())
+	if b.Alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteQuoted(b.Alias)
+	}
+}
@@ -0,0 +1,189 @@
+package clickhouse
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// TimeZonePolicy the location time.Time values are converted to, see Config.InsertTimeZone and Config.ScanTimeZone
+type TimeZonePolicy string
+
+const (
+	TimeZoneUTC    TimeZonePolicy = "UTC"    // UTC
+	TimeZoneServer TimeZonePolicy = "server" // timezone of the server, detected on Initialize, UTC if skipped
+	TimeZoneColumn TimeZonePolicy = "column" // timezone of the column, e.g. DateTime('Asia/Shanghai'), the server timezone for columns without one
+)
+
+var (
+	columnTimeZoneRegexp = regexp.MustCompile(`DateTime(?:64)?\((?:\s*\d+\s*,)?\s*'([^']+)'\s*\)`)
+	columnLocations      sync.Map
+)
+
+// columnLocation returns the location of the timezone of the column type, nil if it has none
+func columnLocation(dataType string) *time.Location {
+	matches := columnTimeZoneRegexp.FindStringSubmatch(dataType)
+	if len(matches) < 2 {
+		return nil
+	}
+
+	if loc, ok := columnLocations.Load(matches[1]); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(matches[1])
+	if err != nil {
+		return nil
+	}
+	columnLocations.Store(matches[1], loc)
+	return loc
+}
+
+// locationOf returns the location of the policy for values of field, nil keeps values as they are
+func (dialector *Dialector) locationOf(policy TimeZonePolicy, field *schema.Field) *time.Location {
+	switch policy {
+	case TimeZoneUTC:
+		return time.UTC
+	case TimeZoneColumn:
+		if field != nil {
+			dataType := field.TagSettings["TYPE"]
+			if dataType == "" {
+				dataType = dialector.DataTypeOf(field)
+			}
+			if loc := columnLocation(dataType); loc != nil {
+				return loc
+			}
+		}
+		fallthrough
+	case TimeZoneServer:
+		if dialector.serverLocation != nil {
+			return dialector.serverLocation
+		}
+		return time.UTC
+	}
+	return nil
+}
+
+// localizeTime returns value converted to loc if it is a time.Time or *time.Time
+func localizeTime(value interface{}, loc *time.Location) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		if !v.IsZero() {
+			return v.In(loc)
+		}
+	case *time.Time:
+		if v != nil && !v.IsZero() {
+			t := v.In(loc)
+			return &t
+		}
+	}
+	return value
+}
+
+// localizeValues converts the time.Time values of inserted rows to the location of InsertTimeZone, the text
+// formats of the HTTP interface and DryRun SQL write them with the wall clock the server parses
+func (dialector *Dialector) localizeValues(stmt *gorm.Statement, values clause.Values) {
+	if dialector.InsertTimeZone == "" {
+		return
+	}
+
+	for idx, column := range values.Columns {
+		var field *schema.Field
+		if stmt.Schema != nil {
+			field = stmt.Schema.LookUpField(column.Name)
+		}
+
+		loc := dialector.locationOf(dialector.InsertTimeZone, field)
+		for _, row := range values.Values {
+			row[idx] = localizeTime(row[idx], loc)
+		}
+	}
+}
+
+// localizeAssignments converts the time.Time values of updated columns to the location of InsertTimeZone
+func (dialector *Dialector) localizeAssignments(stmt *gorm.Statement, set clause.Set) {
+	if dialector.InsertTimeZone == "" {
+		return
+	}
+
+	for idx, assignment := range set {
+		var field *schema.Field
+		if stmt.Schema != nil {
+			field = stmt.Schema.LookUpField(assignment.Column.Name)
+		}
+		set[idx].Value = localizeTime(assignment.Value, dialector.locationOf(dialector.InsertTimeZone, field))
+	}
+}
+
+// localizeScanned converts the time.Time fields of the query results to the location of ScanTimeZone,
+// TimeZoneColumn is how clickhouse-go scans DateTime values already
+func (dialector *Dialector) localizeScanned(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || dialector.ScanTimeZone == "" || dialector.ScanTimeZone == TimeZoneColumn {
+		return
+	}
+
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	if !rv.IsValid() {
+		return
+	}
+	modelType := rv.Type()
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		modelType = modelType.Elem()
+	}
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return
+	}
+
+	// the results are scanned into another struct than the model, e.g. db.Model(&Event{}).Find(&summaries)
+	modelSchema := db.Statement.Schema
+	if modelType != modelSchema.ModelType {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(reflect.New(modelType).Interface()); err != nil {
+			return
+		}
+		modelSchema = stmt.Schema
+	}
+
+	var fields []*schema.Field
+	for _, field := range modelSchema.Fields {
+		if field.GORMDataType == schema.Time && field.Readable {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	localize := func(rv reflect.Value) {
+		for _, field := range fields {
+			value, isZero := field.ValueOf(db.Statement.Context, rv)
+			if isZero {
+				continue
+			}
+			db.AddError(field.Set(db.Statement.Context, rv, localizeTime(value, dialector.locationOf(dialector.ScanTimeZone, field))))
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				localize(elem)
+			}
+		}
+	case reflect.Struct:
+		localize(rv)
+	}
+}
+
+func (dialector *Dialector) registerTimeZoneCallbacks(db *gorm.DB) {
+	db.Callback().Query().After("gorm:query").Register("clickhouse:time_zone", dialector.localizeScanned)
+}
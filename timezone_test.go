@@ -0,0 +1,83 @@
+package clickhouse_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"gorm.io/gorm"
+)
+
+func TestTimeZonePolicy(t *testing.T) {
+	tzDB := newTestDB(t, clickhouse.Config{
+		InsertTimeZone: clickhouse.TimeZoneColumn,
+		ScanTimeZone:   clickhouse.TimeZoneUTC,
+	})
+
+	type TimeZoneEvent struct {
+		ID        uint64
+		CreatedAt time.Time `gorm:"type:DateTime('Asia/Tokyo')"`
+	}
+
+	tzDB.Migrator().DropTable(&TimeZoneEvent{})
+	if err := tzDB.AutoMigrate(&TimeZoneEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	event := TimeZoneEvent{ID: 1, CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	sql := tzDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Create(&event)
+	})
+	if !strings.Contains(sql, "'2024-01-01 21:00:00'") {
+		t.Errorf("created_at should be written in the timezone of the column, got %v", sql)
+	}
+
+	if err := tzDB.Create(&event).Error; err != nil {
+		t.Fatalf("failed to create, got error %v", err)
+	}
+
+	var result TimeZoneEvent
+	if err := tzDB.First(&result, event.ID).Error; err != nil {
+		t.Fatalf("failed to query, got error %v", err)
+	}
+	if !result.CreatedAt.Equal(event.CreatedAt) || result.CreatedAt.Location() != time.UTC {
+		t.Errorf("created_at should be %v in UTC, got %v", event.CreatedAt, result.CreatedAt)
+	}
+}
+
+func TestScanTimeZoneOfDest(t *testing.T) {
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{ScanTimeZone: clickhouse.TimeZoneUTC})
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	type ScanTimeZoneEvent struct {
+		ID        uint64
+		Name      string
+		CreatedAt time.Time
+	}
+	type ScanTimeZoneSummary struct {
+		Day time.Time
+	}
+
+	day := time.Date(2024, 1, 2, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	mock.Returns(`FROM .scan_time_zone_events.`, []string{"day"}, []interface{}{day})
+
+	var summaries []ScanTimeZoneSummary
+	if err := db.Model(&ScanTimeZoneEvent{}).Select("toDate(created_at) AS day").Find(&summaries).Error; err != nil {
+		t.Fatalf("failed to query, got error %v", err)
+	}
+	if len(summaries) != 1 || !summaries[0].Day.Equal(day) || summaries[0].Day.Location() != time.UTC {
+		t.Errorf("day should be %v in UTC, got %+v", day, summaries)
+	}
+
+	var summary ScanTimeZoneSummary
+	if err := db.Model(&ScanTimeZoneEvent{}).Select("toDate(created_at) AS day").Take(&summary).Error; err != nil {
+		t.Fatalf("failed to query, got error %v", err)
+	}
+	if !summary.Day.Equal(day) || summary.Day.Location() != time.UTC {
+		t.Errorf("day should be %v in UTC, got %+v", day, summary)
+	}
+}
@@ -0,0 +1,87 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracerName = "github.com/hardwk/gorm-driver-clickhouse"
+
+type spanCtxKey struct{}
+
+func (dialector *Dialector) registerTracingCallbacks(db *gorm.DB) {
+	if dialector.TracerProvider == nil {
+		return
+	}
+
+	tracer := dialector.TracerProvider.Tracer(tracerName)
+	db.Callback().Create().Before("gorm:begin_transaction").Register("clickhouse:start_span", dialector.startSpan(tracer, "create"))
+	db.Callback().Create().After("gorm:commit_or_rollback_transaction").Register("clickhouse:end_span", dialector.endSpan)
+	db.Callback().Update().Before("gorm:begin_transaction").Register("clickhouse:start_span", dialector.startSpan(tracer, "update"))
+	db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register("clickhouse:end_span", dialector.endSpan)
+	db.Callback().Delete().Before("gorm:begin_transaction").Register("clickhouse:start_span", dialector.startSpan(tracer, "delete"))
+	db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register("clickhouse:end_span", dialector.endSpan)
+	db.Callback().Query().Before("gorm:query").Register("clickhouse:start_span", dialector.startSpan(tracer, "query"))
+	db.Callback().Query().After("gorm:after_query").Register("clickhouse:end_span", dialector.endSpan)
+	db.Callback().Row().Before("gorm:row").Register("clickhouse:start_span", dialector.startSpan(tracer, "row"))
+	db.Callback().Row().After("gorm:row").Register("clickhouse:end_span", dialector.endSpan)
+	db.Callback().Raw().Before("gorm:raw").Register("clickhouse:start_span", dialector.startSpan(tracer, "raw"))
+	db.Callback().Raw().After("gorm:raw").Register("clickhouse:end_span", dialector.endSpan)
+}
+
+// startSpan starts a span for the statement and propagates it to the server
+func (dialector *Dialector) startSpan(tracer trace.Tracer, operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := tracer.Start(db.Statement.Context, "clickhouse."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "clickhouse"),
+				attribute.String("db.operation", operation),
+			),
+		)
+		ctx = clickhouse.Context(ctx, clickhouse.WithSpan(span.SpanContext()))
+		db.Statement.Context = context.WithValue(ctx, spanCtxKey{}, span)
+	}
+}
+
+// endSpan enriches the span with the statement and server side statistics, then ends it
+func (dialector *Dialector) endSpan(db *gorm.DB) {
+	span, ok := db.Statement.Context.Value(spanCtxKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.statement", db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)),
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+
+	if cluster, ok := db.Get("gorm:table_cluster_options"); ok {
+		span.SetAttributes(attribute.String("clickhouse.cluster", fmt.Sprint(cluster)))
+	}
+
+	if stats, ok := QueryStatsFromContext(db.Statement.Context); ok {
+		snapshot := stats.Snapshot()
+		span.SetAttributes(
+			attribute.String("clickhouse.query_id", snapshot.QueryID),
+			attribute.Int64("clickhouse.read_rows", int64(snapshot.ReadRows)),
+			attribute.Int64("clickhouse.read_bytes", int64(snapshot.ReadBytes)),
+			attribute.Int64("clickhouse.written_rows", int64(snapshot.WrittenRows)),
+			attribute.Int64("clickhouse.written_bytes", int64(snapshot.WrittenBytes)),
+			attribute.Int64("clickhouse.peak_memory", snapshot.PeakMemory),
+		)
+	}
+
+	if db.Error != nil {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+	span.End()
+}
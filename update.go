@@ -56,6 +56,7 @@ func (dialector *Dialector) Update(db *gorm.DB) {
 		db.Statement.AddClauseIfNotExists(clause.Update{})
 		if _, ok := db.Statement.Clauses["SET"]; !ok {
 			if set := callbacks.ConvertToAssignments(db.Statement); len(set) != 0 {
+				dialector.localizeAssignments(db.Statement, set)
 				defer delete(db.Statement.Clauses, "SET")
 				db.Statement.AddClause(set)
 			} else {
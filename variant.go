@@ -0,0 +1,120 @@
+package clickhouse
+
+import (
+	"database/sql/driver"
+	"regexp"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/chcol"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// variantSettings settings required to create Variant and Dynamic columns before they became generally available
+var variantSettings = clickhouse.Settings{"allow_experimental_variant_type": 1, "allow_experimental_dynamic_type": 1}
+
+var variantTypeRegexp = regexp.MustCompile(`(?i)\b(?:Variant\s*\(|Dynamic\b)`)
+
+// Variant value of a Variant(T1, T2, ...) column tagged with the ClickHouse type of the value, Any returns the
+// value and Type its type, e.g. a field `gorm:"type:Variant(String, UInt64)"`, fields without a type are
+// created as Dynamic columns
+type Variant struct {
+	chcol.Variant
+}
+
+// NewVariant returns a Variant of v, the type is inferred from the Go type of v when inserted
+func NewVariant(v any) Variant {
+	return Variant{Variant: chcol.NewVariant(v)}
+}
+
+// NewVariantWithType returns a Variant of v inserted as the ClickHouse type chType, e.g. UInt64
+func NewVariantWithType(v any, chType string) Variant {
+	return Variant{Variant: chcol.NewVariantWithType(v, chType)}
+}
+
+// GormDataType implements schema.GormDataTypeInterface interface
+func (Variant) GormDataType() string {
+	return "variant"
+}
+
+// Value implements driver.Valuer interface, returns the value to bind it in statements, inserts keep the type
+func (v Variant) Value() (driver.Value, error) {
+	return v.Any(), nil
+}
+
+// Dynamic value of a Dynamic column tagged with the ClickHouse type of the value like Variant
+type Dynamic struct {
+	chcol.Dynamic
+}
+
+// NewDynamic returns a Dynamic of v, the type is inferred from the Go type of v when inserted
+func NewDynamic(v any) Dynamic {
+	return Dynamic{Dynamic: chcol.NewDynamic(v)}
+}
+
+// NewDynamicWithType returns a Dynamic of v inserted as the ClickHouse type chType
+func NewDynamicWithType(v any, chType string) Dynamic {
+	return Dynamic{Dynamic: chcol.NewDynamicWithType(v, chType)}
+}
+
+// GormDataType implements schema.GormDataTypeInterface interface
+func (Dynamic) GormDataType() string {
+	return "dynamic"
+}
+
+// Value implements driver.Valuer interface, returns the value to bind it in statements, inserts keep the type
+func (v Dynamic) Value() (driver.Value, error) {
+	return v.Any(), nil
+}
+
+// hasVariant reports whether the columns of fields are Variant or Dynamic columns
+func (dialector Dialector) hasVariant(fields ...*schema.Field) bool {
+	for _, field := range fields {
+		if variantTypeRegexp.MatchString(dialector.DataTypeOf(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// variantValues replaces the Variant and Dynamic values of inserted rows with the values of clickhouse-go,
+// which keep the type of the value unlike the bound values
+func variantValues(values clause.Values) {
+	for _, row := range values.Values {
+		for idx, value := range row {
+			switch v := value.(type) {
+			case Variant:
+				row[idx] = v.Variant
+			case *Variant:
+				if v != nil {
+					row[idx] = v.Variant
+				}
+			case Dynamic:
+				row[idx] = v.Dynamic
+			case *Dynamic:
+				if v != nil {
+					row[idx] = v.Dynamic
+				}
+			}
+		}
+	}
+}
+
+// explainVars replaces the clickhouse-go Variant values of vars with their values, as their Value method
+// returns themselves
+func explainVars(vars []interface{}) []interface{} {
+	var explained []interface{}
+	for idx, value := range vars {
+		if v, ok := value.(chcol.Variant); ok {
+			if explained == nil {
+				explained = append([]interface{}(nil), vars...)
+			}
+			explained[idx] = v.Any()
+		}
+	}
+
+	if explained == nil {
+		return vars
+	}
+	return explained
+}
@@ -0,0 +1,47 @@
+package clickhouse_test
+
+import (
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestVariant(t *testing.T) {
+	type VariantEvent struct {
+		ID      uint64
+		Value   clickhouse.Variant `gorm:"type:Variant(String, UInt64)"`
+		Payload clickhouse.Dynamic
+	}
+
+	DB.Migrator().DropTable(&VariantEvent{})
+	if err := DB.AutoMigrate(&VariantEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	events := []VariantEvent{
+		{ID: 1, Value: clickhouse.NewVariant("click"), Payload: clickhouse.NewDynamicWithType(int64(42), "Int64")},
+		{ID: 2, Value: clickhouse.NewVariantWithType(uint64(7), "UInt64"), Payload: clickhouse.NewDynamic("text")},
+		{ID: 3},
+	}
+	if err := DB.Create(&events).Error; err != nil {
+		t.Fatalf("failed to create events, got error %v", err)
+	}
+
+	var results []VariantEvent
+	if err := DB.Order("id").Find(&results).Error; err != nil {
+		t.Fatalf("failed to find events, got error %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expects 3 events, got %+v", results)
+	}
+	if results[0].Value.Type() != "String" || results[0].Value.Any() != "click" || results[0].Payload.Type() != "Int64" || results[0].Payload.Any() != int64(42) {
+		t.Errorf("expects typed values of event 1, got %+v", results[0])
+	}
+	if results[1].Value.Type() != "UInt64" || results[1].Value.Any() != uint64(7) || results[1].Payload.Any() != "text" {
+		t.Errorf("expects typed values of event 2, got %+v", results[1])
+	}
+	if !results[2].Value.Nil() || !results[2].Payload.Nil() {
+		t.Errorf("expects NULL values of event 3, got %+v", results[2])
+	}
+}
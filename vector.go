@@ -0,0 +1,43 @@
+package clickhouse
+
+import (
+	"database/sql/driver"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Distance distance function between two vectors
+type Distance string
+
+const (
+	CosineDistance Distance = "cosineDistance"
+	L2Distance     Distance = "L2Distance"
+	L1Distance     Distance = "L1Distance"
+	DotProduct     Distance = "dotProduct"
+)
+
+// Vector binds a vector as an array literal instead of expanding it into a tuple
+type Vector []float32
+
+// Value implements driver.Valuer, the clickhouse driver binds float slices as arrays
+func (v Vector) Value() (driver.Value, error) {
+	return []float32(v), nil
+}
+
+// DistanceTo returns the distance expression between the vector column and vector, e.g.
+//
+//	db.Select("id, ? AS distance", clickhouse.DistanceTo("embedding", vector, clickhouse.CosineDistance))
+func DistanceTo(column string, vector []float32, distance Distance) clause.Expr {
+	return clause.Expr{SQL: string(distance) + "(?, ?)", Vars: []interface{}{clause.Column{Name: column}, Vector(vector)}}
+}
+
+// NearestNeighbors scope to query the k rows with the vector column closest to vector,
+// a vector_similarity index on the column is used when the distance function matches, e.g.
+//
+//	db.Scopes(clickhouse.NearestNeighbors("embedding", vector, 10, clickhouse.CosineDistance)).Find(&documents)
+func NearestNeighbors(column string, vector []float32, k int, distance Distance) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clause.OrderBy{Expression: DistanceTo(column, vector, distance)}).Limit(k)
+	}
+}
@@ -0,0 +1,46 @@
+package clickhouse_test
+
+import (
+	"testing"
+
+	"github.com/hardwk/gorm-driver-clickhouse"
+)
+
+func TestNearestNeighbors(t *testing.T) {
+	type Document struct {
+		ID        uint64
+		Embedding []float32 `gorm:"type:Array(Float32)"`
+	}
+
+	DB.Migrator().DropTable(&Document{})
+	if err := DB.AutoMigrate(&Document{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	documents := []Document{{ID: 1, Embedding: []float32{1, 0}}, {ID: 2, Embedding: []float32{0, 1}}, {ID: 3, Embedding: []float32{0.9, 0.1}}}
+	if err := DB.Create(&documents).Error; err != nil {
+		t.Fatalf("failed to create documents, got error %v", err)
+	}
+
+	var results []Document
+	if err := DB.Scopes(clickhouse.NearestNeighbors("embedding", []float32{1, 0.05}, 2, clickhouse.CosineDistance)).Find(&results).Error; err != nil {
+		t.Fatalf("failed to query nearest neighbors, got error %v", err)
+	}
+
+	if len(results) != 2 || results[0].ID != 1 || results[1].ID != 3 {
+		t.Errorf("expects documents 1 and 3, got %+v", results)
+	}
+
+	type result struct {
+		ID       uint64
+		Distance float64
+	}
+	var distances []result
+	if err := DB.Model(&Document{}).Select("id, ? AS distance", clickhouse.DistanceTo("embedding", []float32{0, 1}, clickhouse.L2Distance)).Order("id").Find(&distances).Error; err != nil {
+		t.Fatalf("failed to query distance, got error %v", err)
+	}
+
+	if len(distances) != 3 || distances[1].Distance != 0 {
+		t.Errorf("distance of document 2 should be 0, got %+v", distances)
+	}
+}
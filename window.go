@@ -0,0 +1,148 @@
+package clickhouse
+
+import (
+	"gorm.io/gorm/clause"
+)
+
+// Common window frames
+const (
+	FrameRunning = "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW"
+	FrameAll     = "ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING"
+)
+
+// Window window definition of an OVER clause
+type Window struct {
+	PartitionBy []clause.Column
+	OrderBy     []clause.OrderByColumn
+	Frame       string
+}
+
+// Over builds a window, e.g.
+//
+//	clickhouse.Over([]string{"user_id"}, []clause.OrderByColumn{{Column: clause.Column{Name: "ts"}}}, clickhouse.FrameRunning)
+func Over(partitionBy []string, orderBy []clause.OrderByColumn, frame string) Window {
+	window := Window{OrderBy: orderBy, Frame: frame}
+	for _, name := range partitionBy {
+		window.PartitionBy = append(window.PartitionBy, clause.Column{Name: name})
+	}
+	return window
+}
+
+// Build implements clause.Expression interface
+func (w Window) Build(builder clause.Builder) {
+	builder.WriteByte('(')
+	if len(w.PartitionBy) > 0 {
+		builder.WriteString("PARTITION BY ")
+		for idx, column := range w.PartitionBy {
+			if idx > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteQuoted(column)
+		}
+	}
+
+	if len(w.OrderBy) > 0 {
+		if len(w.PartitionBy) > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString("ORDER BY ")
+		clause.OrderBy{Columns: w.OrderBy}.Build(builder)
+	}
+
+	if w.Frame != "" {
+		if len(w.PartitionBy) > 0 || len(w.OrderBy) > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(w.Frame)
+	}
+	builder.WriteByte(')')
+}
+
+// WindowFunc a window function call, e.g. sum(`amount`) OVER (PARTITION BY `user_id` ORDER BY `ts`)
+type WindowFunc struct {
+	Func   string
+	Args   []interface{}
+	Window Window
+	Alias  string
+}
+
+// As sets the alias of the window function result
+func (f WindowFunc) As(alias string) WindowFunc {
+	f.Alias = alias
+	return f
+}
+
+// Build implements clause.Expression interface
+func (f WindowFunc) Build(builder clause.Builder) {
+	builder.WriteString(f.Func)
+	builder.WriteByte('(')
+	for idx, arg := range f.Args {
+		if idx > 0 {
+			builder.WriteByte(',')
+		}
+
+		switch v := arg.(type) {
+		case clause.Column, clause.Table:
+			builder.WriteQuoted(v)
+		default:
+			builder.AddVar(builder, v)
+		}
+	}
+	builder.WriteString(") OVER ")
+	f.Window.Build(builder)
+
+	if f.Alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteQuoted(f.Alias)
+	}
+}
+
+// RowNumber row_number() OVER window
+func RowNumber(window Window) WindowFunc {
+	return WindowFunc{Func: "row_number", Window: window}
+}
+
+// Rank rank() OVER window
+func Rank(window Window) WindowFunc {
+	return WindowFunc{Func: "rank", Window: window}
+}
+
+// DenseRank dense_rank() OVER window
+func DenseRank(window Window) WindowFunc {
+	return WindowFunc{Func: "dense_rank", Window: window}
+}
+
+// Sum sum(column) OVER window, e.g. a running total with FrameRunning
+func Sum(column string, window Window) WindowFunc {
+	return WindowFunc{Func: "sum", Args: []interface{}{clause.Column{Name: column}}, Window: window}
+}
+
+// Avg avg(column) OVER window
+func Avg(column string, window Window) WindowFunc {
+	return WindowFunc{Func: "avg", Args: []interface{}{clause.Column{Name: column}}, Window: window}
+}
+
+// Count count() OVER window
+func Count(window Window) WindowFunc {
+	return WindowFunc{Func: "count", Window: window}
+}
+
+// Lag lagInFrame(column, offset) OVER window
+func Lag(column string, offset int, window Window) WindowFunc {
+	return WindowFunc{Func: "lagInFrame", Args: []interface{}{clause.Column{Name: column}, offset}, Window: window}
+}
+
+// Lead leadInFrame(column, offset) OVER window
+func Lead(column string, offset int, window Window) WindowFunc {
+	return WindowFunc{Func: "leadInFrame", Args: []interface{}{clause.Column{Name: column}, offset}, Window: window}
+}
+
+// FirstValue first_value(column) OVER window
+func FirstValue(column string, window Window) WindowFunc {
+	return WindowFunc{Func: "first_value", Args: []interface{}{clause.Column{Name: column}}, Window: window}
+}
+
+// LastValue last_value(column) OVER window
+func LastValue(column string, window Window) WindowFunc {
+	return WindowFunc{Func: "last_value", Args: []interface{}{clause.Column{Name: column}}, Window: window}
+}
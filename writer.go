@@ -0,0 +1,219 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWriterClosed = errors.New("writer is closed")
+	ErrWriterFull   = errors.New("writer buffer is full")
+)
+
+// WriterConfig buffering and retry options of Writer
+type WriterConfig struct {
+	MaxRows        int                               // flush when this many rows are buffered, also the max rows of a batch, defaults to 10000
+	MaxBytes       int                               // flush when buffered rows roughly reach this size in bytes, 0 disables
+	FlushInterval  time.Duration                     // flush buffered rows at least this often, defaults to 1s
+	MaxPendingRows int                               // Append returns ErrWriterFull when this many rows wait for a flush, defaults to 10 times MaxRows, negative is unlimited
+	Retry          *RetryPolicy                      // retry failed batches, defaults to 3 attempts of any retryable error
+	OnError        func(rows interface{}, err error) // called when a flush fails, rows is the dropped []T batch when err isn't retryable, nil when the rows are kept and flushed again
+}
+
+func (c WriterConfig) withDefaults() WriterConfig {
+	if c.MaxRows <= 0 {
+		c.MaxRows = 10000
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	if c.MaxPendingRows == 0 {
+		c.MaxPendingRows = 10 * c.MaxRows
+	}
+
+	retry := RetryPolicy{}
+	if c.Retry != nil {
+		retry = *c.Retry
+	}
+	retry = retry.withDefaults()
+	c.Retry = &retry
+	return c
+}
+
+// Writer buffers rows appended by any goroutine and inserts them in the background in batches of the
+// model T, flushing when MaxRows or MaxBytes is reached and every FlushInterval. Batches failing with a
+// retryable error are kept buffered until inserted, so rows are written at least once, batches failing with
+// other errors, e.g. a type mismatch, are dropped and passed to OnError so they don't block later rows, e.g.
+//
+//	writer := clickhouse.NewWriter[Event](db, clickhouse.WriterConfig{MaxRows: 50000, FlushInterval: 5 * time.Second})
+//	defer writer.Close()
+//
+//	writer.Append(Event{Name: "click"})
+type Writer[T any] struct {
+	db     *gorm.DB
+	config WriterConfig
+
+	mu     sync.Mutex
+	rows   []T
+	bytes  int
+	closed bool
+
+	flushMu sync.Mutex
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewWriter starts a Writer inserting rows with db
+func NewWriter[T any](db *gorm.DB, config WriterConfig) *Writer[T] {
+	w := &Writer[T]{
+		db:      db,
+		config:  config.withDefaults(),
+		full:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Append buffers rows for the next flush
+func (w *Writer[T]) Append(rows ...T) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	if w.config.MaxPendingRows > 0 && len(w.rows)+len(rows) > w.config.MaxPendingRows {
+		w.mu.Unlock()
+		return ErrWriterFull
+	}
+
+	w.rows = append(w.rows, rows...)
+	if w.config.MaxBytes > 0 {
+		w.bytes += estimateSize(reflect.ValueOf(rows))
+	}
+	full := len(w.rows) >= w.config.MaxRows || (w.config.MaxBytes > 0 && w.bytes >= w.config.MaxBytes)
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of buffered rows not inserted yet
+func (w *Writer[T]) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.rows)
+}
+
+// Flush inserts all buffered rows, rows of a batch failing with a retryable error stay buffered, other
+// failed batches are dropped and passed to OnError, returns the first error
+func (w *Writer[T]) Flush(ctx context.Context) error {
+	_, err := w.flush(ctx)
+	return err
+}
+
+// flush inserts all buffered rows, kept reports whether the rows of the failed batch stay buffered
+func (w *Writer[T]) flush(ctx context.Context) (kept bool, err error) {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	for {
+		w.mu.Lock()
+		size := min(len(w.rows), w.config.MaxRows)
+		batch := w.rows[:size:size]
+		w.mu.Unlock()
+
+		if size == 0 {
+			return false, err
+		}
+
+		if insertErr := w.insert(ctx, batch); insertErr != nil {
+			if ctx.Err() != nil || w.config.Retry.Retryable(insertErr) {
+				return true, insertErr
+			}
+			if w.config.OnError != nil {
+				w.config.OnError(batch, insertErr)
+			}
+			if err == nil {
+				err = insertErr
+			}
+		}
+
+		w.mu.Lock()
+		if w.rows = w.rows[size:]; len(w.rows) == 0 {
+			w.rows = nil
+		}
+		if w.config.MaxBytes > 0 {
+			w.bytes = max(w.bytes-estimateSize(reflect.ValueOf(batch)), 0)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// Close stops the background flushes and flushes the remaining rows, Append returns ErrWriterClosed afterwards
+func (w *Writer[T]) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.stopped
+	return w.Flush(context.Background())
+}
+
+func (w *Writer[T]) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		case <-w.full:
+		}
+
+		if kept, err := w.flush(context.Background()); kept && w.config.OnError != nil {
+			w.config.OnError(nil, err)
+		}
+	}
+}
+
+// insert inserts batch as a single native batch, retrying retryable errors
+func (w *Writer[T]) insert(ctx context.Context, batch []T) (err error) {
+	retry := w.config.Retry
+	backoff := retry.Backoff
+	for attempt := 1; ; attempt++ {
+		if err = w.db.WithContext(ctx).Create(&batch).Error; err == nil || attempt >= retry.MaxAttempts || !retry.Retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > retry.MaxBackoff {
+			backoff = retry.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package clickhouse_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/hardwk/gorm-driver-clickhouse"
+	"github.com/hardwk/gorm-driver-clickhouse/clickhouseerr"
+	"gorm.io/gorm"
+)
+
+func TestWriter(t *testing.T) {
+	type WriterEvent struct {
+		ID   uint64
+		Name string
+	}
+
+	DB.Migrator().DropTable(&WriterEvent{})
+	if err := DB.AutoMigrate(&WriterEvent{}); err != nil {
+		t.Fatalf("failed to migrate, got error %v", err)
+	}
+
+	writer := clickhouse.NewWriter[WriterEvent](DB, clickhouse.WriterConfig{MaxRows: 100, FlushInterval: 100 * time.Millisecond})
+	for i := 1; i <= 250; i++ {
+		if err := writer.Append(WriterEvent{ID: uint64(i), Name: "event"}); err != nil {
+			t.Fatalf("failed to append, got error %v", err)
+		}
+	}
+
+	if err := writer.Flush(context.Background()); err != nil {
+		t.Fatalf("failed to flush, got error %v", err)
+	}
+	if pending := writer.Pending(); pending != 0 {
+		t.Errorf("expects no pending rows after flush, got %v", pending)
+	}
+
+	writer.Append(WriterEvent{ID: 251, Name: "event"})
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close, got error %v", err)
+	}
+
+	if err := writer.Append(WriterEvent{ID: 252}); !errors.Is(err, clickhouse.ErrWriterClosed) {
+		t.Errorf("expects ErrWriterClosed after close, got %v", err)
+	}
+
+	var count int64
+	if err := DB.Model(&WriterEvent{}).Count(&count).Error; err != nil || count != 251 {
+		t.Errorf("expects 251 written rows, got %v, error %v", count, err)
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	type WriterMockEvent struct {
+		ID uint64
+	}
+
+	dialector, mock := clickhouse.NewMock(clickhouse.Config{})
+	mockDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open mock, got error %v", err)
+	}
+
+	var dropped []interface{}
+	writer := clickhouse.NewWriter[WriterMockEvent](mockDB, clickhouse.WriterConfig{
+		MaxRows:       2,
+		FlushInterval: time.Hour,
+		Retry:         &clickhouse.RetryPolicy{MaxAttempts: 1},
+		OnError: func(rows interface{}, err error) {
+			dropped = append(dropped, rows)
+		},
+	})
+	defer writer.Close()
+
+	if err := writer.Append(make([]WriterMockEvent, 21)...); !errors.Is(err, clickhouse.ErrWriterFull) {
+		t.Errorf("expects ErrWriterFull above 10 times MaxRows pending rows, got %v", err)
+	}
+
+	mock.Fails(`INSERT INTO .writer_mock_events.`, &clickhousego.Exception{Code: clickhouseerr.TimeoutExceeded})
+	writer.Append(WriterMockEvent{ID: 1})
+	if err := writer.Flush(context.Background()); err == nil || writer.Pending() != 1 || len(dropped) != 0 {
+		t.Errorf("rows of retryable errors should be kept, got error %v, %d pending rows", err, writer.Pending())
+	}
+
+	conversionErr := errors.New("converting string to UInt64 is unsupported")
+	mock.Fails(`INSERT INTO .writer_mock_events.`, conversionErr)
+	writer.Append(WriterMockEvent{ID: 2}, WriterMockEvent{ID: 3})
+	if err := writer.Flush(context.Background()); !errors.Is(err, conversionErr) {
+		t.Errorf("expects the error of the dropped batch, got %v", err)
+	}
+	if writer.Pending() != 0 || len(dropped) != 2 {
+		t.Fatalf("batches of errors that aren't retryable should be dropped, got %d pending rows, dropped %v", writer.Pending(), dropped)
+	}
+	if rows, ok := dropped[1].([]WriterMockEvent); !ok || len(rows) != 1 || rows[0].ID != 3 {
+		t.Errorf("expects the dropped rows passed to OnError, got %v", dropped)
+	}
+
+	mock.Reset()
+	writer.Append(WriterMockEvent{ID: 4})
+	if err := writer.Flush(context.Background()); err != nil || len(mock.Statements()) != 1 {
+		t.Errorf("later rows should be inserted, got error %v, statements %v", err, mock.SQL())
+	}
+}